@@ -1,17 +1,39 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"go-booking-system/config"
-	"go-booking-system/internal/domain"
+	"go-booking-system/internal/exchange"
 	"go-booking-system/internal/handler"
+	"go-booking-system/internal/health"
+	"go-booking-system/internal/identity"
+	"go-booking-system/internal/jobs"
+	"go-booking-system/internal/keyring"
+	"go-booking-system/internal/logging"
+	"go-booking-system/internal/mailer"
+	"go-booking-system/internal/middleware"
+	"go-booking-system/internal/migrate"
+	"go-booking-system/internal/money"
 	"go-booking-system/internal/repository"
 	"go-booking-system/internal/routes"
 	"go-booking-system/internal/service"
+	"go-booking-system/internal/sms"
+	"go-booking-system/internal/telemetry"
+	"go-booking-system/migrations"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"gorm.io/gorm"
 
 	_ "go-booking-system/docs"
 
@@ -20,44 +42,416 @@ import (
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
+	// Load and validate configuration before anything else
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
 	}
 
+	// Set up structured logging before anything logs
+	logger := logging.Setup(os.Stdout, logOptions(cfg.Log, cfg.App))
+	if !cfg.App.IsDevelopment() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	// Set up tracing before anything opens spans
+	shutdownTracing, err := telemetry.SetupTracing(context.Background(), telemetry.TracingConfig{
+		ServiceName: cfg.Telemetry.ServiceName,
+		Endpoint:    cfg.Telemetry.OTLPEndpoint,
+		SampleRatio: cfg.Telemetry.SampleRatio,
+	})
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	metrics := telemetry.NewMetrics()
+
 	// Connect to database
-	config.ConnectDatabase()
+	db, err := config.OpenDatabase(cfg.Database, logging.NewGormLogger(logger, cfg.Database.SlowQueryThreshold))
+	if err != nil {
+		fatal("Failed to connect to database", err)
+	}
+	slog.Info("Database connected")
+	if err := db.Use(telemetry.NewGormTracing()); err != nil {
+		fatal("Failed to instrument database", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("Failed to get database pool", err)
+	}
+	if err := metrics.RegisterDB(sqlDB, "postgres"); err != nil {
+		fatal("Failed to register database metrics", err)
+	}
+
+	// Bring the schema up to date, or refuse to run against an old one
+	migrator := newMigrator(sqlDB)
+	if err := migrateOnStart(cfg.Database, migrator); err != nil {
+		fatal("Database schema is not up to date", err)
+	}
 
-	// Auto migrate database
-	config.DB.AutoMigrate(&domain.User{}, &domain.Country{})
+	// Register the dependency checks behind the readiness probe
+	checks := health.NewRegistry()
+	checks.Register(health.Check{Name: "database", Critical: true, Run: health.Database(db)})
+	checks.Register(health.Check{Name: "migrations", Critical: !cfg.Database.AllowPendingMigrations, Run: migrator.CheckApplied})
 
 	// Initialize repositories
-	userRepo := repository.NewUserRepository(config.DB)
-	countryRepo := repository.NewCountryRepository(config.DB)
+	userRepo := repository.NewUserRepository(db)
+	countryRepo := repository.NewCountryRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	revocationRepo := repository.NewRevocationRepository(db)
+	oneTimeTokenRepo := repository.NewOneTimeTokenRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	exchangeRateRepo := repository.NewExchangeRateRepository(db)
+
+	// Load JWT signing keys
+	keys, err := loadKeyring(cfg.Auth)
+	if err != nil {
+		fatal("Failed to load JWT signing keys", err)
+	}
+	slog.Info("Signing access tokens", "kid", keys.ActiveKeyID())
+
+	// Initialize mailer and SMS sender
+	mail := newMailer(cfg.Mail, checks)
+	smsSender := newSMSSender(cfg.SMS)
+
+	// Configure social sign-in providers
+	providers := newIdentityProviders(cfg.OIDC)
 
 	// Initialize services
-	accountService := service.NewAccountService(userRepo, countryRepo)
+	tokenService := service.NewTokenService(keys, revocationRepo, cfg.Auth.AccessTokenTTL)
+	loginGuard := telemetry.InstrumentLoginGuard(service.NewLoginGuard(newLoginAttemptRepository(cfg.Auth, db)), metrics)
+	accountService := service.NewAccountService(userRepo, countryRepo, sessionRepo, oneTimeTokenRepo, recoveryCodeRepo, identityRepo, roleRepo, auditRepo, providers, tokenService, loginGuard, mail, smsSender, service.AccountSettings{
+		AppBaseURL:      cfg.App.BaseURL,
+		RefreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+		TOTPIssuer:      cfg.Auth.TOTPIssuer,
+	})
+	roleService := service.NewRoleService(userRepo, roleRepo, auditRepo, tokenService)
+	countryService := service.NewCountryService(countryRepo)
+	rounding, err := money.ParseRoundingMode(cfg.Currency.Rounding)
+	if err != nil {
+		fatal("Invalid currency rounding", err)
+	}
+	var rateProvider exchange.Provider
+	if cfg.Currency.RateSource != "" {
+		rateProvider = exchange.NewJSONProvider(cfg.Currency.RateSource, cfg.Currency.RateFetchTimeout)
+	}
+	currencyService := service.NewCurrencyService(countryRepo, exchangeRateRepo, rateProvider, service.CurrencySettings{
+		Rounding:      rounding,
+		RateCacheTTL:  cfg.Currency.RateCacheTTL,
+		RateBase:      cfg.Currency.RateBase,
+		RateMaxChange: cfg.Currency.RateMaxChange,
+	})
+
+	// Start background jobs
+	gracePeriod := cfg.App.AccountDeletionGracePeriod()
+	anonymizer := jobs.NewPeriodic("anonymize-deleted-accounts", time.Hour, func(ctx context.Context) error {
+		count, err := accountService.AnonymizeDeletedAccounts(ctx, gracePeriod)
+		if count > 0 {
+			slog.InfoContext(ctx, "Anonymized deleted accounts", "count", count)
+		}
+		return err
+	})
+	anonymizer.Start()
+	socialStatePurger := jobs.NewPeriodic("purge-expired-social-sign-ins", time.Hour, func(ctx context.Context) error {
+		_, err := accountService.PurgeExpiredSocialSignIns(ctx)
+		return err
+	})
+	socialStatePurger.Start()
+	revocationPurger := jobs.NewPeriodic("purge-expired-revocations", time.Hour, func(ctx context.Context) error {
+		_, err := tokenService.PurgeExpiredRevocations(ctx)
+		return err
+	})
+	revocationPurger.Start()
+	loginAttemptPurger := jobs.NewPeriodic("purge-expired-login-attempts", time.Hour, func(ctx context.Context) error {
+		_, err := loginGuard.PurgeExpired(ctx)
+		return err
+	})
+	loginAttemptPurger.Start()
+	rateRefresher := jobs.NewPeriodic("refresh-exchange-rates", cfg.Currency.RateRefreshInterval, func(ctx context.Context) error {
+		result, err := currencyService.RefreshRates(ctx)
+		if err != nil {
+			return err
+		}
+		if result.Recorded > 0 || result.Rejected > 0 {
+			slog.InfoContext(ctx, "Refreshed exchange rates", "recorded", result.Recorded, "unchanged", result.Unchanged, "rejected", result.Rejected, "missing", result.Missing)
+		}
+		return nil
+	})
+	if rateProvider != nil {
+		rateRefresher.Start()
+	}
 
 	// Initialize handlers
 	accountHandler := handler.NewAccountHandler(accountService)
-	healthHandler := handler.NewHealthHandler()
+	adminHandler := handler.NewAdminHandler(roleService, countryService, currencyService)
+	countryHandler := handler.NewCountryHandler(countryService)
+	currencyHandler := handler.NewCurrencyHandler(currencyService)
+	healthHandler := handler.NewHealthHandler(checks)
+	wellKnownHandler := handler.NewWellKnownHandler(tokenService)
+
+	// Initialize Gin router; requests are logged by AccessLog instead of gin's logger
+	router := gin.New()
+	router.Use(middleware.AccessLog(logger), middleware.Recovery())
 
-	// Initialize Gin router
-	router := gin.Default()
+	// Only trust X-Forwarded-For from our own load balancers, otherwise
+	// clients could spoof their IP and dodge per-IP sign-in throttling
+	if len(cfg.HTTP.TrustedProxies) > 0 {
+		if err := router.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+			fatal("Invalid TRUSTED_PROXIES", err)
+		}
+	}
+
+	// Trace and time every request; both label spans and series by route template
+	router.Use(otelgin.Middleware(cfg.Telemetry.ServiceName), middleware.Metrics(metrics))
 
 	// Setup routes with handler dependencies
-	routes.SetupRoutes(router, accountHandler, healthHandler)
+	routes.SetupRoutes(router, accountHandler, adminHandler, countryHandler, currencyHandler, healthHandler, wellKnownHandler, tokenService, routes.Timeouts{
+		Health:  cfg.HTTP.HealthRequestTimeout,
+		Account: cfg.HTTP.AccountRequestTimeout,
+		Admin:   cfg.HTTP.AdminRequestTimeout,
+	})
 
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	server := newServer(cfg.HTTP, router)
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("Server starting", "addr", server.Addr, "tls", cfg.HTTP.TLSCertFile != "")
+		if cfg.HTTP.TLSCertFile != "" {
+			serverErr <- server.ListenAndServeTLS(cfg.HTTP.TLSCertFile, cfg.HTTP.TLSKeyFile)
+		} else {
+			serverErr <- server.ListenAndServe()
+		}
+	}()
+
+	// Prometheus metrics get a listener of their own, kept off the public port
+	metricsServer := newMetricsServer(cfg.Telemetry, metrics)
+	if metricsServer != nil {
+		go func() {
+			slog.Info("Metrics server starting", "addr", metricsServer.Addr)
+			serverErr <- metricsServer.ListenAndServe()
+		}()
+	}
+
+	// Wait for SIGINT/SIGTERM, or for the server to fail on its own
+	stop, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case err := <-serverErr:
+		fatal("Server stopped", err)
+	case <-stop.Done():
+	}
+	// A second signal kills the process instead of waiting for the drain
+	stopSignals()
+	slog.Info("Shutting down")
+
+	// Fail readiness first and keep serving until load balancers notice,
+	// otherwise they keep routing requests to a closed listener
+	checks.Drain()
+	time.Sleep(cfg.HTTP.ShutdownDrainDelay)
+
+	// Everything below shares one deadline
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting connections and let in-flight requests finish
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Failed to drain requests", "error", err)
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			slog.Error("Failed to stop metrics server", "error", err)
+		}
+	}
+
+	// Stop background work, which still needs the database
+	for _, job := range []*jobs.Periodic{anonymizer, socialStatePurger, revocationPurger, loginAttemptPurger, rateRefresher} {
+		if err := job.Stop(ctx); err != nil {
+			slog.Error("Failed to stop job", "error", err)
+		}
+	}
+	if err := accountService.WaitForBackgroundWork(ctx); err != nil {
+		slog.Error("Failed to finish background work", "error", err)
+	}
+
+	// Flush buffered spans and close the database pool
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	if err := sqlDB.Close(); err != nil {
+		slog.Error("Failed to close database", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// newServer creates the HTTP server for handler
+func newServer(cfg config.HTTP, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
 	}
+}
+
+// newMetricsServer creates the server for /metrics, or nil when metrics are not served
+func newMetricsServer(cfg config.Telemetry, metrics *telemetry.Metrics) *http.Server {
+	if !cfg.MetricsEnabled() {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	return &http.Server{
+		Addr:              cfg.MetricsAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// logOptions picks the log level and format for the environment unless the
+// configuration sets them; Validate has already checked both
+func logOptions(cfg config.Log, app config.App) logging.Options {
+	opts := logging.DefaultOptions(app.Env)
+	if level, err := logging.ParseLevel(cfg.Level); err == nil {
+		opts.Level = level
+	}
+	if format, err := logging.ParseFormat(cfg.Format); err == nil {
+		opts.Format = format
+	}
+	return opts
+}
+
+// fatal logs a startup failure and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// newMigrator loads the embedded migrations
+func newMigrator(sqlDB *sql.DB) *migrate.Migrator {
+	migrationList, err := migrate.Load(migrations.FS)
+	if err != nil {
+		fatal("Failed to load migrations", err)
+	}
+	return migrate.New(sqlDB, migrationList)
+}
+
+// migrateOnStart applies pending migrations when configured to. Otherwise a
+// schema that is behind stops startup unless pending migrations are allowed.
+func migrateOnStart(cfg config.Database, migrator *migrate.Migrator) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if cfg.MigrateOnStart {
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+		}
+		return err
+	}
+
+	err := migrator.CheckApplied(ctx)
+	if errors.Is(err, migrate.ErrSchemaBehind) && cfg.AllowPendingMigrations {
+		slog.Warn("Starting with pending migrations", "error", err)
+		return nil
+	}
+	return err
+}
+
+// loadKeyring loads asymmetric signing keys from the keys directory. Without
+// one it falls back to HS256 with the shared secret, which is for local
+// development only since other services cannot verify those tokens.
+func loadKeyring(cfg config.Auth) (*keyring.Keyring, error) {
+	if cfg.JWTKeysDir == "" {
+		slog.Warn("JWT_KEYS_DIR not set, signing tokens with JWT_SECRET (HS256)")
+		return keyring.NewHMAC(cfg.JWTSecret)
+	}
+	return keyring.Load(cfg.JWTKeysDir, cfg.JWTActiveKeyID)
+}
 
-	log.Printf("Server starting on port %s...", port)
-	log.Printf("Swagger docs available at http://localhost:%s/swagger/index.html", port)
-	router.Run(":" + port)
+// newMailer selects the mail transport: "smtp" sends through the relay,
+// anything else writes messages to the log path (or the log).
+// The relay is checked for readiness but is not critical: while it is down
+// sign-ups still work and users can ask for their emails again later.
+func newMailer(cfg config.Mail, checks *health.Registry) mailer.Mailer {
+	if cfg.Driver == "smtp" {
+		checks.Register(health.Check{
+			Name: "smtp",
+			Run:  health.TCP(net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort)),
+		})
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	}
+	return mailer.NewLogMailer(cfg.LogPath)
+}
+
+// newSMSSender selects the SMS transport: "twilio" sends through Twilio,
+// anything else writes messages to the log path (or the log).
+func newSMSSender(cfg config.SMS) sms.Sender {
+	if cfg.Driver == "twilio" {
+		return sms.NewTwilioSender(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.From)
+	}
+	return sms.NewLogSender(cfg.LogPath)
+}
+
+// newIdentityProviders configures social sign-in. A provider is enabled when
+// its client id is set.
+func newIdentityProviders(cfg config.OIDC) identity.Registry {
+	providers := identity.Registry{}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if google := cfg.Google; google.ClientID != "" {
+		provider, err := identity.NewOIDCProvider(ctx, identity.OIDCConfig{
+			Issuer:       google.Issuer,
+			ClientID:     google.ClientID,
+			ClientSecret: google.ClientSecret,
+			RedirectURL:  google.RedirectURL,
+			Scopes:       []string{"email", "profile"},
+		})
+		if err != nil {
+			fatal("Failed to configure Google sign-in", err)
+		}
+		providers["google"] = provider
+	}
+
+	if apple := cfg.Apple; apple.ClientID != "" {
+		secret, err := identity.AppleClientSecret(apple.TeamID, apple.KeyID, apple.ClientID, apple.PrivateKeyPath)
+		if err != nil {
+			fatal("Failed to load Apple sign-in key", err)
+		}
+		provider, err := identity.NewOIDCProvider(ctx, identity.OIDCConfig{
+			Issuer:           apple.Issuer,
+			ClientID:         apple.ClientID,
+			RedirectURL:      apple.RedirectURL,
+			Scopes:           []string{"email", "name"},
+			ClientSecretFunc: secret,
+			// Apple only returns email/name scopes to a form post
+			AuthParams: map[string]string{"response_mode": "form_post"},
+		})
+		if err != nil {
+			fatal("Failed to configure Apple sign-in", err)
+		}
+		providers["apple"] = provider
+	}
+
+	return providers
+}
+
+// newLoginAttemptRepository selects where failed sign-ins are counted:
+// "memory" for a single instance, Postgres otherwise
+func newLoginAttemptRepository(cfg config.Auth, db *gorm.DB) repository.LoginAttemptRepository {
+	if cfg.LoginAttemptStore == "memory" {
+		return repository.NewMemoryLoginAttemptRepository()
+	}
+	return repository.NewLoginAttemptRepository(db)
 }
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"go-booking-system/internal/dto"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// csvColumns names the CSV columns after the JSON fields of dto.CountryRequest,
+// in declaration order
+func csvColumns() []string {
+	t := reflect.TypeOf(dto.CountryRequest{})
+	columns := make([]string, t.NumField())
+	for i := range columns {
+		columns[i], _, _ = strings.Cut(t.Field(i).Tag.Get("json"), ",")
+	}
+	return columns
+}
+
+// writeCSV writes a header row and one row per record; unset numbers are empty cells
+func writeCSV(w io.Writer, records []dto.CountryRequest) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvColumns()); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		v := reflect.ValueOf(record)
+		row := make([]string, v.NumField())
+		for i := range row {
+			row[i] = formatCell(v.Field(i))
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// readCSV reads records written by writeCSV. Columns may come in any order
+// and may be left out, which leaves the field empty.
+func readCSV(r io.Reader) ([]dto.CountryRequest, error) {
+	in := csv.NewReader(r)
+	header, err := in.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	fieldIndex := map[string]int{}
+	for i, column := range csvColumns() {
+		fieldIndex[column] = i
+	}
+	fields := make([]int, len(header))
+	for i, column := range header {
+		index, ok := fieldIndex[strings.TrimSpace(column)]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		fields[i] = index
+	}
+
+	var records []dto.CountryRequest
+	for {
+		row, err := in.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var record dto.CountryRequest
+		v := reflect.ValueOf(&record).Elem()
+		for i, cell := range row {
+			if err := parseCell(v.Field(fields[i]), strings.TrimSpace(cell)); err != nil {
+				line, _ := in.FieldPos(i)
+				return nil, fmt.Errorf("line %d, column %s: %w", line, header[i], err)
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+// formatCell renders one field of a record
+func formatCell(field reflect.Value) string {
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return field.String()
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool())
+	case reflect.Int:
+		return strconv.FormatInt(field.Int(), 10)
+	case reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, 64)
+	default:
+		panic("unsupported country field kind " + field.Kind().String())
+	}
+}
+
+// parseCell sets one field of a record from a CSV cell; an empty cell leaves
+// the field unset
+func parseCell(field reflect.Value, cell string) error {
+	if cell == "" {
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", cell)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(cell)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", cell)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", cell)
+		}
+		field.SetFloat(f)
+	default:
+		panic("unsupported country field kind " + field.Kind().String())
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"go-booking-system/internal/dto"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	fee, callingCode := 12, 81
+	rate, surcharge := 149.5, 3.6
+	records := []dto.CountryRequest{
+		{
+			Shortname:                  "JP",
+			Name:                       "Japan",
+			NameJaJp:                   "日本",
+			CallingCode:                &callingCode,
+			CurrencyCode:               "JPY",
+			CurrencySymbol:             "¥",
+			CurrencyRate:               &rate,
+			NoDecimalCurrency:          true,
+			TimezoneName:               "Asia/Tokyo",
+			PlatformFeePercent:         &fee,
+			PaypalTransactionSurcharge: &surcharge,
+		},
+		// Commas, quotes and unset numbers survive as well
+		{Shortname: "CZ", Name: `Czechia, "Czech Republic"`, CurrencyCode: "CZK", Disabled: true},
+	}
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	header, _, _ := strings.Cut(buf.String(), "\n")
+	if !strings.HasPrefix(header, "shortname,name,name_variant,") {
+		t.Errorf("header = %q, want the JSON field names", header)
+	}
+
+	got, err := readCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("round trip = %+v, want %+v", got, records)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []dto.CountryRequest
+		wantErr string
+	}{
+		{
+			name:  "columns in any order, some left out",
+			input: "currency_code,shortname,name\nJPY,JP,Japan\n",
+			want:  []dto.CountryRequest{{Shortname: "JP", Name: "Japan", CurrencyCode: "JPY"}},
+		},
+		{
+			name:  "cells are trimmed",
+			input: "shortname, disabled\n TH , true\n",
+			want:  []dto.CountryRequest{{Shortname: "TH", Disabled: true}},
+		},
+		{name: "header only", input: "shortname,name\n"},
+		{name: "empty file", input: "", wantErr: "read header"},
+		{name: "unknown column", input: "shortname,fee\nJP,12\n", wantErr: `unknown column "fee"`},
+		{name: "not a number", input: "shortname,platform_fee_percent\nJP,twelve\n", wantErr: `line 2, column platform_fee_percent: "twelve" is not a whole number`},
+		{name: "not a boolean", input: "shortname,disabled\nJP,maybe\n", wantErr: `"maybe" is not true or false`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCSV(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go-booking-system/config"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/logging"
+	"go-booking-system/internal/repository"
+	"go-booking-system/internal/service"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// countries exports and bulk-imports country reference data:
+//
+//	go run ./cmd/countries export [-format csv] [-o countries.json]
+//	go run ./cmd/countries import countries.csv
+//
+// Records have the fields of dto.CountryRequest, so an export can be edited
+// and imported again. An import validates every record first, then creates
+// or replaces countries by shortname in one transaction; countries missing
+// from the file are left alone. Changes are audited like those made through
+// /api/admin.
+func main() {
+	format := flag.String("format", "", "json or csv; defaults to the file extension, else json")
+	output := flag.String("o", "", "file export writes to instead of stdout")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: countries [flags] export | import FILE")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := config.OpenDatabase(cfg.Database, logging.NewGormLogger(slog.Default(), 0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	countryService := service.NewCountryService(repository.NewCountryRepository(db))
+	ctx := context.Background()
+
+	switch args[0] {
+	case "export":
+		if err := export(ctx, countryService, *output, formatFor(*format, *output)); err != nil {
+			log.Fatal("Failed to export countries: ", err)
+		}
+
+	case "import":
+		if len(args) != 2 {
+			log.Fatal("import needs a FILE, e.g. import countries.csv")
+		}
+		records, err := readRecords(args[1], formatFor(*format, args[1]))
+		if err != nil {
+			log.Fatal("Failed to read countries: ", err)
+		}
+		if err := validateRecords(records); err != nil {
+			log.Fatal("Invalid countries, nothing was imported:\n", err)
+		}
+		result, err := countryService.ImportCountries(ctx, "", records)
+		if err != nil {
+			log.Fatal("Failed to import countries: ", err)
+		}
+		log.Printf("Created %d, updated %d, unchanged %d", result.Created, result.Updated, result.Unchanged)
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// formatFor picks the file format from the flag, then the file extension
+func formatFor(format, path string) string {
+	if format != "" {
+		return format
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return "csv"
+	}
+	return "json"
+}
+
+// export writes every country, disabled ones included, to path or stdout
+func export(ctx context.Context, countryService service.CountryService, path, format string) error {
+	countries, err := countryService.ListAllCountries(ctx)
+	if err != nil {
+		return err
+	}
+	records := make([]dto.CountryRequest, len(countries))
+	for i, country := range countries {
+		records[i] = country.CountryRequest
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch format {
+	case "csv":
+		err = writeCSV(w, records)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(records)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		log.Printf("Exported %d countries to %s", len(records), path)
+	}
+	return nil
+}
+
+// readRecords parses a CSV or JSON file of country records
+func readRecords(path, format string) ([]dto.CountryRequest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch format {
+	case "csv":
+		return readCSV(f)
+	case "json":
+		var records []dto.CountryRequest
+		decoder := json.NewDecoder(f)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&records); err != nil {
+			return nil, err
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// validateRecords applies the rules the admin API binds requests with, and
+// reports every problem at once
+func validateRecords(records []dto.CountryRequest) error {
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			return name
+		})
+	}
+
+	var errs []error
+	for i := range records {
+		err := binding.Validator.ValidateStruct(&records[i])
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			if err != nil {
+				errs = append(errs, fmt.Errorf("record %d: %w", i+1, err))
+			}
+			continue
+		}
+		for _, fe := range validationErrs {
+			rule := fe.Tag()
+			if fe.Param() != "" {
+				rule += "=" + fe.Param()
+			}
+			errs = append(errs, fmt.Errorf("record %d (%s): %s fails %s", i+1, records[i].Shortname, fe.Field(), rule))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"go-booking-system/internal/dto"
+	"strings"
+	"testing"
+)
+
+func TestValidateRecords(t *testing.T) {
+	valid := func() dto.CountryRequest {
+		return dto.CountryRequest{Shortname: "JP", Name: "Japan", CurrencyCode: "JPY"}
+	}
+	fee := func(v int) *int { return &v }
+
+	tests := []struct {
+		name    string
+		change  func(r *dto.CountryRequest)
+		wantErr string
+	}{
+		{"valid", func(r *dto.CountryRequest) {}, ""},
+		{"three letter shortname", func(r *dto.CountryRequest) { r.Shortname = "JPN" }, ""},
+		{"fee of 0", func(r *dto.CountryRequest) { r.PlatformFeePercent = fee(0) }, ""},
+		{"fee of 100", func(r *dto.CountryRequest) { r.PlatformFeePercent = fee(100) }, ""},
+		{"fee above 100", func(r *dto.CountryRequest) { r.PlatformFeePercent = fee(101) }, "platform_fee_percent fails max=100"},
+		{"negative fee", func(r *dto.CountryRequest) { r.PlatformFeePercent = fee(-1) }, "platform_fee_percent fails min=0"},
+		{"unknown currency", func(r *dto.CountryRequest) { r.CurrencyCode = "XYZ" }, "currency_code fails iso4217"},
+		{"lower case currency", func(r *dto.CountryRequest) { r.CurrencyCode = "jpy" }, "currency_code fails iso4217"},
+		{"missing currency", func(r *dto.CountryRequest) { r.CurrencyCode = "" }, "currency_code fails required"},
+		{"one letter shortname", func(r *dto.CountryRequest) { r.Shortname = "J" }, "shortname fails min=2"},
+		{"four letter shortname", func(r *dto.CountryRequest) { r.Shortname = "JAPN" }, "shortname fails max=3"},
+		{"shortname with digits", func(r *dto.CountryRequest) { r.Shortname = "J1" }, "shortname fails alpha"},
+		{"unknown time zone", func(r *dto.CountryRequest) { r.TimezoneName = "Asia/Atlantis" }, "timezone_name fails timezone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := valid()
+			tt.change(&record)
+
+			err := validateRecords([]dto.CountryRequest{record})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRecordsReportsEveryProblem(t *testing.T) {
+	fee := 150
+	records := []dto.CountryRequest{
+		{Shortname: "JP", Name: "Japan", CurrencyCode: "JPY"},
+		{Shortname: "TH", Name: "Thailand", CurrencyCode: "XYZ", PlatformFeePercent: &fee},
+		{Shortname: "KR", Name: "South Korea", CurrencyCode: "KRW"},
+		{Shortname: "C", CurrencyCode: "CZK"},
+	}
+
+	err := validateRecords(records)
+	if err == nil {
+		t.Fatal("error = nil, want every problem")
+	}
+	want := []string{
+		"record 2 (TH): currency_code fails iso4217",
+		"record 2 (TH): platform_fee_percent fails max=100",
+		"record 4 (C): shortname fails min=2",
+		"record 4 (C): name fails required",
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != len(want) {
+		t.Errorf("got %d problems, want %d:\n%v", len(lines), len(want), err)
+	}
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("error does not report %q:\n%v", w, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"go-booking-system/config"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/logging"
+	"go-booking-system/internal/repository"
+	"go-booking-system/internal/service"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// grantrole grants a role from the command line, which is how the first admin
+// is created. Further changes can go through /api/admin; both are audited.
+//
+//	go run ./cmd/grantrole -email admin@example.com -role admin
+func main() {
+	email := flag.String("email", "", "email of the user to grant the role to")
+	role := flag.String("role", string(domain.RoleAdmin), "role to grant: host or admin")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := config.OpenDatabase(cfg.Database, logging.NewGormLogger(slog.Default(), 0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	user, err := userRepo.FindByEmail(ctx, strings.TrimSpace(*email))
+	if err != nil {
+		log.Fatalf("Failed to find user %s: %v", *email, err)
+	}
+
+	// Granting never revokes tokens, so no token service is needed
+	roleService := service.NewRoleService(userRepo, repository.NewRoleRepository(db), repository.NewAuditRepository(db), nil)
+	result, err := roleService.GrantRole(ctx, "", user.UUID, domain.Role(*role), "")
+	if err != nil {
+		log.Fatal("Failed to grant role:", err)
+	}
+
+	log.Printf("User %s now has roles %s", result.UUID, strings.Join(result.Roles, ", "))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// keygen writes a new JWT signing key pair into the keys directory:
+// <kid>.pem (private, loaded by the API) and <kid>.pub.pem (public).
+//
+// Rotation:
+//  1. run keygen to add the new key, deploy, and let JWKS consumers pick it up
+//  2. set JWT_ACTIVE_KEY_ID to the new kid and deploy
+//  3. delete the old <kid>.pem so it can only verify, and once the access
+//     token TTL has passed delete its <kid>.pub.pem to retire it
+func main() {
+	dir := flag.String("dir", "keys", "keys directory")
+	kid := flag.String("kid", time.Now().UTC().Format("2006-01-02"), "key id")
+	alg := flag.String("alg", "EdDSA", "signing algorithm: EdDSA or RS256")
+	flag.Parse()
+
+	if err := generate(*dir, *kid, *alg); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Wrote %s key %q to %s", *alg, *kid, *dir)
+}
+
+// generate creates a key pair for alg and writes it to dir as kid
+func generate(dir, kid, alg string) error {
+	var (
+		private crypto.Signer
+		err     error
+	)
+	switch alg {
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 3072)
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if err != nil {
+		return fmt.Errorf("generate key: %w", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return fmt.Errorf("encode private key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return fmt.Errorf("encode public key: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create keys directory: %w", err)
+	}
+
+	privatePath := filepath.Join(dir, kid+".pem")
+	if _, err := os.Stat(privatePath); err == nil {
+		return fmt.Errorf("key %s already exists", privatePath)
+	}
+	if err := writePEM(privatePath, "PRIVATE KEY", privateDER, 0o600); err != nil {
+		return err
+	}
+	return writePEM(filepath.Join(dir, kid+".pub.pem"), "PUBLIC KEY", publicDER, 0o644)
+}
+
+// writePEM writes a single PEM block to path
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("write key: %w", err)
+	}
+	return nil
+}
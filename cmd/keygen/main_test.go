@@ -0,0 +1,75 @@
+package main
+
+import (
+	"go-booking-system/internal/keyring"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, alg := range []string{"EdDSA", "RS256"} {
+		t.Run(alg, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "keys")
+			if err := generate(dir, "2025-01", alg); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Stat(filepath.Join(dir, "2025-01.pem"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0o600 {
+				t.Errorf("private key permissions = %o, want 600", perm)
+			}
+
+			// The API loads what keygen writes and signs with the requested algorithm
+			keys, err := keyring.Load(dir, "2025-01")
+			if err != nil {
+				t.Fatal(err)
+			}
+			signed, err := keys.Sign(jwt.RegisteredClaims{Subject: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := jwt.Parse(signed, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.Method.Alg() != alg {
+				t.Errorf("alg = %s, want %s", token.Method.Alg(), alg)
+			}
+			if jwks := keys.PublicJWKS(); len(jwks.Keys) != 1 || jwks.Keys[0].Alg != alg {
+				t.Errorf("JWKS = %+v, want one %s key", jwks.Keys, alg)
+			}
+		})
+	}
+}
+
+func TestGenerateRejects(t *testing.T) {
+	dir := t.TempDir()
+	if err := generate(dir, "2025-01", "EdDSA"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		kid     string
+		alg     string
+		wantErr string
+	}{
+		{name: "existing key", kid: "2025-01", alg: "EdDSA", wantErr: "already exists"},
+		{name: "unsupported algorithm", kid: "2025-02", alg: "HS256", wantErr: "unsupported algorithm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := generate(dir, tt.kid, tt.alg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("generate error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
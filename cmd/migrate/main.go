@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go-booking-system/config"
+	"go-booking-system/internal/logging"
+	"go-booking-system/internal/migrate"
+	"go-booking-system/migrations"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// migrate manages the database schema:
+//
+//	go run ./cmd/migrate up            apply every pending migration
+//	go run ./cmd/migrate down N        roll back the N most recent migrations
+//	go run ./cmd/migrate status        list migrations and when they were applied
+//	go run ./cmd/migrate create NAME   add an empty migration pair to -dir
+//
+// The migrations are compiled in, so a built binary carries its own schema.
+func main() {
+	dir := flag.String("dir", "migrations", "directory create writes new migrations to")
+	timeout := flag.Duration("timeout", 10*time.Minute, "give up after this long")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: migrate [flags] up | down N | status | create NAME")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// create only writes files, so it works without a database
+	if args[0] == "create" {
+		if len(args) != 2 {
+			log.Fatal("create needs a NAME, e.g. create add_bookings")
+		}
+		if err := create(*dir, args[1]); err != nil {
+			log.Fatal("Failed to create migration: ", err)
+		}
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := config.OpenDatabase(cfg.Database, logging.NewGormLogger(slog.Default(), 0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	migrationList, err := migrate.Load(migrations.FS)
+	if err != nil {
+		log.Fatal("Failed to load migrations: ", err)
+	}
+	migrator := migrate.New(sqlDB, migrationList)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Printf("Applied %d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			log.Println("Schema is up to date")
+		}
+
+	case "down":
+		if len(args) != 2 {
+			log.Fatal("down needs the number of migrations to roll back, e.g. down 1")
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			log.Fatalf("Invalid number of migrations %q", args[1])
+		}
+		rolledBack, err := migrator.Down(ctx, n)
+		for _, m := range rolledBack {
+			log.Printf("Rolled back %d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Local().Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-40s  %s\n", status.Version, status.Name, applied)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// migrationName keeps names safe to use in file names
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// create writes an empty up/down pair numbered after the newest migration
+func create(dir, name string) error {
+	if !migrationName.MatchString(name) {
+		return fmt.Errorf("name %q must be lowercase letters, digits and underscores", name)
+	}
+
+	existing, err := migrate.Load(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+	var version int64 = 1
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", version, name))
+	for _, file := range []struct{ suffix, body string }{
+		{".up.sql", "-- " + name + "\n"},
+		{".down.sql", "-- Undo " + name + "\n"},
+	} {
+		// O_EXCL so an existing migration is never overwritten
+		f, err := os.OpenFile(base+file.suffix, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(file.body)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("Created %s", base+file.suffix)
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+// Package config loads the application configuration once at startup.
+//
+// Values are layered, later sources overriding earlier ones: built-in
+// defaults, the YAML file named by CONFIG_FILE, the .env file and finally
+// the process environment. Each field names its environment variable in
+// its env tag and its YAML key in its yaml tag.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+)
+
+// Config is the complete application configuration
+type Config struct {
+	App       App       `yaml:"app"`
+	HTTP      HTTP      `yaml:"http"`
+	Database  Database  `yaml:"database"`
+	Log       Log       `yaml:"log"`
+	Telemetry Telemetry `yaml:"telemetry"`
+	Auth      Auth      `yaml:"auth"`
+	Mail      Mail      `yaml:"mail"`
+	SMS       SMS       `yaml:"sms"`
+	OIDC      OIDC      `yaml:"oidc"`
+	Currency  Currency  `yaml:"currency"`
+}
+
+// App holds settings that apply across the service
+type App struct {
+	// Env is the deployment environment, "production" unless set; only
+	// "development" relaxes defaults, so a forgotten APP_ENV fails safe
+	Env string `yaml:"env" env:"APP_ENV"`
+	// BaseURL is the frontend origin used to build links in emails
+	BaseURL string `yaml:"base_url" env:"APP_BASE_URL"`
+	// AccountDeletionGraceDays is how long a deleted account keeps its
+	// personal data before it is anonymised
+	AccountDeletionGraceDays int `yaml:"account_deletion_grace_days" env:"ACCOUNT_DELETION_GRACE_DAYS"`
+}
+
+// AccountDeletionGracePeriod returns AccountDeletionGraceDays as a duration
+func (a App) AccountDeletionGracePeriod() time.Duration {
+	return time.Duration(a.AccountDeletionGraceDays) * 24 * time.Hour
+}
+
+// IsDevelopment reports whether the service runs on a developer machine
+func (a App) IsDevelopment() bool {
+	return a.Env == "development"
+}
+
+// HTTP configures the server, request deadlines and shutdown
+type HTTP struct {
+	Port              string        `yaml:"port" env:"PORT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	// WriteTimeout must outlast every request deadline, or slow requests get
+	// cut off before they can report a timeout
+	WriteTimeout   time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout    time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	MaxHeaderBytes int           `yaml:"max_header_bytes" env:"HTTP_MAX_HEADER_BYTES"`
+	// TLSCertFile and TLSKeyFile serve HTTPS directly when both are set
+	TLSCertFile string `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile  string `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+	// TrustedProxies may set X-Forwarded-For; clients could otherwise spoof
+	// their IP and dodge per-IP sign-in throttling
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+
+	// Request deadlines per route group; zero means none
+	HealthRequestTimeout  time.Duration `yaml:"health_request_timeout" env:"REQUEST_TIMEOUT_HEALTH"`
+	AccountRequestTimeout time.Duration `yaml:"account_request_timeout" env:"REQUEST_TIMEOUT_ACCOUNT"`
+	AdminRequestTimeout   time.Duration `yaml:"admin_request_timeout" env:"REQUEST_TIMEOUT_ADMIN"`
+
+	// ShutdownDrainDelay keeps serving after readiness fails so load
+	// balancers notice; ShutdownTimeout bounds the rest of the shutdown
+	ShutdownDrainDelay time.Duration `yaml:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+// Log selects the log level and format. Empty values follow App.Env.
+type Log struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// Telemetry points tracing at an OTLP collector and places the metrics endpoint
+type Telemetry struct {
+	ServiceName string `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	// OTLPEndpoint is the OTLP/HTTP collector URL; tracing is off when empty
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG"`
+	// MetricsAddr is where /metrics is served, on a listener of its own so
+	// it stays off the public port; "off" turns metrics off
+	MetricsAddr string `yaml:"metrics_addr" env:"METRICS_ADDR"`
+}
+
+// MetricsOff is the MetricsAddr that turns the metrics listener off. An
+// empty variable cannot do it, since empty variables keep the default.
+const MetricsOff = "off"
+
+// MetricsEnabled reports whether /metrics is served
+func (t Telemetry) MetricsEnabled() bool {
+	return t.MetricsAddr != "" && t.MetricsAddr != MetricsOff
+}
+
+// Auth configures token signing, token lifetimes and sign-in protection
+type Auth struct {
+	// JWTKeysDir holds asymmetric signing keys. Without it tokens are signed
+	// with JWTSecret (HS256), which is for local development only since other
+	// services cannot verify those tokens.
+	JWTKeysDir     string `yaml:"jwt_keys_dir" env:"JWT_KEYS_DIR"`
+	JWTActiveKeyID string `yaml:"jwt_active_key_id" env:"JWT_ACTIVE_KEY_ID"`
+	JWTSecret      string `yaml:"jwt_secret" env:"JWT_SECRET"`
+
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+
+	// LoginAttemptStore is where failed sign-ins are counted: "postgres", or
+	// "memory" for a single instance
+	LoginAttemptStore string `yaml:"login_attempt_store" env:"LOGIN_ATTEMPT_STORE"`
+	// TOTPIssuer labels accounts in authenticator apps
+	TOTPIssuer string `yaml:"totp_issuer" env:"TOTP_ISSUER"`
+}
+
+// Mail selects the mail transport: "smtp" sends through the relay, "log"
+// writes messages to LogPath (or the log) for local development
+type Mail struct {
+	Driver       string `yaml:"driver" env:"MAIL_DRIVER"`
+	From         string `yaml:"from" env:"MAIL_FROM"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+	LogPath      string `yaml:"log_path" env:"MAIL_LOG_PATH"`
+}
+
+// SMS selects the SMS transport: "twilio" sends from From (a number or
+// messaging service SID), "log" writes texts to LogPath (or the log)
+type SMS struct {
+	Driver           string `yaml:"driver" env:"SMS_DRIVER"`
+	From             string `yaml:"from" env:"SMS_FROM"`
+	TwilioAccountSID string `yaml:"twilio_account_sid" env:"TWILIO_ACCOUNT_SID"`
+	TwilioAuthToken  string `yaml:"twilio_auth_token" env:"TWILIO_AUTH_TOKEN"`
+	LogPath          string `yaml:"log_path" env:"SMS_LOG_PATH"`
+}
+
+// OIDC configures social sign-in; a provider is enabled when its client id is set
+type OIDC struct {
+	Google GoogleProvider `yaml:"google"`
+	Apple  AppleProvider  `yaml:"apple"`
+}
+
+// GoogleProvider configures sign-in with Google
+type GoogleProvider struct {
+	// Issuer can point at another issuer, e.g. a local test issuer
+	Issuer       string `yaml:"issuer" env:"OIDC_GOOGLE_ISSUER"`
+	ClientID     string `yaml:"client_id" env:"OIDC_GOOGLE_CLIENT_ID"`
+	ClientSecret string `yaml:"client_secret" env:"OIDC_GOOGLE_CLIENT_SECRET"`
+	RedirectURL  string `yaml:"redirect_url" env:"OIDC_GOOGLE_REDIRECT_URL"`
+}
+
+// AppleProvider configures Sign in with Apple, whose client secret is a JWT
+// signed with the .p8 key at PrivateKeyPath
+type AppleProvider struct {
+	Issuer         string `yaml:"issuer" env:"OIDC_APPLE_ISSUER"`
+	ClientID       string `yaml:"client_id" env:"OIDC_APPLE_CLIENT_ID"`
+	RedirectURL    string `yaml:"redirect_url" env:"OIDC_APPLE_REDIRECT_URL"`
+	TeamID         string `yaml:"team_id" env:"APPLE_TEAM_ID"`
+	KeyID          string `yaml:"key_id" env:"APPLE_KEY_ID"`
+	PrivateKeyPath string `yaml:"private_key_path" env:"APPLE_PRIVATE_KEY_PATH"`
+}
+
+// Currency configures conversion between the currencies of the country table
+type Currency struct {
+	// Rounding settles converted amounts that fall between two minor units:
+	// half_up, half_even, down or up
+	Rounding string `yaml:"rounding" env:"CURRENCY_ROUNDING"`
+	// RateCacheTTL is how long rates read from the database are reused
+	RateCacheTTL time.Duration `yaml:"rate_cache_ttl" env:"CURRENCY_RATE_CACHE_TTL"`
+
+	// RateSource is where rates are refreshed from: an http(s) URL or a file
+	// in the JSON format of exchange.JSONProvider. Rates stay as they are
+	// when it is empty.
+	RateSource string `yaml:"rate_source" env:"CURRENCY_RATE_SOURCE"`
+	// RateBase is the currency stored rates are quoted against
+	RateBase            string        `yaml:"rate_base" env:"CURRENCY_RATE_BASE"`
+	RateRefreshInterval time.Duration `yaml:"rate_refresh_interval" env:"CURRENCY_RATE_REFRESH_INTERVAL"`
+	RateFetchTimeout    time.Duration `yaml:"rate_fetch_timeout" env:"CURRENCY_RATE_FETCH_TIMEOUT"`
+	// RateMaxChange rejects a refreshed rate that moved more than this
+	// fraction from the previous one, e.g. 0.2 for 20%; 0 accepts any change
+	RateMaxChange float64 `yaml:"rate_max_change" env:"CURRENCY_RATE_MAX_CHANGE"`
+}
+
+// Default returns the configuration used when nothing overrides it
+func Default() Config {
+	return Config{
+		App: App{
+			Env:                      "production",
+			AccountDeletionGraceDays: 30,
+		},
+		HTTP: HTTP{
+			Port:                  "8080",
+			ReadHeaderTimeout:     5 * time.Second,
+			ReadTimeout:           15 * time.Second,
+			WriteTimeout:          35 * time.Second,
+			IdleTimeout:           60 * time.Second,
+			MaxHeaderBytes:        1 << 20,
+			HealthRequestTimeout:  2 * time.Second,
+			AccountRequestTimeout: 10 * time.Second,
+			AdminRequestTimeout:   30 * time.Second,
+			ShutdownDrainDelay:    5 * time.Second,
+			ShutdownTimeout:       30 * time.Second,
+		},
+		Database: Database{
+			Host:               "localhost",
+			Port:               "5432",
+			MaxOpenConns:       25,
+			MaxIdleConns:       5,
+			ConnMaxLifetime:    30 * time.Minute,
+			ConnMaxIdleTime:    5 * time.Minute,
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		Telemetry: Telemetry{
+			ServiceName: "go-booking-system",
+			SampleRatio: 1,
+			MetricsAddr: ":9090",
+		},
+		Auth: Auth{
+			AccessTokenTTL:    time.Hour,
+			RefreshTokenTTL:   30 * 24 * time.Hour,
+			LoginAttemptStore: "postgres",
+			TOTPIssuer:        "Go Booking System",
+		},
+		Mail: Mail{Driver: "log"},
+		SMS:  SMS{Driver: "log"},
+		OIDC: OIDC{
+			Google: GoogleProvider{Issuer: "https://accounts.google.com"},
+			Apple:  AppleProvider{Issuer: "https://appleid.apple.com"},
+		},
+		Currency: Currency{
+			Rounding:            "half_up",
+			RateCacheTTL:        5 * time.Minute,
+			RateBase:            "USD",
+			RateRefreshInterval: time.Hour,
+			RateFetchTimeout:    10 * time.Second,
+			RateMaxChange:       0.2,
+		},
+	}
+}
+
+// Load reads the configuration and validates it. A missing .env file is not
+// an error; a missing CONFIG_FILE is.
+func Load() (*Config, error) {
+	// .env only fills variables the environment does not already set
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read .env: %w", err)
+	}
+
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	// Local databases rarely have TLS and shutdown should not keep a
+	// developer waiting; everywhere else these keep the safe choice
+	if cfg.Database.SSLMode == "" {
+		cfg.Database.SSLMode = "require"
+		if cfg.App.IsDevelopment() {
+			cfg.Database.SSLMode = "disable"
+		}
+	}
+	if cfg.App.IsDevelopment() && os.Getenv("SHUTDOWN_DRAIN_DELAY") == "" {
+		cfg.HTTP.ShutdownDrainDelay = 0
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadFile overlays the YAML file at path onto cfg
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	// Reject unknown keys so a typo does not silently fall back to a default
+	if err := yaml.UnmarshalWithOptions(data, cfg, yaml.DisallowUnknownField()); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
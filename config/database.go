@@ -2,32 +2,90 @@ package config
 
 import (
 	"fmt"
-	"log"
-	"os"
+	"net/url"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
+// Database configures the Postgres connection pool. URL, when set, replaces
+// the individual connection fields; an sslmode in it wins over SSLMode.
+type Database struct {
+	URL      string `yaml:"url" env:"DATABASE_URL"`
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     string `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" env:"DB_NAME"`
+	// SSLMode is a libpq sslmode: disable, allow, prefer, require, verify-ca
+	// or verify-full. It defaults to require, or disable in development.
+	SSLMode     string `yaml:"ssl_mode" env:"DB_SSL_MODE"`
+	SSLRootCert string `yaml:"ssl_root_cert" env:"DB_SSL_ROOT_CERT"`
 
-func ConnectDatabase() {
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-	)
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
 
-	// database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Info)})
+	// SlowQueryThreshold is how long a query may take before it is logged
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD"`
+
+	// MigrateOnStart applies pending migrations when the server starts;
+	// otherwise run cmd/migrate before deploying
+	MigrateOnStart bool `yaml:"migrate_on_start" env:"DB_MIGRATE_ON_START"`
+	// AllowPendingMigrations starts the server even though the schema is
+	// behind, e.g. to roll back code without rolling back the schema
+	AllowPendingMigrations bool `yaml:"allow_pending_migrations" env:"DB_ALLOW_PENDING_MIGRATIONS"`
+}
+
+// DSN returns the connection string for the Postgres driver
+func (d Database) DSN() string {
+	if d.URL != "" {
+		u, err := url.Parse(d.URL)
+		if err != nil {
+			return d.URL
+		}
+		query := u.Query()
+		if query.Get("sslmode") == "" {
+			query.Set("sslmode", d.SSLMode)
+		}
+		if d.SSLRootCert != "" && query.Get("sslrootcert") == "" {
+			query.Set("sslrootcert", d.SSLRootCert)
+		}
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(d.User, d.Password),
+		Host:   d.Host + ":" + d.Port,
+		Path:   "/" + d.Name,
+	}
+	query := url.Values{"sslmode": {d.SSLMode}}
+	if d.SSLRootCert != "" {
+		query.Set("sslrootcert", d.SSLRootCert)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// OpenDatabase opens the connection pool, logging queries through queryLogger
+func OpenDatabase(cfg Database, queryLogger logger.Interface) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{Logger: queryLogger})
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return nil, fmt.Errorf("connect to database: %w", err)
 	}
 
-	DB = database
-	log.Println("Database connected successfully!")
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
 }
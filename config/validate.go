@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MinJWTSecretLength is the shortest HS256 secret accepted: 32 bytes, the
+// size of the SHA-256 output
+const MinJWTSecretLength = 32
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// The names cmd/api parses into log and money settings, compared
+// case-insensitively as the parsers do
+var (
+	logLevels     = []string{"debug", "info", "warn", "error"}
+	logFormats    = []string{"json", "text"}
+	roundingModes = []string{"half_up", "half_even", "down", "up"}
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Validate reports every problem with the configuration at once, so a bad
+// deploy fails at startup instead of on the first request that needs it
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	// App
+	check(c.App.Env != "", "APP_ENV must not be empty")
+	check(c.App.AccountDeletionGraceDays >= 0, "ACCOUNT_DELETION_GRACE_DAYS must not be negative")
+
+	// HTTP
+	check(c.HTTP.Port != "", "PORT must not be empty")
+	check(c.HTTP.MaxHeaderBytes > 0, "HTTP_MAX_HEADER_BYTES must be positive")
+	check((c.HTTP.TLSCertFile == "") == (c.HTTP.TLSKeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", c.HTTP.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", c.HTTP.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.HTTP.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.HTTP.IdleTimeout},
+		{"REQUEST_TIMEOUT_HEALTH", c.HTTP.HealthRequestTimeout},
+		{"REQUEST_TIMEOUT_ACCOUNT", c.HTTP.AccountRequestTimeout},
+		{"REQUEST_TIMEOUT_ADMIN", c.HTTP.AdminRequestTimeout},
+		{"SHUTDOWN_DRAIN_DELAY", c.HTTP.ShutdownDrainDelay},
+		{"SHUTDOWN_TIMEOUT", c.HTTP.ShutdownTimeout},
+	} {
+		check(timeout.value >= 0, "%s must not be negative", timeout.name)
+	}
+	longestDeadline := max(c.HTTP.HealthRequestTimeout, c.HTTP.AccountRequestTimeout, c.HTTP.AdminRequestTimeout)
+	check(c.HTTP.WriteTimeout == 0 || c.HTTP.WriteTimeout > longestDeadline,
+		"HTTP_WRITE_TIMEOUT (%s) must be longer than every REQUEST_TIMEOUT_* (%s)", c.HTTP.WriteTimeout, longestDeadline)
+
+	// Database
+	if c.Database.URL != "" {
+		u, err := url.Parse(c.Database.URL)
+		check(err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql"), "DATABASE_URL must be a postgres:// URL")
+	} else {
+		check(c.Database.Host != "" && c.Database.Name != "", "DATABASE_URL or DB_HOST and DB_NAME must be set")
+	}
+	check(slices.Contains(sslModes, c.Database.SSLMode), "DB_SSL_MODE must be one of %v", sslModes)
+	check(c.Database.MaxOpenConns >= 0 && c.Database.MaxIdleConns >= 0, "DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns, "DB_MAX_IDLE_CONNS must not exceed DB_MAX_OPEN_CONNS")
+	check(c.Database.ConnMaxLifetime >= 0 && c.Database.ConnMaxIdleTime >= 0, "DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME must not be negative")
+	check(c.Database.SlowQueryThreshold >= 0, "DB_SLOW_QUERY_THRESHOLD must not be negative")
+
+	// Log
+	check(c.Log.Level == "" || oneOf(c.Log.Level, logLevels), "LOG_LEVEL must be debug, info, warn or error")
+	check(c.Log.Format == "" || oneOf(c.Log.Format, logFormats), "LOG_FORMAT must be json or text")
+
+	// Telemetry
+	check(c.Telemetry.ServiceName != "", "OTEL_SERVICE_NAME must not be empty")
+	check(c.Telemetry.SampleRatio >= 0 && c.Telemetry.SampleRatio <= 1, "OTEL_TRACES_SAMPLER_ARG must be between 0 and 1")
+	if c.Telemetry.MetricsEnabled() {
+		_, port, err := net.SplitHostPort(c.Telemetry.MetricsAddr)
+		check(err == nil && port != "", "METRICS_ADDR must be host:port, :port or off")
+		check(err != nil || port != c.HTTP.Port, "METRICS_ADDR must not use the public PORT (%s)", c.HTTP.Port)
+	}
+
+	// Auth
+	if c.Auth.JWTKeysDir == "" {
+		check(len(c.Auth.JWTSecret) >= MinJWTSecretLength,
+			"JWT_SECRET must be at least %d characters when JWT_KEYS_DIR is not set", MinJWTSecretLength)
+	}
+	check(c.Auth.AccessTokenTTL > 0, "ACCESS_TOKEN_TTL must be positive")
+	check(c.Auth.RefreshTokenTTL > c.Auth.AccessTokenTTL, "REFRESH_TOKEN_TTL must be longer than ACCESS_TOKEN_TTL")
+	check(c.Auth.LoginAttemptStore == "postgres" || c.Auth.LoginAttemptStore == "memory", "LOGIN_ATTEMPT_STORE must be postgres or memory")
+
+	// Mail and SMS
+	switch c.Mail.Driver {
+	case "smtp":
+		check(c.Mail.SMTPHost != "" && c.Mail.SMTPPort != "" && c.Mail.From != "", "MAIL_DRIVER=smtp needs SMTP_HOST, SMTP_PORT and MAIL_FROM")
+	case "log":
+	default:
+		check(false, "MAIL_DRIVER must be smtp or log")
+	}
+	switch c.SMS.Driver {
+	case "twilio":
+		check(c.SMS.TwilioAccountSID != "" && c.SMS.TwilioAuthToken != "" && c.SMS.From != "", "SMS_DRIVER=twilio needs TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and SMS_FROM")
+	case "log":
+	default:
+		check(false, "SMS_DRIVER must be twilio or log")
+	}
+
+	// Social sign-in
+	if c.OIDC.Google.ClientID != "" {
+		check(c.OIDC.Google.ClientSecret != "" && c.OIDC.Google.RedirectURL != "", "OIDC_GOOGLE_CLIENT_ID needs OIDC_GOOGLE_CLIENT_SECRET and OIDC_GOOGLE_REDIRECT_URL")
+	}
+	if c.OIDC.Apple.ClientID != "" {
+		check(c.OIDC.Apple.RedirectURL != "" && c.OIDC.Apple.TeamID != "" && c.OIDC.Apple.KeyID != "" && c.OIDC.Apple.PrivateKeyPath != "",
+			"OIDC_APPLE_CLIENT_ID needs OIDC_APPLE_REDIRECT_URL, APPLE_TEAM_ID, APPLE_KEY_ID and APPLE_PRIVATE_KEY_PATH")
+	}
+
+	// Currency
+	check(oneOf(c.Currency.Rounding, roundingModes), "CURRENCY_ROUNDING must be half_up, half_even, down or up")
+	check(c.Currency.RateCacheTTL >= 0, "CURRENCY_RATE_CACHE_TTL must not be negative")
+	check(currencyCode.MatchString(c.Currency.RateBase), "CURRENCY_RATE_BASE must be an ISO 4217 code such as USD")
+	check(c.Currency.RateMaxChange >= 0, "CURRENCY_RATE_MAX_CHANGE must not be negative")
+	if c.Currency.RateSource != "" {
+		check(c.Currency.RateRefreshInterval > 0, "CURRENCY_RATE_REFRESH_INTERVAL must be positive when CURRENCY_RATE_SOURCE is set")
+		check(c.Currency.RateFetchTimeout > 0, "CURRENCY_RATE_FETCH_TIMEOUT must be positive when CURRENCY_RATE_SOURCE is set")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// oneOf reports whether value is one of names, ignoring case
+func oneOf(value string, names []string) bool {
+	return slices.Contains(names, strings.ToLower(value))
+}
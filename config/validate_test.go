@@ -0,0 +1,107 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig returns the defaults plus the settings that have none
+func validConfig() Config {
+	cfg := Default()
+	cfg.Database.Name = "booking"
+	cfg.Database.SSLMode = "require"
+	cfg.Auth.JWTSecret = strings.Repeat("s", MinJWTSecretLength)
+	return cfg
+}
+
+func TestDefaultIsProduction(t *testing.T) {
+	cfg := Default()
+	if cfg.App.Env != "production" || cfg.App.IsDevelopment() {
+		t.Errorf("default APP_ENV = %q, want production", cfg.App.Env)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		// wantErr is a part of the error message; empty means valid
+		wantErr string
+	}{
+		{name: "defaults with a database and secret", modify: func(c *Config) {}},
+		{name: "empty environment", modify: func(c *Config) { c.App.Env = "" }, wantErr: "APP_ENV"},
+		{name: "negative grace period", modify: func(c *Config) { c.App.AccountDeletionGraceDays = -1 }, wantErr: "ACCOUNT_DELETION_GRACE_DAYS"},
+		{name: "TLS certificate without key", modify: func(c *Config) { c.HTTP.TLSCertFile = "cert.pem" }, wantErr: "TLS_KEY_FILE"},
+		{name: "negative timeout", modify: func(c *Config) { c.HTTP.ShutdownTimeout = -time.Second }, wantErr: "SHUTDOWN_TIMEOUT"},
+		{
+			name:    "write timeout within a request deadline",
+			modify:  func(c *Config) { c.HTTP.WriteTimeout = c.HTTP.AdminRequestTimeout },
+			wantErr: "HTTP_WRITE_TIMEOUT",
+		},
+		{name: "no write timeout", modify: func(c *Config) { c.HTTP.WriteTimeout = 0 }},
+		{name: "database URL", modify: func(c *Config) { c.Database.URL = "postgres://user@db:5432/booking"; c.Database.Name = "" }},
+		{name: "database URL of another scheme", modify: func(c *Config) { c.Database.URL = "mysql://db/booking" }, wantErr: "DATABASE_URL"},
+		{name: "no database name", modify: func(c *Config) { c.Database.Name = "" }, wantErr: "DB_NAME"},
+		{name: "unknown SSL mode", modify: func(c *Config) { c.Database.SSLMode = "on" }, wantErr: "DB_SSL_MODE"},
+		{name: "more idle than open connections", modify: func(c *Config) { c.Database.MaxIdleConns = 30 }, wantErr: "DB_MAX_IDLE_CONNS"},
+		{name: "log level in capitals", modify: func(c *Config) { c.Log.Level = "WARN" }},
+		{name: "unknown log level", modify: func(c *Config) { c.Log.Level = "verbose" }, wantErr: "LOG_LEVEL"},
+		{name: "unknown log format", modify: func(c *Config) { c.Log.Format = "logfmt" }, wantErr: "LOG_FORMAT"},
+		{name: "sample ratio above one", modify: func(c *Config) { c.Telemetry.SampleRatio = 1.5 }, wantErr: "OTEL_TRACES_SAMPLER_ARG"},
+		{name: "metrics turned off", modify: func(c *Config) { c.Telemetry.MetricsAddr = MetricsOff }},
+		{name: "metrics address without port", modify: func(c *Config) { c.Telemetry.MetricsAddr = "localhost" }, wantErr: "METRICS_ADDR must be host:port"},
+		{name: "metrics on the public port", modify: func(c *Config) { c.Telemetry.MetricsAddr = ":8080" }, wantErr: "public PORT"},
+		{name: "short JWT secret", modify: func(c *Config) { c.Auth.JWTSecret = "secret" }, wantErr: "JWT_SECRET"},
+		{name: "short JWT secret with signing keys", modify: func(c *Config) { c.Auth.JWTSecret = ""; c.Auth.JWTKeysDir = "keys" }},
+		{name: "refresh token outlived by access token", modify: func(c *Config) { c.Auth.RefreshTokenTTL = time.Minute }, wantErr: "REFRESH_TOKEN_TTL"},
+		{name: "unknown login attempt store", modify: func(c *Config) { c.Auth.LoginAttemptStore = "redis" }, wantErr: "LOGIN_ATTEMPT_STORE"},
+		{name: "SMTP without host", modify: func(c *Config) { c.Mail.Driver = "smtp"; c.Mail.From = "noreply@example.com" }, wantErr: "SMTP_HOST"},
+		{name: "unknown SMS driver", modify: func(c *Config) { c.SMS.Driver = "sns" }, wantErr: "SMS_DRIVER"},
+		{name: "Google without secret", modify: func(c *Config) { c.OIDC.Google.ClientID = "client" }, wantErr: "OIDC_GOOGLE_CLIENT_SECRET"},
+		{name: "Apple without key", modify: func(c *Config) { c.OIDC.Apple.ClientID = "client" }, wantErr: "APPLE_PRIVATE_KEY_PATH"},
+		{name: "rounding in capitals", modify: func(c *Config) { c.Currency.Rounding = "HALF_EVEN" }},
+		{name: "unknown rounding", modify: func(c *Config) { c.Currency.Rounding = "nearest" }, wantErr: "CURRENCY_ROUNDING"},
+		{name: "lowercase rate base", modify: func(c *Config) { c.Currency.RateBase = "usd" }, wantErr: "CURRENCY_RATE_BASE"},
+		{
+			name:    "rate source without refresh interval",
+			modify:  func(c *Config) { c.Currency.RateSource = "rates.json"; c.Currency.RateRefreshInterval = 0 },
+			wantErr: "CURRENCY_RATE_REFRESH_INTERVAL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate error = %v, want one about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.HTTP.Port = ""
+	cfg.Auth.JWTSecret = ""
+	cfg.Log.Level = "verbose"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted an invalid configuration")
+	}
+	for _, name := range []string{"PORT", "JWT_SECRET", "LOG_LEVEL"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Validate error = %v, missing %s", err, name)
+		}
+	}
+}
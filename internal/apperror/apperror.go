@@ -0,0 +1,129 @@
+// Package apperror defines the typed errors the service layer returns. Each
+// error carries a Kind, which the HTTP layer maps to a status code, and a
+// stable machine-readable Code that clients can switch on.
+package apperror
+
+import (
+	"errors"
+	"time"
+)
+
+// Kind classifies an error independently of the transport
+type Kind uint8
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindLocked
+	KindTooManyRequests
+	KindCanceled    // the caller gave up before the work finished
+	KindUnavailable // the work did not finish in time or a dependency is down
+)
+
+// Codes shared across packages
+const (
+	CodeInternal         = "internal_error"
+	CodeValidationFailed = "validation_failed"
+	CodeMalformedRequest = "malformed_request"
+)
+
+// Error is an application error with a kind, a code and a client-safe message.
+// Err holds the underlying cause, which is logged but never shown to clients.
+type Error struct {
+	Kind       Kind
+	Code       string
+	Message    string
+	Fields     map[string]string // per-field messages for validation errors
+	RetryAfter time.Duration     // how long the client should wait, if known
+	Err        error
+}
+
+// New creates an error of the given kind
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Invalid creates an error for bad client input
+func Invalid(code, message string) *Error {
+	return New(KindInvalid, code, message)
+}
+
+// Unauthorized creates an error for missing or rejected credentials
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+// Forbidden creates an error for an authenticated caller lacking rights
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// NotFound creates an error for a missing resource
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Conflict creates an error for a request that clashes with current state
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// TooManyRequests creates an error for a rate-limited request
+func TooManyRequests(code, message string) *Error {
+	return New(KindTooManyRequests, code, message)
+}
+
+// Internal wraps an unexpected failure. The message is shown to clients,
+// the cause is only logged.
+func Internal(message string, cause error) *Error {
+	return &Error{Kind: KindInternal, Code: CodeInternal, Message: message, Err: cause}
+}
+
+// Validation creates an input error with a message per offending field
+func Validation(fields map[string]string) *Error {
+	return &Error{
+		Kind:    KindInvalid,
+		Code:    CodeValidationFailed,
+		Message: "request validation failed",
+		Fields:  fields,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any *Error with the same code, so a sentinel still matches
+// after Wrap has attached a cause
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Kind == e.Kind
+}
+
+// Wrap returns a copy of the error with cause attached
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.Err = cause
+	return &wrapped
+}
+
+// From returns the *Error in err's chain, or an internal error wrapping err
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal("internal server error", err)
+}
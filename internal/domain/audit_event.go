@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+)
+
+// Audit actions recorded by AuditEvent
+const (
+	AuditActionRoleGranted = "role.granted"
+	AuditActionRoleRevoked = "role.revoked"
+
+	AuditActionCountryCreated  = "country.created"
+	AuditActionCountryUpdated  = "country.updated"
+	AuditActionCountryDisabled = "country.disabled"
+
+	AuditActionExchangeRateSet = "exchange_rate.set"
+)
+
+// AuditEvent records a privileged change: who did it, to whom and what changed.
+// Users are referenced by UUID so the trail survives account anonymisation.
+type AuditEvent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorUUID  string    `gorm:"index" json:"actor_uuid"` // empty when done from the command line
+	Action     string    `gorm:"type:varchar(64);not null;index" json:"action"`
+	TargetUUID string    `gorm:"index" json:"target_uuid"` // empty for reference data such as countries
+	Detail     string    `json:"detail"`
+	ClientIP   string    `json:"client_ip"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
@@ -4,6 +4,9 @@ import (
 	"time"
 )
 
+// Country is reference data for a country: names, currency and the fees and
+// rewards that apply to bookings there. Disabled countries are kept for the
+// records that reference them but are no longer offered to clients.
 type Country struct {
 	ID                         uint       `gorm:"primaryKey;column:id" json:"id"`
 	Name                       *string    `gorm:"column:name;type:varchar(255)" json:"name,omitempty"`
@@ -17,8 +20,9 @@ type Country struct {
 	Longitude                  *float64   `gorm:"column:longitude" json:"longitude,omitempty"`
 	TimezoneName               *string    `gorm:"column:timezone_name;type:varchar(255)" json:"timezone_name,omitempty"`
 	NameVariant                *string    `gorm:"column:name_variant;type:varchar(255)" json:"name_variant,omitempty"`
-	CreatedTime                *time.Time `gorm:"column:created_time" json:"created_time,omitempty"`
-	LastModified               *string    `gorm:"column:last_modified;type:varchar(255)" json:"last_modified,omitempty"`
+	CreatedTime                *time.Time `gorm:"column:created_time;autoCreateTime" json:"created_time,omitempty"`
+	LastModified               *time.Time `gorm:"column:last_modified;autoUpdateTime" json:"last_modified,omitempty"`
+	DisabledAt                 *time.Time `gorm:"column:disabled_at" json:"disabled_at,omitempty"`
 	PaypalTransactionSurcharge *float64   `gorm:"column:paypal_transaction_surcharge" json:"paypal_transaction_surcharge,omitempty"`
 	PaypalPayoutFee            *float64   `gorm:"column:paypal_payout_fee" json:"paypal_payout_fee,omitempty"`
 	NameZhCn                   *string    `gorm:"column:name_zh_cn;type:varchar(255)" json:"name_zh_cn,omitempty"`
@@ -44,3 +48,8 @@ type Country struct {
 func (Country) TableName() string {
 	return "country"
 }
+
+// IsDisabled reports whether the country has been withdrawn from clients
+func (c *Country) IsDisabled() bool {
+	return c.DisabledAt != nil
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+)
+
+// ExchangeRateSourceAdmin marks rates an admin set rather than the refresh
+const ExchangeRateSourceAdmin = "admin"
+
+// ExchangeRate is one entry of the rate history: from EffectiveAt until the
+// next entry for the same currency, one unit of the base currency bought Rate
+// units of CurrencyCode. Entries are never updated, so any past conversion
+// can be reproduced.
+type ExchangeRate struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	CurrencyCode string    `gorm:"type:varchar(3);not null" json:"currency_code"`
+	Rate         string    `gorm:"type:numeric;not null" json:"rate"` // exact decimal, e.g. "149.5"
+	Source       string    `gorm:"type:varchar(64);not null" json:"source"`
+	EffectiveAt  time.Time `gorm:"not null" json:"effective_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+)
+
+// LinkedIdentity connects a user to an account at an external identity
+// provider. A user can have one identity per provider.
+type LinkedIdentity struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index;uniqueIndex:idx_linked_identities_user_provider" json:"user_id"`
+	Provider   string     `gorm:"type:varchar(32);not null;uniqueIndex:idx_linked_identities_provider_subject;uniqueIndex:idx_linked_identities_user_provider" json:"provider"`
+	Subject    string     `gorm:"not null;uniqueIndex:idx_linked_identities_provider_subject" json:"-"` // the provider's stable user id ("sub")
+	Email      string     `json:"email"`                                                                // email reported by the provider when linked
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// OAuthState holds what the server must remember between sending the browser
+// to an identity provider and handling the callback. The state value itself
+// is only stored hashed.
+type OAuthState struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	StateHash    string     `gorm:"uniqueIndex;not null" json:"-"`
+	Provider     string     `gorm:"type:varchar(32);not null" json:"provider"`
+	Nonce        string     `gorm:"not null" json:"-"`
+	CodeVerifier string     `gorm:"not null" json:"-"` // PKCE verifier
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expires_at"`
+	ConsumedAt   *time.Time `json:"consumed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+)
+
+// LoginAttempt tracks consecutive failed sign-ins for one key, which is either
+// an account ("email:<address>") or a client ("ip:<address>")
+type LoginAttempt struct {
+	Key          string     `gorm:"primaryKey" json:"key"`
+	Failures     int        `gorm:"not null;default:0" json:"failures"`
+	LastFailedAt time.Time  `gorm:"not null" json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+}
+
+// IsLocked reports whether the key is locked out at the given time
+func (a *LoginAttempt) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
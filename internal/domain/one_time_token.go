@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+)
+
+// Purposes a OneTimeToken can be issued for
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailChange       = "email_change"
+	TokenPurposeTwoFactor         = "two_factor_challenge"
+	TokenPurposeMagicLink         = "magic_link_sign_in"
+	TokenPurposePhoneSignIn       = "phone_sign_in"
+	TokenPurposePhoneVerification = "phone_verification"
+)
+
+// MaxOneTimeTokenFailures is how many wrong guesses a short code survives
+const MaxOneTimeTokenFailures = 5
+
+// OneTimeToken is a hashed, single-use, expiring token sent to a user out of
+// band (e.g. by email). Target records what the token vouches for, such as the
+// address being verified, so a token cannot be replayed after it changes.
+type OneTimeToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Purpose    string     `gorm:"not null;index" json:"purpose"`
+	TokenHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	Target     string     `json:"target"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	FailedAttempts int `gorm:"not null;default:0" json:"-"` // wrong guesses at a short code
+}
+
+// IsUsable reports whether the token is neither consumed, expired nor
+// guessed at too often
+func (t *OneTimeToken) IsUsable(now time.Time) bool {
+	return t.ConsumedAt == nil && now.Before(t.ExpiresAt) && t.FailedAttempts < MaxOneTimeTokenFailures
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"time"
+)
+
+// RecoveryCode is a hashed single-use code that replaces a TOTP code when the
+// user has lost their authenticator
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+)
+
+// RevokedToken is an access token (identified by its jti claim) that must no
+// longer be accepted, even though its signature and expiry are still valid.
+type RevokedToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	JTI       string    `gorm:"column:jti;not null;uniqueIndex" json:"jti"`
+	UserUUID  string    `gorm:"not null;index" json:"user_uuid"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserRevocation revokes every access token issued to a user at or before
+// RevokedBefore. It backs "log out everywhere" without tracking each jti.
+// Once ExpiresAt has passed, every token it covers has expired anyway.
+type UserRevocation struct {
+	UserUUID      string    `gorm:"primaryKey" json:"user_uuid"`
+	RevokedBefore time.Time `gorm:"not null" json:"revoked_before"`
+	ExpiresAt     time.Time `gorm:"not null;index" json:"expires_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// Role is a named set of permissions. Every account is a guest; host and
+// admin are granted on top of that.
+type Role string
+
+const (
+	RoleGuest Role = "guest"
+	RoleHost  Role = "host"
+	RoleAdmin Role = "admin"
+)
+
+// Permission is a single action that routes can require
+type Permission string
+
+const (
+	PermissionBookingsCreate  Permission = "bookings:create"
+	PermissionListingsManage  Permission = "listings:manage"
+	PermissionCountriesManage Permission = "countries:manage"
+	PermissionUsersRead       Permission = "users:read"
+	PermissionRolesManage     Permission = "roles:manage"
+)
+
+// rolePermissions lists what each role allows. Roles do not inherit from each
+// other; a user's permissions are the union over all their roles.
+var rolePermissions = map[Role][]Permission{
+	RoleGuest: {PermissionBookingsCreate},
+	RoleHost:  {PermissionListingsManage},
+	RoleAdmin: {PermissionCountriesManage, PermissionUsersRead, PermissionRolesManage},
+}
+
+// IsValid reports whether the role is one the system knows about
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// IsGrantable reports whether the role can be granted or revoked. Guest is
+// implied for every account and cannot be taken away.
+func (r Role) IsGrantable() bool {
+	return r.IsValid() && r != RoleGuest
+}
+
+// PermissionsFor returns the sorted union of permissions of the given roles
+func PermissionsFor(roles []Role) []Permission {
+	seen := map[Permission]bool{}
+	for _, role := range roles {
+		for _, permission := range rolePermissions[role] {
+			seen[permission] = true
+		}
+	}
+
+	permissions := make([]Permission, 0, len(seen))
+	for permission := range seen {
+		permissions = append(permissions, permission)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions
+}
+
+// UserRole grants one role to one user
+type UserRole struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_roles_user_role" json:"user_id"`
+	Role      Role      `gorm:"type:varchar(32);not null;uniqueIndex:idx_user_roles_user_role" json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"time"
+)
+
+// Session is a server-side record of a refresh token issued to a device.
+// Only the SHA-256 hash of the token is stored. Every rotation creates a new
+// Session in the same family; replaying a rotated token revokes the family.
+type Session struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserUUID    string     `gorm:"not null;index" json:"user_uuid"`
+	FamilyID    string     `gorm:"not null;index" json:"family_id"`
+	TokenHash   string     `gorm:"not null;uniqueIndex" json:"-"`
+	DeviceLabel string     `json:"device_label"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	RotatedAt   *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// IsExpired reports whether the refresh token has passed its expiry
+func (s *Session) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
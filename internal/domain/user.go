@@ -14,11 +14,18 @@ type User struct {
 	Password        string         `gorm:"not null" json:"-"` // "-" means don't return in JSON
 	Name            string         `gorm:"not null" json:"name"`
 	MobileCountryId *uint          `gorm:"default:null"`
-	Phone           string         `json:"phone"`
+	Phone           string         `gorm:"uniqueIndex:idx_users_verified_phone,where:phone_verified_at IS NOT NULL AND deleted_at IS NULL" json:"phone"` // E.164; only one live account may have a verified number
+	PhoneVerifiedAt *time.Time     `json:"phone_verified_at,omitempty"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty"`
+	TOTPSecret      string         `gorm:"column:totp_secret" json:"-"`              // base32; pending until TOTPEnabledAt is set
+	TOTPEnabledAt   *time.Time     `gorm:"column:totp_enabled_at" json:"-"`          // two-factor sign-in is on when set
+	TOTPLastStep    int64          `gorm:"column:totp_last_step;default:0" json:"-"` // last accepted time step, blocks code replay
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+	AnonymizedAt    *time.Time     `json:"-"` // set once PII of a deleted account has been scrubbed
 	UUID            string         `gorm:"not null" json:"uuid"`
+	Roles           []UserRole     `gorm:"foreignKey:UserID" json:"-"` // granted roles; guest is implied
 }
 
 // Hash password before saving
@@ -36,6 +43,57 @@ func (u *User) CheckPassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 }
 
+// IsEmailVerified reports whether the user has confirmed their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsPhoneVerified reports whether the user has confirmed their phone number by SMS
+func (u *User) IsPhoneVerified() bool {
+	return u.PhoneVerifiedAt != nil
+}
+
+// IsTwoFactorEnabled reports whether sign-in requires a TOTP code
+func (u *User) IsTwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// RoleNames returns every role the user holds, including the implied guest role
+func (u *User) RoleNames() []Role {
+	roles := []Role{RoleGuest}
+	for _, granted := range u.Roles {
+		if granted.Role != RoleGuest {
+			roles = append(roles, granted.Role)
+		}
+	}
+	return roles
+}
+
+// HasRole reports whether the user holds the role
+func (u *User) HasRole(role Role) bool {
+	for _, held := range u.RoleNames() {
+		if held == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Anonymize scrubs personal data from a deleted account. The email is replaced
+// by a unique placeholder so the original address can register again.
+func (u *User) Anonymize(now time.Time) {
+	u.Email = "deleted+" + u.UUID + "@anonymized.invalid"
+	u.Name = "Deleted user"
+	u.Phone = ""
+	u.PhoneVerifiedAt = nil
+	u.Password = ""
+	u.MobileCountryId = nil
+	u.EmailVerifiedAt = nil
+	u.TOTPSecret = ""
+	u.TOTPEnabledAt = nil
+	u.AnonymizedAt = &now
+}
+
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	// if u.UUID == "" {
 	// 	u.UUID = uuid.New().String()
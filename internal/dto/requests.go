@@ -5,12 +5,180 @@ type SignUpRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"user@example.com"`
 	Password string `json:"password" binding:"required,min=6" example:"password123"`
 	Name     string `json:"name" binding:"required" example:"John Doe"`
-	Phone    string `json:"phone" example:"234567890"`
+	Phone    string `json:"phone" binding:"max=32" example:"(201) 555-0123"`
 	Country  string `json:"country" example:"US"`
+	Device   string `json:"device" example:"iPhone 15"`
 }
 
 // SignInRequest represents user login payload
 type SignInRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"user@example.com"`
 	Password string `json:"password" binding:"required" example:"password123"`
+	Device   string `json:"device" example:"iPhone 15"`
+}
+
+// RefreshTokenRequest represents token refresh payload
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"mQ2c7nV0h1yJ..."`
+}
+
+// VerifyEmailRequest represents email verification payload
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required" example:"Xy3k9QpL..."`
+}
+
+// ForgotPasswordRequest represents password reset request payload
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+// ResetPasswordRequest represents password reset payload
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required" example:"Xy3k9QpL..."`
+	Password string `json:"password" binding:"required,min=6" example:"newpassword123"`
+}
+
+// UpdateProfileRequest represents profile update payload; omitted fields are left unchanged
+type UpdateProfileRequest struct {
+	Name    *string `json:"name" binding:"omitempty,max=255" example:"John Doe"`
+	Phone   *string `json:"phone" binding:"omitempty,max=32" example:"(201) 555-0123"`
+	Country *string `json:"country" binding:"omitempty,max=255" example:"US"`
+}
+
+// ChangePasswordRequest represents password change payload
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"password123"`
+	NewPassword     string `json:"new_password" binding:"required,min=6" example:"newpassword123"`
+	Device          string `json:"device" example:"iPhone 15"`
+}
+
+// ChangeEmailRequest represents email change payload
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email" example:"new@example.com"`
+	Password string `json:"password" binding:"required" example:"password123"`
+}
+
+// ConfirmEmailChangeRequest represents email change confirmation payload
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required" example:"Xy3k9QpL..."`
+}
+
+// DeleteAccountRequest represents account deletion payload
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required" example:"password123"`
+}
+
+// TwoFactorCodeRequest represents a TOTP code confirming two-factor enrolment
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// TwoFactorVerifyRequest represents the second sign-in step
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required" example:"Xy3k9QpL..."`
+	Code           string `json:"code" binding:"required" example:"123456"` // TOTP code or recovery code
+	Device         string `json:"device" example:"iPhone 15"`
+}
+
+// PasswordConfirmRequest represents a payload that only re-confirms the current password
+type PasswordConfirmRequest struct {
+	Password string `json:"password" binding:"required" example:"password123"`
+}
+
+// GrantRoleRequest represents an admin granting a role to a user
+type GrantRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=host admin" example:"host"`
+}
+
+// SocialCallbackRequest carries the provider's redirect parameters. It binds
+// from JSON or from a form post (Apple uses response_mode=form_post).
+type SocialCallbackRequest struct {
+	Code   string `json:"code" form:"code" binding:"required" example:"4/0AbCdEf..."`
+	State  string `json:"state" form:"state" binding:"required" example:"q3Jz0n8c7yH2m4pQ9sX1vW6tR5eU0iO3aS8dF2gH7jK"`
+	Device string `json:"device" form:"device" example:"iPhone 15"`
+}
+
+// MagicLinkRequest asks for a sign-in link by email
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+// MagicLinkVerifyRequest signs in with the token from a magic link
+type MagicLinkVerifyRequest struct {
+	Token  string `json:"token" binding:"required" example:"Xy3k9QpL..."`
+	Device string `json:"device" example:"iPhone 15"`
+}
+
+// PhoneCodeRequest asks for a sign-in code by SMS. Country is required
+// unless the phone number starts with "+" or "00".
+type PhoneCodeRequest struct {
+	Phone   string `json:"phone" binding:"required,max=32" example:"0412 345 678"`
+	Country string `json:"country" example:"AU"`
+}
+
+// PhoneCodeVerifyRequest signs in with the code sent by SMS
+type PhoneCodeVerifyRequest struct {
+	Phone   string `json:"phone" binding:"required,max=32" example:"0412 345 678"`
+	Country string `json:"country" example:"AU"`
+	Code    string `json:"code" binding:"required,len=6,numeric" example:"123456"`
+	Device  string `json:"device" example:"iPhone 15"`
+}
+
+// PhoneVerifyRequest confirms the account's phone number with the SMS code
+type PhoneVerifyRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric" example:"123456"`
+}
+
+// CountryRequest is the complete admin view of a country, used to create and
+// replace countries and as the record format of bulk import and export.
+// Empty strings and omitted numbers are stored as NULL. A changed currency
+// rate applies to every country using the currency and is recorded in the
+// exchange rate history.
+type CountryRequest struct {
+	Shortname                  string   `json:"shortname" binding:"required,alpha,min=2,max=3" example:"JP"`
+	Name                       string   `json:"name" binding:"required,max=255" example:"Japan"`
+	NameVariant                string   `json:"name_variant,omitempty" binding:"max=255" example:"Nippon"`
+	NameZhCn                   string   `json:"name_zh_cn,omitempty" binding:"max=255" example:"日本"`
+	NameZhTw                   string   `json:"name_zh_tw,omitempty" binding:"max=255" example:"日本"`
+	NameJaJp                   string   `json:"name_ja_jp,omitempty" binding:"max=255" example:"日本"`
+	NameKoKr                   string   `json:"name_ko_kr,omitempty" binding:"max=255" example:"일본"`
+	NameTh                     string   `json:"name_th,omitempty" binding:"max=255" example:"ญี่ปุ่น"`
+	NameCsCz                   string   `json:"name_cs_cz,omitempty" binding:"max=255" example:"Japonsko"`
+	CallingCode                *int     `json:"calling_code,omitempty" binding:"omitempty,min=1,max=9999" example:"81"`
+	CurrencyCode               string   `json:"currency_code" binding:"required,iso4217" example:"JPY"`
+	CurrencyName               string   `json:"currency_name,omitempty" binding:"max=255" example:"Japanese yen"`
+	CurrencySymbol             string   `json:"currency_symbol,omitempty" binding:"max=16" example:"¥"`
+	CurrencyRate               *float64 `json:"currency_rate,omitempty" binding:"omitempty,gt=0" example:"149.5"`
+	NoDecimalCurrency          bool     `json:"no_decimal_currency" example:"true"`
+	TimezoneName               string   `json:"timezone_name,omitempty" binding:"omitempty,timezone" example:"Asia/Tokyo"`
+	GMT                        string   `json:"gmt,omitempty" binding:"max=255" example:"+09:00"`
+	Latitude                   *float64 `json:"latitude,omitempty" binding:"omitempty,min=-90,max=90" example:"36.2"`
+	Longitude                  *float64 `json:"longitude,omitempty" binding:"omitempty,min=-180,max=180" example:"138.25"`
+	PlatformFeePercent         *int     `json:"platform_fee_percent,omitempty" binding:"omitempty,min=0,max=100" example:"12"`
+	PaypalTransactionSurcharge *float64 `json:"paypal_transaction_surcharge,omitempty" binding:"omitempty,min=0,max=100" example:"3.6"`
+	PaypalPayoutFee            *float64 `json:"paypal_payout_fee,omitempty" binding:"omitempty,min=0" example:"0.25"`
+	HasVatGst                  bool     `json:"has_vat_gst" example:"true"`
+	DepositOnly                bool     `json:"deposit_only" example:"false"`
+	AllowShare                 bool     `json:"allow_share" example:"true"`
+	ReferralReward             *int     `json:"referral_reward,omitempty" binding:"omitempty,min=0" example:"1000"`
+	ReferralHostReward         *int     `json:"referral_host_reward,omitempty" binding:"omitempty,min=0" example:"3000"`
+	HostExpectedEarnings       *int     `json:"host_expected_earnings,omitempty" binding:"omitempty,min=0" example:"50000"`
+	PointsMultiplier           *int     `json:"points_multiplier,omitempty" binding:"omitempty,min=0" example:"1"`
+	SearchRadius               *int     `json:"search_radius,omitempty" binding:"omitempty,min=1" example:"50"`
+	Disabled                   bool     `json:"disabled" example:"false"`
+}
+
+// ExchangeRateRequest sets the rate of a currency against the base currency.
+// The rate is a decimal string so it is stored exactly.
+type ExchangeRateRequest struct {
+	Rate string `json:"rate" binding:"required,max=32" example:"149.5"`
+}
+
+// CurrencyConvertQuery is the query string of a currency conversion. At,
+// an RFC 3339 time, converts with the rates in effect then instead of now.
+type CurrencyConvertQuery struct {
+	From   string `form:"from" binding:"required,len=3,alpha" example:"USD"`
+	To     string `form:"to" binding:"required,len=3,alpha" example:"JPY"`
+	Amount string `form:"amount" binding:"required,max=32" example:"125.50"`
+	At     string `form:"at" example:"2025-01-20T14:30:00Z"`
 }
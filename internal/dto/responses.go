@@ -2,23 +2,41 @@ package dto
 
 // UserResponse represents user data in API responses
 type UserResponse struct {
-	UUID      string `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Email     string `json:"email" example:"user@example.com"`
-	Name      string `json:"name" example:"John Doe"`
-	Phone     string `json:"phone,omitempty" example:"+1234567890"`
-	CreatedAt string `json:"created_at" example:"2024-12-05T08:00:00Z"`
+	UUID             string   `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Email            string   `json:"email" example:"user@example.com"`
+	Name             string   `json:"name" example:"John Doe"`
+	Phone            string   `json:"phone,omitempty" example:"+12015550123"`
+	PhoneNational    string   `json:"phone_national,omitempty" example:"(201) 555-0123"`
+	PhoneVerified    bool     `json:"phone_verified" example:"false"`
+	EmailVerified    bool     `json:"email_verified" example:"true"`
+	TwoFactorEnabled bool     `json:"two_factor_enabled" example:"false"`
+	Roles            []string `json:"roles" example:"guest,host"`
+	CreatedAt        string   `json:"created_at" example:"2024-12-05T08:00:00Z"`
 }
 
 type SignUp_Success struct {
-	Message string       `json:"message" example:"Login successful"`
-	User    UserResponse `json:"user"`
-	Token   string       `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Message      string       `json:"message" example:"Login successful"`
+	User         UserResponse `json:"user"`
+	Token        string       `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string       `json:"refresh_token" example:"mQ2c7nV0h1yJ..."`
 }
 
+// SignIn_Success represents a sign-in result. When two-factor authentication
+// is enabled only ChallengeToken is set, to be completed at /2fa/verify.
 type SignIn_Success struct {
-	Message string       `json:"message" example:"Login successful"`
-	User    UserResponse `json:"user"`
-	Token   string       `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Message           string        `json:"message" example:"Login successful"`
+	User              *UserResponse `json:"user,omitempty"`
+	Token             string        `json:"token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken      string        `json:"refresh_token,omitempty" example:"mQ2c7nV0h1yJ..."`
+	TwoFactorRequired bool          `json:"two_factor_required,omitempty" example:"false"`
+	ChallengeToken    string        `json:"challenge_token,omitempty" example:"Xy3k9QpL..."`
+}
+
+// RefreshToken_Success represents a rotated token pair
+type RefreshToken_Success struct {
+	Message      string `json:"message" example:"Token refreshed"`
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token" example:"mQ2c7nV0h1yJ..."`
 }
 
 // HealthResponse represents health check response
@@ -27,7 +45,212 @@ type HealthResponse struct {
 	Message string `json:"message" example:"Server is Healthy"`
 }
 
-// ErrorResponse represents error response
+// LivenessResponse reports that the process is serving requests
+type LivenessResponse struct {
+	Status string `json:"status" example:"up"`
+}
+
+// ReadinessResponse reports whether the service can take traffic and why
+type ReadinessResponse struct {
+	Status string                `json:"status" example:"up"`
+	Checks []HealthCheckResponse `json:"checks"`
+}
+
+// HealthCheckResponse is the outcome of one dependency check
+type HealthCheckResponse struct {
+	Name      string  `json:"name" example:"database"`
+	Status    string  `json:"status" example:"up"`
+	Critical  bool    `json:"critical" example:"true"`
+	LatencyMs float64 `json:"latency_ms" example:"1.42"`
+}
+
+// ChangePassword_Success represents a fresh token pair issued after a password change
+type ChangePassword_Success struct {
+	Message      string `json:"message" example:"Password changed"`
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token" example:"mQ2c7nV0h1yJ..."`
+}
+
+// AccountExport is a machine-readable archive of everything stored about a user
+type AccountExport struct {
+	ExportedAt    string           `json:"exported_at" example:"2024-12-05T08:00:00Z"`
+	Profile       ExportProfile    `json:"profile"`
+	Sessions      []ExportSession  `json:"sessions"`
+	OneTimeTokens []ExportToken    `json:"one_time_tokens"`
+	Identities    []ExportIdentity `json:"identities"`
+	AuditEvents   []ExportAudit    `json:"audit_events"`
+	LoginAttempts []ExportAttempts `json:"login_attempts"`
+}
+
+// ExportProfile is the account record in an export
+type ExportProfile struct {
+	UUID             string   `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Email            string   `json:"email" example:"user@example.com"`
+	Name             string   `json:"name" example:"John Doe"`
+	Phone            string   `json:"phone,omitempty" example:"+12015550123"`
+	PhoneVerifiedAt  string   `json:"phone_verified_at,omitempty" example:"2024-12-05T08:00:00Z"`
+	Country          string   `json:"country,omitempty" example:"US"`
+	EmailVerifiedAt  string   `json:"email_verified_at,omitempty" example:"2024-12-05T08:00:00Z"`
+	TwoFactorEnabled bool     `json:"two_factor_enabled" example:"false"`
+	Roles            []string `json:"roles" example:"guest,host"`
+	CreatedAt        string   `json:"created_at" example:"2024-12-05T08:00:00Z"`
+	UpdatedAt        string   `json:"updated_at" example:"2024-12-05T08:00:00Z"`
+}
+
+// ExportSession is a signed-in device in an export
+type ExportSession struct {
+	Device    string `json:"device,omitempty" example:"iPhone 15"`
+	CreatedAt string `json:"created_at" example:"2024-12-05T08:00:00Z"`
+	ExpiresAt string `json:"expires_at" example:"2025-01-04T08:00:00Z"`
+	RotatedAt string `json:"rotated_at,omitempty" example:"2024-12-05T09:00:00Z"`
+	RevokedAt string `json:"revoked_at,omitempty" example:"2024-12-06T08:00:00Z"`
+}
+
+// ExportToken is an emailed link (verification, reset, ...) in an export
+type ExportToken struct {
+	Purpose    string `json:"purpose" example:"email_verification"`
+	Target     string `json:"target,omitempty" example:"user@example.com"`
+	CreatedAt  string `json:"created_at" example:"2024-12-05T08:00:00Z"`
+	ExpiresAt  string `json:"expires_at" example:"2024-12-06T08:00:00Z"`
+	ConsumedAt string `json:"consumed_at,omitempty" example:"2024-12-05T08:10:00Z"`
+}
+
+// ExportAudit is an audited change the user made or was subject to. Who
+// acted is given as "self", "admin" or "system" (the command line), so an
+// export does not reveal which admin it was.
+type ExportAudit struct {
+	Action    string `json:"action" example:"role.granted"`
+	Detail    string `json:"detail,omitempty" example:"host"`
+	Actor     string `json:"actor" example:"admin"`
+	ClientIP  string `json:"client_ip,omitempty" example:"203.0.113.7"` // only for the user's own actions
+	CreatedAt string `json:"created_at" example:"2024-12-05T08:00:00Z"`
+}
+
+// ExportAttempts is the failed sign-in counter of the account in an export
+type ExportAttempts struct {
+	Failures     int    `json:"failures" example:"2"`
+	LastFailedAt string `json:"last_failed_at" example:"2024-12-05T08:00:00Z"`
+	LockedUntil  string `json:"locked_until,omitempty" example:"2024-12-05T08:01:00Z"`
+}
+
+// TwoFactorSetup_Success carries the secret to load into an authenticator app
+type TwoFactorSetup_Success struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OtpauthURI string `json:"otpauth_uri" example:"otpauth://totp/Go%20Booking%20System:user@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Go%20Booking%20System"`
+}
+
+// RecoveryCodes_Success carries freshly generated recovery codes; they are shown only once
+type RecoveryCodes_Success struct {
+	Message       string   `json:"message" example:"Two-factor authentication enabled"`
+	RecoveryCodes []string `json:"recovery_codes" example:"k3j9d-q8w2m,p0x7c-v5n4b"`
+}
+
+// SocialAuthorize_Success carries the URL that starts a sign-in at an identity provider
+type SocialAuthorize_Success struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://accounts.google.com/o/oauth2/v2/auth?client_id=...&code_challenge=...&state=..."`
+	State            string `json:"state" example:"q3Jz0n8c7yH2m4pQ9sX1vW6tR5eU0iO3aS8dF2gH7jK"`
+}
+
+// ExportIdentity is a linked identity provider account in an export
+type ExportIdentity struct {
+	Provider   string `json:"provider" example:"google"`
+	Email      string `json:"email,omitempty" example:"user@example.com"`
+	CreatedAt  string `json:"created_at" example:"2024-12-05T08:00:00Z"`
+	LastUsedAt string `json:"last_used_at,omitempty" example:"2024-12-05T08:00:00Z"`
+}
+
+// UserRoles_Response lists the roles of a user and the permissions they add up to
+type UserRoles_Response struct {
+	UUID        string   `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Roles       []string `json:"roles" example:"guest,host"`
+	Permissions []string `json:"permissions" example:"bookings:create,listings:manage"`
+}
+
+// AuditEventResponse is one entry of the audit trail
+type AuditEventResponse struct {
+	ActorUUID  string `json:"actor_uuid,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Action     string `json:"action" example:"role.granted"`
+	TargetUUID string `json:"target_uuid" example:"9b2f0c1e-7a55-4d8e-b0c4-5f3e1d2a6b7c"`
+	Detail     string `json:"detail" example:"host"`
+	ClientIP   string `json:"client_ip,omitempty" example:"203.0.113.7"`
+	CreatedAt  string `json:"created_at" example:"2024-12-05T08:00:00Z"`
+}
+
+// CountryResponse is the public view of a country. Name is in the language
+// negotiated from ?lang= or Accept-Language.
+type CountryResponse struct {
+	Shortname      string   `json:"shortname" example:"JP"`
+	Name           string   `json:"name" example:"Japan"`
+	CallingCode    *int     `json:"calling_code,omitempty" example:"81"`
+	CurrencyCode   string   `json:"currency_code,omitempty" example:"JPY"`
+	CurrencyName   string   `json:"currency_name,omitempty" example:"Japanese yen"`
+	CurrencySymbol string   `json:"currency_symbol,omitempty" example:"¥"`
+	TimezoneName   string   `json:"timezone_name,omitempty" example:"Asia/Tokyo"`
+	Latitude       *float64 `json:"latitude,omitempty" example:"36.2"`
+	Longitude      *float64 `json:"longitude,omitempty" example:"138.25"`
+}
+
+// CountryAdminResponse is a country with every setting, for admins
+type CountryAdminResponse struct {
+	CountryRequest
+	CreatedTime  string `json:"created_time,omitempty" example:"2024-12-05T08:00:00Z"`
+	LastModified string `json:"last_modified,omitempty" example:"2025-01-20T14:30:00Z"`
+}
+
+// CountryImport_Result counts what a bulk import did
+type CountryImport_Result struct {
+	Created   int `json:"created" example:"2"`
+	Updated   int `json:"updated" example:"5"`
+	Unchanged int `json:"unchanged" example:"180"`
+}
+
+// MoneyResponse is an amount of money. Amount is a decimal string so clients
+// never lose precision to floating point.
+type MoneyResponse struct {
+	Currency   string `json:"currency" example:"JPY"`
+	Amount     string `json:"amount" example:"18762"`
+	MinorUnits int64  `json:"minor_units" example:"18762"`
+	Formatted  string `json:"formatted" example:"¥18,762"`
+}
+
+// CurrencyConversionResponse is an amount and what it is worth in another
+// currency. At is set when historical rates were used.
+type CurrencyConversionResponse struct {
+	From MoneyResponse `json:"from"`
+	To   MoneyResponse `json:"to"`
+	Rate string        `json:"rate" example:"149.5"`
+	At   string        `json:"at,omitempty" example:"2025-01-20T14:30:00Z"`
+}
+
+// RateRefresh_Result counts what an exchange rate refresh did with the
+// currencies the country table uses
+type RateRefresh_Result struct {
+	Recorded  int `json:"recorded" example:"12"`
+	Unchanged int `json:"unchanged" example:"30"`
+	Rejected  int `json:"rejected" example:"1"` // moved more than the configured threshold
+	Missing   int `json:"missing" example:"2"`  // not published by the source
+}
+
+// ExchangeRateResponse is an entry of the exchange rate history. Previous is
+// the rate it replaced, if any.
+type ExchangeRateResponse struct {
+	CurrencyCode string `json:"currency_code" example:"JPY"`
+	Rate         string `json:"rate" example:"149.5"`
+	Previous     string `json:"previous,omitempty" example:"121.3"`
+	Source       string `json:"source" example:"admin"`
+	EffectiveAt  string `json:"effective_at" example:"2025-01-20T14:30:00Z"`
+}
+
+// MessageResponse represents a plain success message
+type MessageResponse struct {
+	Message string `json:"message" example:"Logged out"`
+}
+
+// ErrorResponse represents error response. Code is stable and meant for
+// programs; Message is for people. Fields is set for validation errors.
 type ErrorResponse struct {
-	Error string `json:"error" example:"Error Message"`
+	Code      string            `json:"code" example:"validation_failed"`
+	Message   string            `json:"message" example:"request validation failed"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	RequestID string            `json:"request_id,omitempty" example:"3f0c1b9e-5d1a-4c1e-9a43-2b7f6f0e8d21"`
 }
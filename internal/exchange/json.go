@@ -0,0 +1,143 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxDocumentBytes bounds the rate document read from a source
+const maxDocumentBytes = 1 << 20
+
+// currencyCode matches an ISO 4217 code
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// JSONProvider reads rates from a JSON document in the common format
+//
+//	{"base": "USD", "timestamp": 1718000000, "rates": {"EUR": 0.92, "JPY": 149.5}}
+//
+// served over HTTP(S) or from a file, which makes a local stand-in easy.
+// "date": "2024-06-10" may replace timestamp; with neither, the rates are
+// taken to be published when fetched.
+type JSONProvider struct {
+	source string
+	client *http.Client
+}
+
+// NewJSONProvider creates a provider for source: an http(s) URL, a file://
+// URL or a file path
+func NewJSONProvider(source string, timeout time.Duration) *JSONProvider {
+	return &JSONProvider{
+		source: source,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name returns the host or file the rates come from
+func (p *JSONProvider) Name() string {
+	name := strings.TrimPrefix(p.source, "file://")
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+		name, _, _ = strings.Cut(name, "/")
+	}
+	// The history column is short; the start of the name is the useful part
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// Latest fetches and parses the rate document
+func (p *JSONProvider) Latest(ctx context.Context) (*Quote, error) {
+	fetchedAt := time.Now()
+	body, err := p.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseQuote(body, fetchedAt)
+}
+
+// fetch reads the raw document from the source
+func (p *JSONProvider) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(p.source, "http://") && !strings.HasPrefix(p.source, "https://") {
+		f, err := os.Open(strings.TrimPrefix(p.source, "file://"))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(io.LimitReader(f, maxDocumentBytes))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange rate source returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDocumentBytes))
+}
+
+// document is the wire format read by JSONProvider
+type document struct {
+	Base      string                 `json:"base"`
+	Timestamp int64                  `json:"timestamp"`
+	Date      string                 `json:"date"`
+	Rates     map[string]json.Number `json:"rates"`
+}
+
+// parseQuote validates a rate document. Numbers are kept as written.
+func parseQuote(body []byte, fetchedAt time.Time) (*Quote, error) {
+	var doc document
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse exchange rates: %w", err)
+	}
+
+	quote := &Quote{
+		Base:  strings.ToUpper(doc.Base),
+		Rates: make(map[string]string, len(doc.Rates)),
+		AsOf:  fetchedAt,
+	}
+	if !currencyCode.MatchString(quote.Base) {
+		return nil, fmt.Errorf("exchange rates have invalid base %q", doc.Base)
+	}
+	switch {
+	case doc.Timestamp > 0:
+		quote.AsOf = time.Unix(doc.Timestamp, 0)
+	case doc.Date != "":
+		date, err := time.Parse(time.DateOnly, doc.Date)
+		if err != nil {
+			return nil, fmt.Errorf("exchange rates have invalid date %q", doc.Date)
+		}
+		quote.AsOf = date
+	}
+
+	for code, rate := range doc.Rates {
+		code = strings.ToUpper(code)
+		if !currencyCode.MatchString(code) {
+			return nil, fmt.Errorf("exchange rates have invalid currency %q", code)
+		}
+		quote.Rates[code] = rate.String()
+	}
+	if len(quote.Rates) == 0 {
+		return nil, fmt.Errorf("exchange rates document has no rates")
+	}
+	return quote, nil
+}
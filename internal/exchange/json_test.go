@@ -0,0 +1,132 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuote(t *testing.T) {
+	fetchedAt := time.Date(2025, 1, 20, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		body    string
+		want    *Quote
+		wantErr string
+	}{
+		{
+			name: "timestamp",
+			body: `{"base": "usd", "timestamp": 1718000000, "rates": {"eur": 0.92, "JPY": 149.5}}`,
+			want: &Quote{Base: "USD", Rates: map[string]string{"EUR": "0.92", "JPY": "149.5"}, AsOf: time.Unix(1718000000, 0)},
+		},
+		{
+			name: "date",
+			body: `{"base": "EUR", "date": "2024-06-10", "rates": {"USD": 1.08}}`,
+			want: &Quote{Base: "EUR", Rates: map[string]string{"USD": "1.08"}, AsOf: time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "neither, taken as fetched",
+			body: `{"base": "USD", "rates": {"JPY": 149.5}}`,
+			want: &Quote{Base: "USD", Rates: map[string]string{"JPY": "149.5"}, AsOf: fetchedAt},
+		},
+		{
+			name: "numbers kept as written",
+			body: `{"base": "USD", "rates": {"JPY": 149.50, "KWD": 0.30712345678901234567, "IDR": 1.6e4}}`,
+			want: &Quote{Base: "USD", Rates: map[string]string{"JPY": "149.50", "KWD": "0.30712345678901234567", "IDR": "1.6e4"}, AsOf: fetchedAt},
+		},
+		{name: "not JSON", body: `<html>`, wantErr: "parse exchange rates"},
+		{name: "rate that is not a number", body: `{"base": "USD", "rates": {"JPY": "n/a"}}`, wantErr: "parse exchange rates"},
+		{name: "invalid base", body: `{"base": "dollar", "rates": {"JPY": 149.5}}`, wantErr: `invalid base "dollar"`},
+		{name: "missing base", body: `{"rates": {"JPY": 149.5}}`, wantErr: "invalid base"},
+		{name: "invalid date", body: `{"base": "USD", "date": "10/06/2024", "rates": {"JPY": 149.5}}`, wantErr: "invalid date"},
+		{name: "invalid currency", body: `{"base": "USD", "rates": {"YEN": 149.5, "JP": 1}}`, wantErr: "invalid currency"},
+		{name: "no rates", body: `{"base": "USD", "rates": {}}`, wantErr: "no rates"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseQuote([]byte(tt.body), fetchedAt)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Base != tt.want.Base || !got.AsOf.Equal(tt.want.AsOf) || !reflect.DeepEqual(got.Rates, tt.want.Rates) {
+				t.Errorf("quote = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONProviderSources(t *testing.T) {
+	const body = `{"base": "USD", "timestamp": 1718000000, "rates": {"JPY": 149.5}}`
+
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/latest.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		name     string
+		source   string
+		wantName string
+		wantErr  string
+	}{
+		{name: "file path", source: path, wantName: path},
+		{name: "file URL", source: "file://" + path, wantName: path},
+		{name: "HTTP", source: server.URL + "/latest.json", wantName: host},
+		{name: "HTTP error", source: server.URL + "/missing.json", wantName: host, wantErr: "404 Not Found"},
+		{name: "missing file", source: filepath.Join(t.TempDir(), "missing.json"), wantErr: "no such file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewJSONProvider(tt.source, time.Second)
+			if tt.wantName != "" && provider.Name() != tt.wantName {
+				t.Errorf("name = %q, want %q", provider.Name(), tt.wantName)
+			}
+
+			quote, err := provider.Latest(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quote.Rates["JPY"] != "149.5" || !quote.AsOf.Equal(time.Unix(1718000000, 0)) {
+				t.Errorf("quote = %+v, want JPY 149.5 as of the timestamp", quote)
+			}
+		})
+	}
+}
+
+func TestJSONProviderNameIsShort(t *testing.T) {
+	source := "https://" + strings.Repeat("a", 80) + ".example.com/latest.json"
+	if name := NewJSONProvider(source, time.Second).Name(); len(name) != 64 {
+		t.Errorf("name has %d characters, want 64", len(name))
+	}
+}
@@ -0,0 +1,26 @@
+// Package exchange fetches exchange rates from an external source. Rates are
+// kept as decimal strings, exactly as published, so they can be stored and
+// converted without floating-point error.
+package exchange
+
+import (
+	"context"
+	"time"
+)
+
+// Quote is a set of rates published together
+type Quote struct {
+	// Base is the currency the rates are quoted against
+	Base string
+	// Rates maps ISO 4217 codes to units of that currency per unit of Base
+	Rates map[string]string
+	// AsOf is when the source published the rates
+	AsOf time.Time
+}
+
+// Provider fetches the latest published rates
+type Provider interface {
+	// Name identifies the source in the rate history
+	Name() string
+	Latest(ctx context.Context) (*Quote, error)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"go-booking-system/internal/apperror"
 	"go-booking-system/internal/dto"
 	"go-booking-system/internal/service"
 	"net/http"
@@ -20,15 +21,37 @@ func NewAccountHandler(accountService service.AccountService) *AccountHandler {
 	}
 }
 
+// errMissingAuth is reported when a protected handler runs without RequireAuth
+var errMissingAuth = apperror.Unauthorized("authorization_required", "authorization required")
+
+// userUUIDFromContext returns the UUID that RequireAuth stored in context.
+// It records the error for the error middleware when the value is missing.
+func userUUIDFromContext(c *gin.Context) (string, bool) {
+	userUUID, exists := c.Get("userUUID")
+	if !exists {
+		_ = c.Error(errMissingAuth)
+		return "", false
+	}
+
+	// Convert interface{} to string
+	uuid, ok := userUUID.(string)
+	if !ok {
+		_ = c.Error(apperror.Internal("invalid user UUID format", nil))
+		return "", false
+	}
+
+	return uuid, true
+}
+
 // SignUp godoc
 // @Summary Register a new user
-// @Description Create a new user account with email, password, name, phone, and country
+// @Description Create a new user account with email, password, name, phone, and country. The phone is stored in E.164 form; national numbers are read with the country's numbering plan.
 // @Tags Account
 // @Accept json
 // @Produce json
 // @Param input body dto.SignUpRequest true "User registration data"
 // @Success 201 {object} dto.SignUp_Success "User registered successfully"
-// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data, unknown country or invalid phone number"
 // @Failure 409 {object} dto.ErrorResponse "Email already registered"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Router /api/account/signup [post]
@@ -37,19 +60,15 @@ func (h *AccountHandler) SignUp(c *gin.Context) {
 
 	// Validate HTTP input
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	// Call service layer for business logic
-	result, err := h.accountService.SignUp(input)
+	result, err := h.accountService.SignUp(c.Request.Context(), input)
 	if err != nil {
-		// Handle specific errors
-		if err.Error() == "email already registered" {
-			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
 		return
 	}
 
@@ -59,14 +78,16 @@ func (h *AccountHandler) SignUp(c *gin.Context) {
 
 // SignIn godoc
 // @Summary User login
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. Accounts with two-factor authentication get a challenge token instead of tokens; finish with /api/account/2fa/verify.
 // @Tags Account
 // @Accept json
 // @Produce json
 // @Param input body dto.SignInRequest true "Login credentials"
-// @Success 200 {object} dto.SignUp_Success "Login successful"
+// @Success 200 {object} dto.SignIn_Success "Login successful or two-factor code required"
 // @Failure 400 {object} dto.ErrorResponse "Invalid input data"
 // @Failure 401 {object} dto.ErrorResponse "Invalid credentials"
+// @Failure 423 {object} dto.ErrorResponse "Account temporarily locked; see Retry-After"
+// @Failure 429 {object} dto.ErrorResponse "Too many failed attempts from this client; see Retry-After"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Router /api/account/signin [post]
 func (h *AccountHandler) SignIn(c *gin.Context) {
@@ -74,19 +95,15 @@ func (h *AccountHandler) SignIn(c *gin.Context) {
 
 	// Validate HTTP input
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	// Call service layer for business logic
-	result, err := h.accountService.SignIn(input)
+	result, err := h.accountService.SignIn(c.Request.Context(), input, c.ClientIP())
 	if err != nil {
-		// Handle specific errors
-		if err.Error() == "invalid credentials" {
-			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
 		return
 	}
 
@@ -109,32 +126,936 @@ func (h *AccountHandler) SignIn(c *gin.Context) {
 func (h *AccountHandler) GetProfile(c *gin.Context) {
 	// Get the user UUID that the middleware stored in context
 	// The RequireAuth middleware extracts this from the JWT token
-	userUUID, exists := c.Get("userUUID")
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.GetProfile(c.Request.Context(), uuid)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a rotated refresh token. Replaying an already used refresh token revokes every session from the same sign-in.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param input body dto.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} dto.RefreshToken_Success "Token refreshed"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Failure 401 {object} dto.ErrorResponse "Invalid, expired or reused refresh token"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/token/refresh [post]
+func (h *AccountHandler) RefreshToken(c *gin.Context) {
+	var input dto.RefreshTokenRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.RefreshToken(c.Request.Context(), input)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke the presented access token and the refresh token issued with it
+// @Tags Account
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.MessageResponse "Logged out"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/logout [post]
+func (h *AccountHandler) Logout(c *gin.Context) {
+	// Get the token claims that the middleware stored in context
+	value, exists := c.Get("tokenClaims")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
-			Error: "User not found in context",
-		})
+		_ = c.Error(errMissingAuth)
 		return
 	}
 
-	// Convert interface{} to string
-	uuid, ok := userUUID.(string)
+	claims, ok := value.(*service.AccessClaims)
+	if !ok {
+		_ = c.Error(apperror.Internal("invalid token claims format", nil))
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.Logout(c.Request.Context(), claims); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Logged out"})
+}
+
+// LogoutAll godoc
+// @Summary Log out everywhere
+// @Description Revoke every access and refresh token issued to the authenticated user
+// @Tags Account
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.MessageResponse "Logged out from all devices"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/logout-all [post]
+func (h *AccountHandler) LogoutAll(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.LogoutAll(c.Request.Context(), uuid); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Logged out from all devices"})
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm an email address with the single-use token from the verification email. Request a new access token afterwards to pick up the verified status.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param input body dto.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} dto.MessageResponse "Email verified"
+// @Failure 400 {object} dto.ErrorResponse "Invalid or expired verification token"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/verify-email [post]
+func (h *AccountHandler) VerifyEmail(c *gin.Context) {
+	var input dto.VerifyEmailRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.VerifyEmail(c.Request.Context(), input); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Email verified"})
+}
+
+// ResendVerificationEmail godoc
+// @Summary Resend verification email
+// @Description Send a new verification link to the authenticated user's email address. Previously sent links stop working.
+// @Tags Account
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 202 {object} dto.MessageResponse "Verification email sent"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 409 {object} dto.ErrorResponse "Email already verified"
+// @Failure 429 {object} dto.ErrorResponse "Verification email recently sent"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/verify-email/resend [post]
+func (h *AccountHandler) ResendVerificationEmail(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.ResendVerificationEmail(c.Request.Context(), uuid); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusAccepted, dto.MessageResponse{Message: "Verification email sent"})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use password reset link. Always returns 202 so the response does not reveal whether an account exists.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param input body dto.ForgotPasswordRequest true "Account email"
+// @Success 202 {object} dto.MessageResponse "Reset email sent if the account exists"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Router /api/account/password/forgot [post]
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	var input dto.ForgotPasswordRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	h.accountService.ForgotPassword(c.Request.Context(), input)
+
+	// Return the same response whether or not the account exists
+	c.JSON(http.StatusAccepted, dto.MessageResponse{
+		Message: "If an account exists for this email, a reset link has been sent",
+	})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with the token from the reset email. Signs the user out of every device.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param input body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} dto.MessageResponse "Password reset"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data or invalid/expired reset token"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/password/reset [post]
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var input dto.ResetPasswordRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.ResetPassword(c.Request.Context(), input); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Password has been reset"})
+}
+
+// UpdateProfile godoc
+// @Summary Update user profile
+// @Description Update the authenticated user's name, phone or mobile country. Omitted fields are left unchanged; an empty country clears it.
+// @Tags Account
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body dto.UpdateProfileRequest true "Profile fields to change"
+// @Success 200 {object} dto.UserResponse "Updated profile"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data, unknown country or invalid phone number"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Email address not verified"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/profile [patch]
+func (h *AccountHandler) UpdateProfile(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.UpdateProfileRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.UpdateProfile(c.Request.Context(), uuid, input)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Change the password after confirming the current one. All sessions are signed out and a new token pair is returned for this device.
+// @Tags Account
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body dto.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} dto.ChangePassword_Success "Password changed"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Invalid current password"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/password [post]
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.ChangePasswordRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.ChangePassword(c.Request.Context(), uuid, input)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// RequestEmailChange godoc
+// @Summary Request email change
+// @Description Send a confirmation link to a new email address. The account email only changes once the link is followed.
+// @Tags Account
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body dto.ChangeEmailRequest true "New email and current password"
+// @Success 202 {object} dto.MessageResponse "Confirmation email sent"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Invalid current password"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 409 {object} dto.ErrorResponse "Email already registered"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/email [post]
+func (h *AccountHandler) RequestEmailChange(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Error: "Invalid user UUID format",
-		})
+		return
+	}
+
+	var input dto.ChangeEmailRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.RequestEmailChange(c.Request.Context(), uuid, input); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusAccepted, dto.MessageResponse{Message: "Confirmation email sent to the new address"})
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm email change
+// @Description Switch the account to the new email address using the token sent to it
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param input body dto.ConfirmEmailChangeRequest true "Confirmation token"
+// @Success 200 {object} dto.MessageResponse "Email changed"
+// @Failure 400 {object} dto.ErrorResponse "Invalid or expired confirmation token"
+// @Failure 409 {object} dto.ErrorResponse "Email already registered"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/email/confirm [post]
+func (h *AccountHandler) ConfirmEmailChange(c *gin.Context) {
+	var input dto.ConfirmEmailChangeRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.ConfirmEmailChange(c.Request.Context(), input); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Email changed"})
+}
+
+// DeleteAccount godoc
+// @Summary Delete account
+// @Description Delete the authenticated user's account after confirming the password. The account is signed out everywhere immediately; personal data is anonymised after the grace period.
+// @Tags Account
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body dto.DeleteAccountRequest true "Current password"
+// @Success 200 {object} dto.MessageResponse "Account deleted"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Invalid current password"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 409 {object} dto.ErrorResponse "Cannot delete the last admin account"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account [delete]
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.DeleteAccountRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.DeleteAccount(c.Request.Context(), uuid, input); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Account deleted"})
+}
+
+// ExportAccount godoc
+// @Summary Export account data
+// @Description Download a JSON archive of everything stored about the authenticated user
+// @Tags Account
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.AccountExport "Account data archive"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/export [get]
+func (h *AccountHandler) ExportAccount(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.ExportAccount(c.Request.Context(), uuid)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Offer the archive as a file download
+	c.Header("Content-Disposition", `attachment; filename="account-export-`+uuid+`.json"`)
+	c.Header("Cache-Control", "no-store")
+	c.IndentedJSON(http.StatusOK, result)
+}
+
+// SetupTwoFactor godoc
+// @Summary Start two-factor setup
+// @Description Generate a TOTP secret for an authenticator app. Two-factor sign-in is only enabled after the first code is confirmed.
+// @Tags Two-Factor
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} dto.TwoFactorSetup_Success "Secret and otpauth:// URI"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 409 {object} dto.ErrorResponse "Two-factor authentication already enabled"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/2fa/setup [post]
+func (h *AccountHandler) SetupTwoFactor(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.SetupTwoFactor(c.Request.Context(), uuid)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Confirm two-factor setup
+// @Description Enable two-factor sign-in with a code from the authenticator app. Returns one-time recovery codes that are not shown again.
+// @Tags Two-Factor
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body dto.TwoFactorCodeRequest true "Current TOTP code"
+// @Success 200 {object} dto.RecoveryCodes_Success "Two-factor enabled"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data, invalid code or setup not started"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 409 {object} dto.ErrorResponse "Two-factor authentication already enabled"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/2fa/confirm [post]
+func (h *AccountHandler) ConfirmTwoFactor(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.TwoFactorCodeRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.ConfirmTwoFactor(c.Request.Context(), uuid, input)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// VerifyTwoFactor godoc
+// @Summary Complete two-factor sign-in
+// @Description Exchange the challenge token from /api/account/signin and a TOTP or recovery code for an access and refresh token
+// @Tags Two-Factor
+// @Accept json
+// @Produce json
+// @Param input body dto.TwoFactorVerifyRequest true "Challenge token and code"
+// @Success 200 {object} dto.SignIn_Success "Login successful"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Failure 401 {object} dto.ErrorResponse "Invalid code or invalid/expired challenge token"
+// @Failure 423 {object} dto.ErrorResponse "Account temporarily locked; see Retry-After"
+// @Failure 429 {object} dto.ErrorResponse "Too many failed attempts from this client; see Retry-After"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/2fa/verify [post]
+func (h *AccountHandler) VerifyTwoFactor(c *gin.Context) {
+	var input dto.TwoFactorVerifyRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.VerifyTwoFactor(c.Request.Context(), input, c.ClientIP())
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor sign-in after confirming the password. Remaining recovery codes are discarded.
+// @Tags Two-Factor
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body dto.PasswordConfirmRequest true "Current password"
+// @Success 200 {object} dto.MessageResponse "Two-factor disabled"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data or two-factor not enabled"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Invalid current password"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/2fa/disable [post]
+func (h *AccountHandler) DisableTwoFactor(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.PasswordConfirmRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.DisableTwoFactor(c.Request.Context(), uuid, input); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, dto.MessageResponse{Message: "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes after confirming the password. Previous codes stop working.
+// @Tags Two-Factor
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body dto.PasswordConfirmRequest true "Current password"
+// @Success 200 {object} dto.RecoveryCodes_Success "New recovery codes"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data or two-factor not enabled"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Invalid current password"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/2fa/recovery-codes [post]
+func (h *AccountHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.PasswordConfirmRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.RegenerateRecoveryCodes(c.Request.Context(), uuid, input)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// AuthorizeSocial godoc
+// @Summary Start social sign-in
+// @Description Start an OpenID Connect sign-in (authorization code + PKCE) at an identity provider. Send the browser to authorization_url; the provider redirects back with code and state for /api/account/oauth/{provider}/callback.
+// @Tags Social Sign-In
+// @Produce json
+// @Param provider path string true "Identity provider" Enums(google, apple)
+// @Success 200 {object} dto.SocialAuthorize_Success "Authorization URL"
+// @Failure 404 {object} dto.ErrorResponse "Unknown identity provider"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/oauth/{provider}/authorize [get]
+func (h *AccountHandler) AuthorizeSocial(c *gin.Context) {
+	// Call service layer for business logic
+	result, err := h.accountService.StartSocialSignIn(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// SocialCallback godoc
+// @Summary Complete social sign-in
+// @Description Exchange the provider's code and state for tokens. An identity seen before signs in its account; otherwise it is linked to the account with the same verified email, or a new account is created. Accounts with two-factor authentication get a challenge token instead.
+// @Tags Social Sign-In
+// @Accept json
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param provider path string true "Identity provider" Enums(google, apple)
+// @Param input body dto.SocialCallbackRequest true "Code and state from the provider redirect"
+// @Success 200 {object} dto.SignIn_Success "Login successful or two-factor code required"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data or invalid/expired state"
+// @Failure 401 {object} dto.ErrorResponse "Identity provider rejected the sign-in"
+// @Failure 403 {object} dto.ErrorResponse "Provider email not verified or account deleted"
+// @Failure 404 {object} dto.ErrorResponse "Unknown identity provider"
+// @Failure 409 {object} dto.ErrorResponse "Email registered but not verified, or account already linked to another identity of the provider"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/oauth/{provider}/callback [post]
+func (h *AccountHandler) SocialCallback(c *gin.Context) {
+	var input dto.SocialCallbackRequest
+
+	// Validate HTTP input; Apple posts the callback as a form
+	if err := c.ShouldBind(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.CompleteSocialSignIn(c.Request.Context(), c.Param("provider"), input)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// RequestMagicLink godoc
+// @Summary Request a sign-in link
+// @Description Email a single-use sign-in link. Always returns 202 so the response does not reveal whether an account exists.
+// @Tags Passwordless Sign-In
+// @Accept json
+// @Produce json
+// @Param input body dto.MagicLinkRequest true "Account email"
+// @Success 202 {object} dto.MessageResponse "Sign-in link sent if the account exists"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Router /api/account/signin/magic-link [post]
+func (h *AccountHandler) RequestMagicLink(c *gin.Context) {
+	var input dto.MagicLinkRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	h.accountService.RequestMagicLink(c.Request.Context(), input)
+
+	// Return the same response whether or not the account exists
+	c.JSON(http.StatusAccepted, dto.MessageResponse{
+		Message: "If an account exists for this email, a sign-in link has been sent",
+	})
+}
+
+// SignInWithMagicLink godoc
+// @Summary Sign in with a magic link
+// @Description Exchange the token from a sign-in link for an access and refresh token. Accounts with two-factor authentication get a challenge token instead.
+// @Tags Passwordless Sign-In
+// @Accept json
+// @Produce json
+// @Param input body dto.MagicLinkVerifyRequest true "Sign-in link token"
+// @Success 200 {object} dto.SignIn_Success "Login successful or two-factor code required"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Failure 401 {object} dto.ErrorResponse "Invalid or expired sign-in link"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/signin/magic-link/verify [post]
+func (h *AccountHandler) SignInWithMagicLink(c *gin.Context) {
+	var input dto.MagicLinkVerifyRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.SignInWithMagicLink(c.Request.Context(), input)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// RequestPhoneCode godoc
+// @Summary Request a sign-in code by SMS
+// @Description Text a six-digit sign-in code to the account that verified the phone number. National numbers are read with the given country's numbering plan. Returns 202 whether or not an account uses the number.
+// @Tags Passwordless Sign-In
+// @Accept json
+// @Produce json
+// @Param input body dto.PhoneCodeRequest true "Phone number and country"
+// @Success 202 {object} dto.MessageResponse "Code sent if the number belongs to an account"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data, invalid phone number or unknown country"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/signin/phone [post]
+func (h *AccountHandler) RequestPhoneCode(c *gin.Context) {
+	var input dto.PhoneCodeRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.RequestPhoneCode(c.Request.Context(), input); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return the same response whether or not the account exists
+	c.JSON(http.StatusAccepted, dto.MessageResponse{
+		Message: "If an account uses this number, a sign-in code has been sent",
+	})
+}
+
+// SignInWithPhoneCode godoc
+// @Summary Sign in with an SMS code
+// @Description Exchange the phone number and SMS code for an access and refresh token. Wrong codes count towards the sign-in lockout. Accounts with two-factor authentication get a challenge token instead.
+// @Tags Passwordless Sign-In
+// @Accept json
+// @Produce json
+// @Param input body dto.PhoneCodeVerifyRequest true "Phone number, country and code"
+// @Success 200 {object} dto.SignIn_Success "Login successful or two-factor code required"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data, invalid phone number or unknown country"
+// @Failure 401 {object} dto.ErrorResponse "Invalid or expired code"
+// @Failure 423 {object} dto.ErrorResponse "Account temporarily locked; see Retry-After"
+// @Failure 429 {object} dto.ErrorResponse "Too many failed attempts from this client; see Retry-After"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/signin/phone/verify [post]
+func (h *AccountHandler) SignInWithPhoneCode(c *gin.Context) {
+	var input dto.PhoneCodeVerifyRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.accountService.SignInWithPhoneCode(c.Request.Context(), input, c.ClientIP())
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// RequestPhoneVerification godoc
+// @Summary Send phone verification code
+// @Description Text a six-digit code to the account's phone number. Only one account can verify a given number.
+// @Tags Account
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 202 {object} dto.MessageResponse "Verification code sent"
+// @Failure 400 {object} dto.ErrorResponse "No or invalid phone number on account"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Email address not verified"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 409 {object} dto.ErrorResponse "Phone already verified, by this or another account"
+// @Failure 429 {object} dto.ErrorResponse "Verification code recently sent"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/phone/verify/send [post]
+func (h *AccountHandler) RequestPhoneVerification(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Call service layer for business logic
+	if err := h.accountService.RequestPhoneVerification(c.Request.Context(), uuid); err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusAccepted, dto.MessageResponse{Message: "Verification code sent"})
+}
+
+// VerifyPhone godoc
+// @Summary Verify phone number
+// @Description Confirm the account's phone number with the code sent by SMS. A verified number can be used for SMS sign-in.
+// @Tags Account
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body dto.PhoneVerifyRequest true "SMS code"
+// @Success 200 {object} dto.UserResponse "Updated profile"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data or invalid/expired code"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Email address not verified"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 409 {object} dto.ErrorResponse "Phone number already verified by another account"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/account/phone/verify [post]
+func (h *AccountHandler) VerifyPhone(c *gin.Context) {
+	// Get the user UUID that the middleware stored in context
+	uuid, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.PhoneVerifyRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	// Call service layer for business logic
-	result, err := h.accountService.GetProfile(uuid)
+	result, err := h.accountService.VerifyPhone(c.Request.Context(), uuid, input)
 	if err != nil {
-		// Handle specific errors
-		if err.Error() == "user not found" {
-			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
 		return
 	}
 
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/middleware"
+	"go-booking-system/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// forgotPasswordRecorder records password reset requests. Calling any other
+// method panics on the nil embedded service.
+type forgotPasswordRecorder struct {
+	service.AccountService
+	requests []dto.ForgotPasswordRequest
+}
+
+func (r *forgotPasswordRecorder) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) {
+	r.requests = append(r.requests, req)
+}
+
+func TestForgotPasswordAlwaysAccepted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCalled bool
+	}{
+		{"registered email", `{"email":"guest@example.com"}`, http.StatusAccepted, true},
+		{"unknown email", `{"email":"nobody@example.com"}`, http.StatusAccepted, true},
+		{"not an email", `{"email":"nobody"}`, http.StatusBadRequest, false},
+		{"missing email", `{}`, http.StatusBadRequest, false},
+	}
+
+	var bodies []string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &forgotPasswordRecorder{}
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.POST("/api/account/password/forgot", NewAccountHandler(recorder).ForgotPassword)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/account/password/forgot", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if called := len(recorder.requests) == 1; called != tt.wantCalled {
+				t.Errorf("service called = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.wantStatus == http.StatusAccepted {
+				bodies = append(bodies, w.Body.String())
+			}
+		})
+	}
+
+	// Nothing in the response tells registered and unknown addresses apart
+	if len(bodies) == 2 && bodies[0] != bodies[1] {
+		t.Errorf("responses differ: %s vs %s", bodies[0], bodies[1])
+	}
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles administrative HTTP requests
+type AdminHandler struct {
+	roleService     service.RoleService
+	countryService  service.CountryService
+	currencyService service.CurrencyService
+}
+
+// NewAdminHandler creates a new admin handler instance
+func NewAdminHandler(roleService service.RoleService, countryService service.CountryService, currencyService service.CurrencyService) *AdminHandler {
+	return &AdminHandler{
+		roleService:     roleService,
+		countryService:  countryService,
+		currencyService: currencyService,
+	}
+}
+
+// GetUserRoles godoc
+// @Summary Get user roles
+// @Description List the roles of a user and the permissions they grant. Requires the roles:manage permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param uuid path string true "User UUID"
+// @Success 200 {object} dto.UserRoles_Response "User roles"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Missing permission"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/admin/users/{uuid}/roles [get]
+func (h *AdminHandler) GetUserRoles(c *gin.Context) {
+	// Call service layer for business logic
+	result, err := h.roleService.GetUserRoles(c.Request.Context(), c.Param("uuid"))
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// GrantRole godoc
+// @Summary Grant a role
+// @Description Grant the host or admin role to a user. The change is recorded in the audit trail and applies to the user's next access token. Requires the roles:manage permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param uuid path string true "User UUID"
+// @Param input body dto.GrantRoleRequest true "Role to grant"
+// @Success 200 {object} dto.UserRoles_Response "Updated roles"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Missing permission"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/admin/users/{uuid}/roles [post]
+func (h *AdminHandler) GrantRole(c *gin.Context) {
+	// Get the acting admin that the middleware stored in context
+	actorUUID, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.GrantRoleRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.roleService.GrantRole(c.Request.Context(), actorUUID, c.Param("uuid"), domain.Role(input.Role), c.ClientIP())
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// RevokeRole godoc
+// @Summary Revoke a role
+// @Description Take the host or admin role away from a user. The change is recorded in the audit trail and the user's access tokens are revoked. Requires the roles:manage permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param uuid path string true "User UUID"
+// @Param role path string true "Role to revoke" Enums(host, admin)
+// @Success 200 {object} dto.UserRoles_Response "Updated roles"
+// @Failure 400 {object} dto.ErrorResponse "Role cannot be revoked"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Missing permission"
+// @Failure 404 {object} dto.ErrorResponse "User not found"
+// @Failure 409 {object} dto.ErrorResponse "Cannot revoke the last admin"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/admin/users/{uuid}/roles/{role} [delete]
+func (h *AdminHandler) RevokeRole(c *gin.Context) {
+	// Get the acting admin that the middleware stored in context
+	actorUUID, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.roleService.RevokeRole(c.Request.Context(), actorUUID, c.Param("uuid"), domain.Role(c.Param("role")), c.ClientIP())
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// ListAuditEvents godoc
+// @Summary List audit events
+// @Description List recent privileged changes, newest first. Requires the roles:manage permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param target query string false "Only events about this user UUID"
+// @Param limit query int false "Maximum number of events (default and max 200)"
+// @Success 200 {array} dto.AuditEventResponse "Audit events"
+// @Failure 400 {object} dto.ErrorResponse "Invalid limit"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Missing permission"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/admin/audit-events [get]
+func (h *AdminHandler) ListAuditEvents(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			_ = c.Error(apperror.Validation(map[string]string{"limit": "must be a positive integer"}))
+			return
+		}
+		limit = parsed
+	}
+
+	// Call service layer for business logic
+	result, err := h.roleService.ListAuditEvents(c.Request.Context(), c.Query("target"), limit)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"go-booking-system/internal/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListCountries godoc
+// @Summary List countries with all settings
+// @Description List every country, disabled ones included, with its fees, rewards and other internal settings. Requires the countries:manage permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {array} dto.CountryAdminResponse "Countries"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Missing permission"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/admin/countries [get]
+func (h *AdminHandler) ListCountries(c *gin.Context) {
+	// Call service layer for business logic
+	result, err := h.countryService.ListAllCountries(c.Request.Context())
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateCountry godoc
+// @Summary Create a country
+// @Description Add a country. The change is recorded in the audit trail. Requires the countries:manage permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param input body dto.CountryRequest true "Country settings"
+// @Success 201 {object} dto.CountryAdminResponse "Created country"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Missing permission"
+// @Failure 409 {object} dto.ErrorResponse "Country already exists"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/admin/countries [post]
+func (h *AdminHandler) CreateCountry(c *gin.Context) {
+	// Get the acting admin that the middleware stored in context
+	actorUUID, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.CountryRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.countryService.CreateCountry(c.Request.Context(), actorUUID, input, c.ClientIP())
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusCreated, result)
+}
+
+// ReplaceCountry godoc
+// @Summary Replace a country
+// @Description Overwrite every setting of a country; omitted fields are cleared. Set disabled to false to re-enable a country. Changed fields are recorded in the audit trail. Requires the countries:manage permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param shortname path string true "Country shortname" example(JP)
+// @Param input body dto.CountryRequest true "Country settings"
+// @Success 200 {object} dto.CountryAdminResponse "Updated country"
+// @Failure 400 {object} dto.ErrorResponse "Invalid input data"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Missing permission"
+// @Failure 404 {object} dto.ErrorResponse "Country not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/admin/countries/{shortname} [put]
+func (h *AdminHandler) ReplaceCountry(c *gin.Context) {
+	// Get the acting admin that the middleware stored in context
+	actorUUID, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.CountryRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.countryService.ReplaceCountry(c.Request.Context(), actorUUID, c.Param("shortname"), input, c.ClientIP())
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
+
+// DisableCountry godoc
+// @Summary Disable a country
+// @Description Withdraw a country from the public country list and from sign-up and profile forms. The row is kept for the users that reference it. Requires the countries:manage permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param shortname path string true "Country shortname" example(JP)
+// @Success 200 {object} dto.CountryAdminResponse "Disabled country"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Missing permission"
+// @Failure 404 {object} dto.ErrorResponse "Country not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/admin/countries/{shortname} [delete]
+func (h *AdminHandler) DisableCountry(c *gin.Context) {
+	// Get the acting admin that the middleware stored in context
+	actorUUID, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.countryService.DisableCountry(c.Request.Context(), actorUUID, c.Param("shortname"), c.ClientIP())
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"go-booking-system/internal/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetExchangeRate godoc
+// @Summary Set an exchange rate
+// @Description Record the rate of a currency against the base currency from now on, e.g. to accept a move the scheduled refresh rejected or to override the rate source. The rate applies to every country using the currency, is appended to the rate history with source admin and is recorded in the audit trail. Requires the countries:manage permission.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param code path string true "ISO 4217 currency code" example(JPY)
+// @Param input body dto.ExchangeRateRequest true "Units of the currency per unit of the base currency"
+// @Success 200 {object} dto.ExchangeRateResponse "Recorded rate"
+// @Failure 400 {object} dto.ErrorResponse "Invalid rate, unknown currency or the base currency"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - invalid or missing token"
+// @Failure 403 {object} dto.ErrorResponse "Missing permission"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/admin/currency/rates/{code} [put]
+func (h *AdminHandler) SetExchangeRate(c *gin.Context) {
+	// Get the acting admin that the middleware stored in context
+	actorUUID, ok := userUUIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input dto.ExchangeRateRequest
+
+	// Validate HTTP input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Call service layer for business logic
+	result, err := h.currencyService.SetRate(c.Request.Context(), actorUUID, c.Param("code"), input.Rate, c.ClientIP())
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
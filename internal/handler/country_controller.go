@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// countryCacheControl lets clients and shared caches keep country data for an
+// hour; it rarely changes, and the ETag makes revalidation cheap
+const countryCacheControl = "public, max-age=3600"
+
+// CountryHandler handles public country HTTP requests
+type CountryHandler struct {
+	countryService service.CountryService
+}
+
+// NewCountryHandler creates a new country handler instance
+func NewCountryHandler(countryService service.CountryService) *CountryHandler {
+	return &CountryHandler{
+		countryService: countryService,
+	}
+}
+
+// ListCountries godoc
+// @Summary List countries
+// @Description List every country with its calling code, currency and time zone. Names are localized from ?lang= or, failing that, Accept-Language (en, zh-CN, zh-TW, ja, ko, th, cs), falling back to English. Responses carry an ETag; send it back in If-None-Match to get 304 Not Modified.
+// @Tags Countries
+// @Produce json
+// @Param lang query string false "Language for names, overriding Accept-Language" example(ja)
+// @Param Accept-Language header string false "Preferred languages for names"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {array} dto.CountryResponse "Countries"
+// @Success 304 "Cached copy is current"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/countries [get]
+func (h *CountryHandler) ListCountries(c *gin.Context) {
+	lang := service.MatchCountryLanguage(c.Query("lang"), c.GetHeader("Accept-Language"))
+
+	// Call service layer for business logic
+	result, err := h.countryService.ListCountries(c.Request.Context(), lang)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response, or 304 when the client's copy is current
+	c.Header("Content-Language", lang.String())
+	writeCacheable(c, result)
+}
+
+// GetCountry godoc
+// @Summary Get a country
+// @Description Get one country by its shortname, e.g. US. Names are localized as for the country list, and responses carry an ETag.
+// @Tags Countries
+// @Produce json
+// @Param shortname path string true "Country shortname" example(JP)
+// @Param lang query string false "Language for the name, overriding Accept-Language" example(ja)
+// @Param Accept-Language header string false "Preferred languages for the name"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} dto.CountryResponse "Country"
+// @Success 304 "Cached copy is current"
+// @Failure 404 {object} dto.ErrorResponse "Country not found"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/countries/{shortname} [get]
+func (h *CountryHandler) GetCountry(c *gin.Context) {
+	lang := service.MatchCountryLanguage(c.Query("lang"), c.GetHeader("Accept-Language"))
+
+	// Call service layer for business logic
+	result, err := h.countryService.GetCountry(c.Request.Context(), c.Param("shortname"), lang)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response, or 304 when the client's copy is current
+	c.Header("Content-Language", lang.String())
+	writeCacheable(c, result)
+}
+
+// writeCacheable writes v as JSON with an ETag derived from its content, and
+// answers 304 Not Modified when the client already holds that version
+func writeCacheable(c *gin.Context, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		_ = c.Error(apperror.Internal("failed to encode response", err))
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", countryCacheControl)
+	// The body depends on the language header as well as the URL
+	c.Header("Vary", "Accept-Language")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag. As RFC 9110
+// requires for If-None-Match, weak validators compare equal to strong ones.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/middleware"
+	"go-booking-system/internal/repository"
+	"go-booking-system/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// countryStore serves a fixed country list. Calling any other method panics
+// on the nil embedded repository.
+type countryStore struct {
+	repository.CountryRepository
+	countries []domain.Country
+}
+
+func (s *countryStore) FindEnabled(ctx context.Context) ([]domain.Country, error) {
+	return s.countries, nil
+}
+
+func (s *countryStore) FindByShortname(ctx context.Context, shortname string) (*domain.Country, error) {
+	for i := range s.countries {
+		if *s.countries[i].Shortname == shortname {
+			return &s.countries[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// newCountryRouter serves the public country routes over countries
+func newCountryRouter(countries ...domain.Country) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewCountryHandler(service.NewCountryService(&countryStore{countries: countries}))
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/api/countries", handler.ListCountries)
+	router.GET("/api/countries/:shortname", handler.GetCountry)
+	return router
+}
+
+func japan() domain.Country {
+	name, shortname, currency, japanese := "Japan", "JP", "JPY", "日本"
+	fee, surcharge, reward := 15, 3.4, 500
+	return domain.Country{
+		Name:                       &name,
+		Shortname:                  &shortname,
+		CurrencyCode:               &currency,
+		NameJaJp:                   &japanese,
+		PlatformFeePercent:         &fee,
+		PaypalTransactionSurcharge: &surcharge,
+		PaypalPayoutFee:            &surcharge,
+		ReferralReward:             &reward,
+		ReferralHostReward:         &reward,
+	}
+}
+
+func serveCountries(router *gin.Engine, path string, header map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCountryResponsesAreCacheable(t *testing.T) {
+	router := newCountryRouter(japan())
+
+	for _, path := range []string{"/api/countries", "/api/countries/jp"} {
+		t.Run(path, func(t *testing.T) {
+			w := serveCountries(router, path, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			etag := w.Header().Get("ETag")
+			if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) || len(etag) < 3 {
+				t.Fatalf("ETag = %q, want a quoted validator", etag)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Language" {
+				t.Errorf("Vary = %q, want Accept-Language", got)
+			}
+			if got := w.Header().Get("Cache-Control"); got != countryCacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, countryCacheControl)
+			}
+
+			// The same content always gets the same validator
+			if again := serveCountries(router, path, nil).Header().Get("ETag"); again != etag {
+				t.Errorf("second ETag = %q, want %q", again, etag)
+			}
+
+			tests := []struct {
+				name        string
+				ifNoneMatch string
+				wantStatus  int
+			}{
+				{"current copy", etag, http.StatusNotModified},
+				{"weak form of the current copy", "W/" + etag, http.StatusNotModified},
+				{"listed among others", `"stale", ` + etag, http.StatusNotModified},
+				{"any copy", "*", http.StatusNotModified},
+				{"stale copy", `"stale"`, http.StatusOK},
+			}
+			for _, tt := range tests {
+				w := serveCountries(router, path, map[string]string{"If-None-Match": tt.ifNoneMatch})
+				if w.Code != tt.wantStatus {
+					t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+				}
+				if tt.wantStatus == http.StatusNotModified {
+					if w.Body.Len() != 0 {
+						t.Errorf("%s: 304 has a body: %s", tt.name, w.Body.String())
+					}
+					if got := w.Header().Get("ETag"); got != etag {
+						t.Errorf("%s: ETag = %q, want %q", tt.name, got, etag)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCountryResponsesFollowLanguage(t *testing.T) {
+	router := newCountryRouter(japan())
+
+	english := serveCountries(router, "/api/countries/JP", nil)
+	japanese := serveCountries(router, "/api/countries/JP", map[string]string{"Accept-Language": "ja-JP,en;q=0.5"})
+
+	var body dto.CountryResponse
+	if err := json.Unmarshal(japanese.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response %q: %v", japanese.Body.String(), err)
+	}
+	if body.Name != "日本" {
+		t.Errorf("name = %q, want 日本", body.Name)
+	}
+	if got := japanese.Header().Get("Content-Language"); got != "ja" {
+		t.Errorf("Content-Language = %q, want ja", got)
+	}
+	// A cache keyed on the URL alone must not serve one language for another
+	if english.Header().Get("ETag") == japanese.Header().Get("ETag") {
+		t.Errorf("English and Japanese share ETag %s", english.Header().Get("ETag"))
+	}
+}
+
+func TestCountryResponsesLeaveOutFees(t *testing.T) {
+	router := newCountryRouter(japan())
+
+	for _, path := range []string{"/api/countries", "/api/countries/JP"} {
+		w := serveCountries(router, path, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+		for _, field := range []string{"platform_fee", "paypal", "referral", "surcharge", "payout"} {
+			if strings.Contains(w.Body.String(), field) {
+				t.Errorf("%s: response exposes %s: %s", path, field, w.Body.String())
+			}
+		}
+	}
+}
+
+func TestGetCountryNotFound(t *testing.T) {
+	w := serveCountries(newCountryRouter(japan()), "/api/countries/XX", nil)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w.Header().Get("ETag") != "" {
+		t.Errorf("error response has ETag %q", w.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrencyHandler handles currency conversion HTTP requests
+type CurrencyHandler struct {
+	currencyService service.CurrencyService
+}
+
+// NewCurrencyHandler creates a new currency handler instance
+func NewCurrencyHandler(currencyService service.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{
+		currencyService: currencyService,
+	}
+}
+
+// Convert godoc
+// @Summary Convert an amount
+// @Description Convert an amount between two currencies using the rates of the country table. The result is rounded to the target currency's minor unit, so zero-decimal currencies such as JPY and KRW come back in whole units. Amounts are decimal strings and never pass through floating point. With at, the rates in effect at that time are used instead of the current ones, so past conversions can be reproduced.
+// @Tags Currency
+// @Produce json
+// @Param from query string true "ISO 4217 code to convert from" example(USD)
+// @Param to query string true "ISO 4217 code to convert to" example(JPY)
+// @Param amount query string true "Decimal amount in the from currency" example(125.50)
+// @Param at query string false "RFC 3339 time whose rates to use; defaults to now" example(2025-01-20T14:30:00Z)
+// @Success 200 {object} dto.CurrencyConversionResponse "Converted amount"
+// @Failure 400 {object} dto.ErrorResponse "Invalid amount or unknown currency"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /api/currency/convert [get]
+func (h *CurrencyHandler) Convert(c *gin.Context) {
+	var input dto.CurrencyConvertQuery
+
+	// Validate HTTP input
+	if err := c.ShouldBindQuery(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	var at time.Time
+	if input.At != "" {
+		parsed, err := time.Parse(time.RFC3339, input.At)
+		if err != nil {
+			_ = c.Error(apperror.Validation(map[string]string{"at": "must be an RFC 3339 time, e.g. 2025-01-20T14:30:00Z"}))
+			return
+		}
+		at = parsed
+	}
+
+	// Call service layer for business logic
+	result, err := h.currencyService.ConvertAmount(c.Request.Context(), input.From, input.To, input.Amount, at)
+	if err != nil {
+		// Let the error middleware map it to a response
+		_ = c.Error(err)
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, result)
+}
@@ -2,25 +2,42 @@ package handler
 
 import (
 	"go-booking-system/internal/dto"
+	"go-booking-system/internal/health"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // HealthHandler handles health check HTTP requests
-type HealthHandler struct{}
+type HealthHandler struct {
+	checks *health.Registry
+}
 
 // NewHealthHandler creates a new health handler instance
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(checks *health.Registry) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Live godoc
+// @Summary Liveness probe
+// @Description Reports that the process is up and serving requests. It checks no dependencies, so a database outage never gets healthy instances restarted.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} dto.LivenessResponse "Process is alive"
+// @Router /api/health/live [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.LivenessResponse{Status: string(health.StatusUp)})
 }
 
 // HealthStatus godoc
 // @Summary Health check endpoint
-// @Description Check if the server is running and healthy. Status 0 means healthy.
+// @Description Check if the server is running. Status 0 means healthy. Like the liveness probe it checks no dependencies; use /api/health/live or /api/health/ready instead.
 // @Tags Health
 // @Produce json
 // @Success 200 {object} dto.HealthResponse "Server is healthy"
+// @Deprecated
 // @Router /api/health/ [get]
 func (h *HealthHandler) HealthStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.HealthResponse{
@@ -28,3 +45,42 @@ func (h *HealthHandler) HealthStatus(c *gin.Context) {
 		Message: "Server is Healthy",
 	})
 }
+
+// Ready godoc
+// @Summary Readiness probe
+// @Description Runs every registered dependency check and reports its status and latency. Returns 503 when a critical check fails so the load balancer stops routing to this instance.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} dto.ReadinessResponse "Ready to serve traffic"
+// @Failure 503 {object} dto.ReadinessResponse "A critical dependency is down"
+// @Router /api/health/ready [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	// Run the registered dependency checks
+	report := h.checks.Run(c.Request.Context())
+
+	checks := make([]dto.HealthCheckResponse, len(report.Results))
+	for i, result := range report.Results {
+		// Failure details stay in the logs; the probe is unauthenticated
+		if result.Err != nil {
+			slog.WarnContext(c.Request.Context(), "health check failed",
+				"check", result.Name, "critical", result.Critical, "error", result.Err)
+		}
+		checks[i] = dto.HealthCheckResponse{
+			Name:      result.Name,
+			Status:    string(result.Status),
+			Critical:  result.Critical,
+			LatencyMs: float64(result.Latency) / float64(time.Millisecond),
+		}
+	}
+
+	status := http.StatusOK
+	if report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+
+	// Return success response
+	c.JSON(status, dto.ReadinessResponse{
+		Status: string(report.Status),
+		Checks: checks,
+	})
+}
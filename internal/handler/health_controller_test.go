@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/health"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("dial tcp 10.0.0.5:5432: connection refused") }
+
+	tests := []struct {
+		name        string
+		checks      []health.Check
+		wantReady   int
+		wantStatus  string
+		wantResults map[string]string
+	}{
+		{
+			name:       "no checks",
+			wantReady:  http.StatusOK,
+			wantStatus: "up",
+		},
+		{
+			name: "every check passes",
+			checks: []health.Check{
+				{Name: "database", Critical: true, Run: up},
+				{Name: "smtp", Run: up},
+			},
+			wantReady:   http.StatusOK,
+			wantStatus:  "up",
+			wantResults: map[string]string{"database": "up", "smtp": "up"},
+		},
+		{
+			name: "non-critical check fails",
+			checks: []health.Check{
+				{Name: "database", Critical: true, Run: up},
+				{Name: "smtp", Run: down},
+			},
+			wantReady:   http.StatusOK,
+			wantStatus:  "up",
+			wantResults: map[string]string{"database": "up", "smtp": "down"},
+		},
+		{
+			name: "critical check fails",
+			checks: []health.Check{
+				{Name: "database", Critical: true, Run: down},
+				{Name: "smtp", Run: up},
+			},
+			wantReady:   http.StatusServiceUnavailable,
+			wantStatus:  "down",
+			wantResults: map[string]string{"database": "down", "smtp": "up"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := health.NewRegistry()
+			for _, check := range tt.checks {
+				checks.Register(check)
+			}
+			handler := NewHealthHandler(checks)
+			router := gin.New()
+			router.GET("/api/health/live", handler.Live)
+			router.GET("/api/health/ready", handler.Ready)
+			router.GET("/api/health/", handler.HealthStatus)
+
+			serve := func(path string) *httptest.ResponseRecorder {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+				return w
+			}
+
+			// Liveness never depends on the checks
+			if w := serve("/api/health/live"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"up"`) {
+				t.Errorf("live = %d %s, want 200 up", w.Code, w.Body.String())
+			}
+			var legacy dto.HealthResponse
+			w := serve("/api/health/")
+			if err := json.Unmarshal(w.Body.Bytes(), &legacy); err != nil || w.Code != http.StatusOK || legacy.Status != 0 {
+				t.Errorf("legacy health = %d %s, want 200 with status 0", w.Code, w.Body.String())
+			}
+
+			w = serve("/api/health/ready")
+			if w.Code != tt.wantReady {
+				t.Errorf("ready status = %d, want %d", w.Code, tt.wantReady)
+			}
+			var ready dto.ReadinessResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &ready); err != nil {
+				t.Fatalf("response %q: %v", w.Body.String(), err)
+			}
+			if ready.Status != tt.wantStatus {
+				t.Errorf("ready body status = %q, want %q", ready.Status, tt.wantStatus)
+			}
+			results := map[string]string{}
+			for _, check := range ready.Checks {
+				results[check.Name] = check.Status
+			}
+			if len(results) != len(tt.wantResults) {
+				t.Errorf("checks = %v, want %v", results, tt.wantResults)
+			}
+			for name, want := range tt.wantResults {
+				if results[name] != want {
+					t.Errorf("check %s = %q, want %q", name, results[name], want)
+				}
+			}
+			// Failure details stay in the logs
+			if strings.Contains(w.Body.String(), "connection refused") {
+				t.Errorf("response leaks the check error: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHealthProbesWhileDraining(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	checks := health.NewRegistry()
+	checks.Register(health.Check{Name: "database", Critical: true, Run: func(context.Context) error { return nil }})
+	handler := NewHealthHandler(checks)
+	router := gin.New()
+	router.GET("/api/health/live", handler.Live)
+	router.GET("/api/health/ready", handler.Ready)
+
+	checks.Drain()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/health/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("ready status = %d, want %d once draining", w.Code, http.StatusServiceUnavailable)
+	}
+
+	// The process is still alive and must not be restarted mid-drain
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/health/live", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("live status = %d, want %d while draining", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"go-booking-system/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WellKnownHandler serves public discovery documents under /.well-known
+type WellKnownHandler struct {
+	tokenService service.TokenService
+}
+
+// NewWellKnownHandler creates a new well-known handler instance
+func NewWellKnownHandler(tokenService service.TokenService) *WellKnownHandler {
+	return &WellKnownHandler{
+		tokenService: tokenService,
+	}
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens. Select the key by the token's kid header; keys stay listed until they are retired.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} keyring.JWKS "Public signing keys"
+// @Router /.well-known/jwks.json [get]
+func (h *WellKnownHandler) JWKS(c *gin.Context) {
+	// Verifiers may cache the set briefly; rotation adds a key well before it is used
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokenService.PublicKeys())
+}
@@ -0,0 +1,31 @@
+package health
+
+import (
+	"context"
+	"net"
+
+	"gorm.io/gorm"
+)
+
+// Database checks that a connection to the database can be used
+func Database(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// TCP checks that addr accepts connections, e.g. an SMTP relay
+func TCP(addr string) CheckFunc {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
@@ -0,0 +1,137 @@
+// Package health runs the dependency checks behind the readiness probe
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status of a single check or of the whole report
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// errDraining is reported once shutdown has begun
+var errDraining = errors.New("shutting down")
+
+// defaultCheckTimeout bounds a check that was registered without its own timeout
+const defaultCheckTimeout = time.Second
+
+// CheckFunc probes one dependency and returns why it is unusable, if it is
+type CheckFunc func(ctx context.Context) error
+
+// Check is a registered dependency check. Only critical checks take the
+// service out of rotation; the rest are reported for visibility.
+type Check struct {
+	Name     string
+	Critical bool
+	Timeout  time.Duration
+	Run      CheckFunc
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name     string
+	Critical bool
+	Status   Status
+	Latency  time.Duration
+	Err      error
+}
+
+// Report is the outcome of every registered check
+type Report struct {
+	Status  Status
+	Results []Result
+}
+
+// Registry holds the checks run by the readiness probe. Components register
+// their own checks at startup, so new dependencies need no probe changes.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []Check
+	draining atomic.Bool
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = defaultCheckTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check)
+}
+
+// Drain fails readiness from now on, so load balancers stop routing new
+// requests here while the ones in flight finish
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// Run executes all checks concurrently, each under its own timeout. The
+// report is down when any critical check fails or the registry is draining.
+func (r *Registry) Run(ctx context.Context) Report {
+	if r.draining.Load() {
+		return Report{Status: StatusDown, Results: []Result{{
+			Name:     "shutdown",
+			Critical: true,
+			Status:   StatusDown,
+			Err:      errDraining,
+		}}}
+	}
+
+	r.mu.RLock()
+	checks := append([]Check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Results: results}
+	for _, result := range results {
+		if result.Critical && result.Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// run executes one check and times it
+func run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := Result{
+		Name:     check.Name,
+		Critical: check.Critical,
+		Status:   StatusUp,
+		Latency:  time.Since(start),
+		Err:      err,
+	}
+	if err != nil {
+		result.Status = StatusDown
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AppleIssuer is Sign in with Apple's OpenID Connect issuer
+const AppleIssuer = "https://appleid.apple.com"
+
+// appleClientSecretTTL is how long each generated client secret is valid;
+// Apple accepts up to six months but a fresh one is signed per exchange
+const appleClientSecretTTL = 5 * time.Minute
+
+// AppleClientSecret returns a ClientSecretFunc that signs the ES256 JWT Apple
+// expects as client_secret, using the .p8 key downloaded from the developer
+// portal
+func AppleClientSecret(teamID, keyID, clientID, keyPath string) (func() (string, error), error) {
+	raw, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("identity: apple key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("identity: apple key is not an ECDSA key")
+	}
+
+	return func() (string, error) {
+		now := time.Now()
+		// Plain integers: jwt.NumericDate may carry fractional seconds, which Apple rejects
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+			"iss": teamID,
+			"sub": clientID,
+			"aud": AppleIssuer,
+			"iat": now.Unix(),
+			"exp": now.Add(appleClientSecretTTL).Unix(),
+		})
+		token.Header["kid"] = keyID
+		return token.SignedString(key)
+	}, nil
+}
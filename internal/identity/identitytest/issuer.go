@@ -0,0 +1,195 @@
+// Package identitytest provides a fake OpenID Connect issuer for tests
+package identitytest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "test-key"
+
+// User is who signs in at the fake issuer
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+
+	// Nonce, when set, replaces the nonce the client asked for, as a replayed
+	// ID token would
+	Nonce string
+}
+
+// authorization is an issued code waiting to be redeemed
+type authorization struct {
+	clientID  string
+	challenge string
+	nonce     string
+	user      User
+}
+
+// Issuer serves discovery, JWKS and the token endpoint over httptest. Codes
+// are handed out by Authorize instead of a login page, and the token endpoint
+// checks the S256 PKCE challenge before signing an RS256 ID token.
+type Issuer struct {
+	URL string
+
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]authorization
+	secret string // client secret of the last token request
+}
+
+// NewIssuer starts a fake issuer that is closed when the test ends
+func NewIssuer(t *testing.T) *Issuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := &Issuer{key: key, codes: map[string]authorization{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("GET /jwks", issuer.jwks)
+	mux.HandleFunc("POST /token", issuer.token)
+
+	issuer.server = httptest.NewServer(mux)
+	issuer.URL = issuer.server.URL
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// Authorize plays the user approving the sign-in at authURL and returns the
+// authorization code the browser would bring back
+func (i *Issuer) Authorize(t *testing.T, authURL string, user User) string {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if method := query.Get("code_challenge_method"); method != "S256" {
+		t.Fatalf("code_challenge_method = %q, want S256", method)
+	}
+
+	nonce := query.Get("nonce")
+	if user.Nonce != "" {
+		nonce = user.Nonce
+	}
+
+	code := rand.Text()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.codes[code] = authorization{
+		clientID:  query.Get("client_id"),
+		challenge: query.Get("code_challenge"),
+		nonce:     nonce,
+		user:      user,
+	}
+	return code
+}
+
+// ClientSecret returns the client secret sent with the last token request
+func (i *Issuer) ClientSecret() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.secret
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                i.URL,
+		"authorization_endpoint":                i.URL + "/authorize",
+		"token_endpoint":                        i.URL + "/token",
+		"jwks_uri":                              i.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(i.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	i.mu.Lock()
+	i.secret = secret
+	auth, found := i.codes[r.PostForm.Get("code")]
+	// Codes are single use, whether or not the exchange succeeds
+	delete(i.codes, r.PostForm.Get("code"))
+	i.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !found || auth.clientID != clientID || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            i.URL,
+		"sub":            auth.user.Subject,
+		"aud":            clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          auth.nonce,
+		"email":          auth.user.Email,
+		"email_verified": auth.user.EmailVerified,
+		"name":           auth.user.Name,
+	})
+	idToken.Header["kid"] = keyID
+	signed, err := idToken.SignedString(i.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
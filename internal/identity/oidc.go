@@ -0,0 +1,122 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCConfig describes an OpenID Connect client registration
+type OIDCConfig struct {
+	Issuer       string // discovery is done against <Issuer>/.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // "openid" is always requested
+
+	// ClientSecretFunc, when set, is called for every code exchange instead
+	// of using ClientSecret (Apple requires a freshly signed JWT)
+	ClientSecretFunc func() (string, error)
+
+	// AuthParams are added to the authorization URL (e.g. Apple's response_mode)
+	AuthParams map[string]string
+}
+
+// OIDCProvider is a Provider backed by any OpenID Connect issuer. ID tokens
+// are verified against the issuer's published JWKS.
+type OIDCProvider struct {
+	oauth      oauth2.Config
+	verifier   *oidc.IDTokenVerifier
+	secretFunc func() (string, error)
+	authParams []oauth2.AuthCodeOption
+}
+
+// NewOIDCProvider runs discovery against the issuer and returns a provider
+func NewOIDCProvider(ctx context.Context, cfg OIDCConfig) (*OIDCProvider, error) {
+	if cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("identity: client id and redirect url are required")
+	}
+
+	discovered, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("identity: discover %s: %w", cfg.Issuer, err)
+	}
+
+	scopes := append([]string{oidc.ScopeOpenID}, cfg.Scopes...)
+	authParams := make([]oauth2.AuthCodeOption, 0, len(cfg.AuthParams))
+	for key, value := range cfg.AuthParams {
+		authParams = append(authParams, oauth2.SetAuthURLParam(key, value))
+	}
+
+	return &OIDCProvider{
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     discovered.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier:   discovered.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		secretFunc: cfg.ClientSecretFunc,
+		authParams: authParams,
+	}, nil
+}
+
+// AuthCodeURL returns the authorization URL with state, nonce and the S256 PKCE challenge
+func (p *OIDCProvider) AuthCodeURL(state, nonce, verifier string) string {
+	opts := append([]oauth2.AuthCodeOption{
+		oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier),
+	}, p.authParams...)
+	return p.oauth.AuthCodeURL(state, opts...)
+}
+
+// Exchange redeems the code, then verifies the ID token signature, issuer,
+// audience, expiry and nonce before trusting any claim
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	config := p.oauth
+	if p.secretFunc != nil {
+		secret, err := p.secretFunc()
+		if err != nil {
+			return nil, fmt.Errorf("identity: client secret: %w", err)
+		}
+		config.ClientSecret = secret
+	}
+
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("identity: exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("identity: token response has no id_token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("identity: verify id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("identity: id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified any    `json:"email_verified"` // Apple sends "true" as a string
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("identity: decode claims: %w", err)
+	}
+
+	return &Claims{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:          claims.Name,
+	}, nil
+}
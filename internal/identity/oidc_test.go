@@ -0,0 +1,133 @@
+package identity_test
+
+import (
+	"context"
+	"go-booking-system/internal/identity"
+	"go-booking-system/internal/identity/identitytest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func newTestProvider(t *testing.T, issuer *identitytest.Issuer, cfg identity.OIDCConfig) *identity.OIDCProvider {
+	t.Helper()
+
+	cfg.Issuer = issuer.URL
+	cfg.ClientID = "booking-app"
+	cfg.RedirectURL = "https://app.example.com/callback"
+	provider, err := identity.NewOIDCProvider(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	user := identitytest.User{Subject: "sub-123", Email: "guest@example.com", EmailVerified: true, Name: "Guest"}
+
+	tests := []struct {
+		name string
+		user identitytest.User
+		// verifier and nonce override what the client presents when set
+		verifier, nonce string
+		// replay redeems the code once before the exchange under test
+		replay  bool
+		want    *identity.Claims
+		wantErr bool
+	}{
+		{
+			name: "valid exchange",
+			user: user,
+			want: &identity.Claims{Subject: "sub-123", Email: "guest@example.com", EmailVerified: true, Name: "Guest"},
+		},
+		{
+			name: "unverified email",
+			user: identitytest.User{Subject: "sub-123", Email: "guest@example.com"},
+			want: &identity.Claims{Subject: "sub-123", Email: "guest@example.com"},
+		},
+		{
+			name:    "id token for another nonce",
+			user:    identitytest.User{Subject: "sub-123", Nonce: "replayed-nonce"},
+			wantErr: true,
+		},
+		{
+			name:    "client expects another nonce",
+			user:    user,
+			nonce:   "other-nonce",
+			wantErr: true,
+		},
+		{
+			name:     "PKCE verifier mismatch",
+			user:     user,
+			verifier: oauth2.GenerateVerifier(),
+			wantErr:  true,
+		},
+		{
+			name:    "code already redeemed",
+			user:    user,
+			replay:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			issuer := identitytest.NewIssuer(t)
+			provider := newTestProvider(t, issuer, identity.OIDCConfig{ClientSecret: "client-secret"})
+
+			verifier, nonce := oauth2.GenerateVerifier(), "nonce-123"
+			code := issuer.Authorize(t, provider.AuthCodeURL("state-123", nonce, verifier), tt.user)
+			if tt.replay {
+				if _, err := provider.Exchange(ctx, code, verifier, nonce); err != nil {
+					t.Fatalf("first exchange: %v", err)
+				}
+			}
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+
+			claims, err := provider.Exchange(ctx, code, verifier, nonce)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Exchange = %+v, want an error", claims)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *claims != *tt.want {
+				t.Errorf("claims = %+v, want %+v", claims, tt.want)
+			}
+			if secret := issuer.ClientSecret(); secret != "client-secret" {
+				t.Errorf("client secret = %q, want the configured one", secret)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderClientSecretFunc(t *testing.T) {
+	issuer := identitytest.NewIssuer(t)
+	calls := 0
+	provider := newTestProvider(t, issuer, identity.OIDCConfig{
+		ClientSecret: "static-secret",
+		ClientSecretFunc: func() (string, error) {
+			calls++
+			return "signed-secret", nil
+		},
+	})
+
+	verifier := oauth2.GenerateVerifier()
+	code := issuer.Authorize(t, provider.AuthCodeURL("state-123", "nonce-123", verifier), identitytest.User{Subject: "sub-123"})
+	if _, err := provider.Exchange(context.Background(), code, verifier, "nonce-123"); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 1 || issuer.ClientSecret() != "signed-secret" {
+		t.Errorf("client secret = %q after %d calls, want a fresh signed one", issuer.ClientSecret(), calls)
+	}
+}
@@ -0,0 +1,39 @@
+package identity
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnknownProvider is returned for provider names that are not configured
+var ErrUnknownProvider = errors.New("identity: unknown provider")
+
+// Claims is what the application learns about a user from an identity provider
+type Claims struct {
+	Subject       string // stable user id at the provider
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider signs users in through an external identity provider using the
+// authorization-code flow with PKCE
+type Provider interface {
+	// AuthCodeURL returns the URL to send the browser to. state, nonce and
+	// verifier must be kept server-side until the callback.
+	AuthCodeURL(state, nonce, verifier string) string
+	// Exchange redeems the authorization code and returns the verified claims
+	Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error)
+}
+
+// Registry holds the configured providers by name (e.g. "google", "apple")
+type Registry map[string]Provider
+
+// Get returns the named provider or ErrUnknownProvider
+func (r Registry) Get(name string) (Provider, error) {
+	provider, ok := r[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Periodic runs a function on a fixed interval in the background
+type Periodic struct {
+	name     string
+	interval time.Duration
+	fn       func(ctx context.Context) error
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPeriodic creates a job that calls fn every interval once started
+func NewPeriodic(name string, interval time.Duration, fn func(ctx context.Context) error) *Periodic {
+	return &Periodic{
+		name:     name,
+		interval: interval,
+		fn:       fn,
+	}
+}
+
+// Start runs the job immediately and then on every tick until Stop is called
+func (p *Periodic) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the job and waits for a run in progress to finish, or for ctx to expire
+func (p *Periodic) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run executes one iteration and logs its failure; a failing run never stops the job
+func (p *Periodic) run(ctx context.Context) {
+	if err := p.fn(ctx); err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "job failed", "job", p.name, "error", err)
+	}
+}
@@ -0,0 +1,70 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestPublicJWKS(t *testing.T) {
+	dir := t.TempDir()
+	writePrivate(t, dir, "2025-02", testKeys.ed)
+	writePublic(t, dir, "2025-01", testKeys.rsa)
+	k, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := k.PublicJWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("keys = %+v, want the RSA and Ed25519 keys", set.Keys)
+	}
+
+	// Sorted by kid, so the retired RSA key comes first
+	rsaKey, edKey := set.Keys[0], set.Keys[1]
+	if rsaKey.Kid != "2025-01" || rsaKey.Kty != "RSA" || rsaKey.Alg != "RS256" || rsaKey.Use != "sig" || rsaKey.Crv != "" || rsaKey.X != "" {
+		t.Errorf("RSA key = %+v", rsaKey)
+	}
+	if n := decodeBase64URL(t, rsaKey.N); new(big.Int).SetBytes(n).Cmp(testKeys.rsa.N) != 0 {
+		t.Error("RSA modulus does not match the key")
+	}
+	if e := decodeBase64URL(t, rsaKey.E); new(big.Int).SetBytes(e).Int64() != int64(testKeys.rsa.E) {
+		t.Errorf("RSA exponent = %s", rsaKey.E)
+	}
+
+	if edKey.Kid != "2025-02" || edKey.Kty != "OKP" || edKey.Crv != "Ed25519" || edKey.Alg != "EdDSA" || edKey.Use != "sig" || edKey.N != "" || edKey.E != "" {
+		t.Errorf("Ed25519 key = %+v", edKey)
+	}
+	if x := decodeBase64URL(t, edKey.X); string(x) != string(testKeys.ed.Public().(ed25519.PublicKey)) {
+		t.Error("Ed25519 public key does not match the key")
+	}
+}
+
+func TestPublicJWKSSkipsHMAC(t *testing.T) {
+	k, err := NewHMAC("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An empty set still serialises as a list for JWKS consumers
+	data, err := json.Marshal(k.PublicJWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"keys":[]}` {
+		t.Errorf("JWKS = %s, want no keys", data)
+	}
+}
+
+// decodeBase64URL decodes an unpadded base64url JWK member
+func decodeBase64URL(t *testing.T, s string) []byte {
+	t.Helper()
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode %q: %v", s, err)
+	}
+	return data
+}
@@ -0,0 +1,210 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key file naming inside the keys directory:
+//
+//	<kid>.pem      private key (PKCS#8 RSA/Ed25519 or PKCS#1 RSA); signs and verifies
+//	<kid>.pub.pem  public key only (PKIX); verifies tokens from a key being retired
+//
+// When both files exist for a kid the private key wins. A key is retired by
+// deleting its files once every token it signed has expired.
+const (
+	privateKeySuffix = ".pem"
+	publicKeySuffix  = ".pub.pem"
+)
+
+// Key is a single entry of the keyring
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	signingKey interface{} // nil for verify-only keys
+	verifyKey  interface{}
+}
+
+// Keyring holds the active signing key and every key still accepted for verification
+type Keyring struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// Load reads all keys in dir and selects activeID as the signing key.
+// activeID may be empty when the directory holds exactly one private key.
+func Load(dir, activeID string) (*Keyring, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read keys directory: %w", err)
+	}
+
+	k := &Keyring{keys: make(map[string]*Key)}
+	var privateIDs []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, privateKeySuffix) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("read key %s: %w", name, err)
+		}
+
+		var key *Key
+		if strings.HasSuffix(name, publicKeySuffix) {
+			key, err = parsePublicKey(strings.TrimSuffix(name, publicKeySuffix), data)
+		} else {
+			key, err = parsePrivateKey(strings.TrimSuffix(name, privateKeySuffix), data)
+			if err == nil {
+				privateIDs = append(privateIDs, key.ID)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse key %s: %w", name, err)
+		}
+
+		// A public key file next to its private key adds nothing
+		if existing, exists := k.keys[key.ID]; exists && existing.signingKey != nil {
+			continue
+		}
+		k.keys[key.ID] = key
+	}
+
+	if activeID == "" {
+		if len(privateIDs) != 1 {
+			return nil, errors.New("active key id must be set when the keyring has several private keys")
+		}
+		activeID = privateIDs[0]
+	}
+
+	active, ok := k.keys[activeID]
+	if !ok || active.signingKey == nil {
+		return nil, fmt.Errorf("active key %q has no private key in %s", activeID, dir)
+	}
+	k.active = active
+
+	return k, nil
+}
+
+// NewHMAC creates a single-key keyring that signs with HS256. It exists for
+// local development only: the secret is never published in the JWKS, so other
+// services cannot verify tokens signed this way.
+func NewHMAC(secret string) (*Keyring, error) {
+	if secret == "" {
+		return nil, errors.New("HMAC secret is empty")
+	}
+
+	key := &Key{
+		ID:         "hs256",
+		Method:     jwt.SigningMethodHS256,
+		signingKey: []byte(secret),
+		verifyKey:  []byte(secret),
+	}
+	return &Keyring{active: key, keys: map[string]*Key{key.ID: key}}, nil
+}
+
+// ActiveKeyID returns the kid new tokens are signed with
+func (k *Keyring) ActiveKeyID() string {
+	return k.active.ID
+}
+
+// Sign signs claims with the active key and sets the kid header
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.active.Method, claims)
+	token.Header["kid"] = k.active.ID
+	return token.SignedString(k.active.signingKey)
+}
+
+// Keyfunc resolves the verification key from the token's kid header.
+// It is meant to be passed to jwt.Parse together with ValidMethods.
+func (k *Keyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	// Never let the token choose a different algorithm than the key was made for
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("algorithm %s does not match key %q", token.Method.Alg(), kid)
+	}
+
+	return key.verifyKey, nil
+}
+
+// ValidMethods lists the algorithms of every key in the keyring
+func (k *Keyring) ValidMethods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range k.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// parsePrivateKey parses a PEM encoded RSA or Ed25519 private key
+func parsePrivateKey(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		// Fall back to the traditional "RSA PRIVATE KEY" encoding
+		rsaKey, rsaErr := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if rsaErr != nil {
+			return nil, err
+		}
+		parsed = rsaKey
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, signingKey: key, verifyKey: &key.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, signingKey: key, verifyKey: key.Public()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+}
+
+// parsePublicKey parses a PEM encoded RSA or Ed25519 public key
+func parsePublicKey(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PublicKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, verifyKey: key}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, verifyKey: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", parsed)
+	}
+}
@@ -0,0 +1,298 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKeys are generated once; RSA key generation is slow
+var testKeys = struct {
+	ed  ed25519.PrivateKey
+	rsa *rsa.PrivateKey
+}{}
+
+func init() {
+	var err error
+	if _, testKeys.ed, err = ed25519.GenerateKey(rand.Reader); err != nil {
+		panic(err)
+	}
+	if testKeys.rsa, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		panic(err)
+	}
+}
+
+// writePrivate writes <kid>.pem as PKCS#8
+func writePrivate(t *testing.T, dir, kid string, key crypto.Signer) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, kid+privateKeySuffix), "PRIVATE KEY", der)
+}
+
+// writePublic writes <kid>.pub.pem as PKIX
+func writePublic(t *testing.T, dir, kid string, key crypto.Signer) {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, kid+publicKeySuffix), "PUBLIC KEY", der)
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// signWith signs claims with an arbitrary method, key and kid header
+func signWith(t *testing.T, method jwt.SigningMethod, key interface{}, kid string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{
+		Subject:   "user",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// verify parses a token the way the token service does
+func verify(k *Keyring, token string) error {
+	_, err := jwt.Parse(token, k.Keyfunc, jwt.WithValidMethods(k.ValidMethods()))
+	return err
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		prepare    func(t *testing.T, dir string)
+		activeID   string
+		wantActive string
+		wantErr    string
+	}{
+		{
+			name:       "single private key is active",
+			prepare:    func(t *testing.T, dir string) { writePrivate(t, dir, "2025-01", testKeys.ed) },
+			wantActive: "2025-01",
+		},
+		{
+			name: "active key chosen by id",
+			prepare: func(t *testing.T, dir string) {
+				writePrivate(t, dir, "2025-01", testKeys.ed)
+				writePrivate(t, dir, "2025-02", testKeys.rsa)
+			},
+			activeID:   "2025-02",
+			wantActive: "2025-02",
+		},
+		{
+			name: "several private keys need an active id",
+			prepare: func(t *testing.T, dir string) {
+				writePrivate(t, dir, "2025-01", testKeys.ed)
+				writePrivate(t, dir, "2025-02", testKeys.rsa)
+			},
+			wantErr: "active key id must be set",
+		},
+		{
+			name: "retired key cannot sign",
+			prepare: func(t *testing.T, dir string) {
+				writePrivate(t, dir, "2025-02", testKeys.ed)
+				writePublic(t, dir, "2025-01", testKeys.rsa)
+			},
+			activeID: "2025-01",
+			wantErr:  "has no private key",
+		},
+		{
+			name:     "unknown active id",
+			prepare:  func(t *testing.T, dir string) { writePrivate(t, dir, "2025-01", testKeys.ed) },
+			activeID: "2024-12",
+			wantErr:  "has no private key",
+		},
+		{
+			name: "traditional RSA encoding",
+			prepare: func(t *testing.T, dir string) {
+				writePEM(t, filepath.Join(dir, "legacy.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(testKeys.rsa))
+			},
+			wantActive: "legacy",
+		},
+		{
+			name: "other files ignored",
+			prepare: func(t *testing.T, dir string) {
+				writePrivate(t, dir, "2025-01", testKeys.ed)
+				if err := os.WriteFile(filepath.Join(dir, "README"), []byte("keys"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantActive: "2025-01",
+		},
+		{
+			name: "not PEM",
+			prepare: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: "no PEM block found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.prepare(t, dir)
+
+			k, err := Load(dir, tt.activeID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := k.ActiveKeyID(); got != tt.wantActive {
+				t.Errorf("active key = %q, want %q", got, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestLoadMissingDirectory(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Fatal("Load accepted a missing directory")
+	}
+}
+
+func TestSignSetsActiveKid(t *testing.T) {
+	dir := t.TempDir()
+	writePrivate(t, dir, "ed", testKeys.ed)
+	writePrivate(t, dir, "rsa", testKeys.rsa)
+
+	for kid, method := range map[string]jwt.SigningMethod{"ed": jwt.SigningMethodEdDSA, "rsa": jwt.SigningMethodRS256} {
+		t.Run(kid, func(t *testing.T) {
+			k, err := Load(dir, kid)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signed, err := k.Sign(jwt.RegisteredClaims{Subject: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := jwt.Parse(signed, k.Keyfunc, jwt.WithValidMethods(k.ValidMethods()))
+			if err != nil {
+				t.Fatalf("token signed by the keyring does not verify: %v", err)
+			}
+			if token.Header["kid"] != kid || token.Method.Alg() != method.Alg() {
+				t.Errorf("header = %v, want kid %q and alg %s", token.Header, kid, method.Alg())
+			}
+		})
+	}
+}
+
+func TestKeyfunc(t *testing.T) {
+	// "new" signs; "old" was rotated out and only verifies
+	dir := t.TempDir()
+	writePrivate(t, dir, "new", testKeys.ed)
+	writePublic(t, dir, "new", testKeys.ed)
+	writePublic(t, dir, "old", testKeys.rsa)
+	k, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherEd, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "active key", token: signWith(t, jwt.SigningMethodEdDSA, testKeys.ed, "new")},
+		{name: "retired key still verifies", token: signWith(t, jwt.SigningMethodRS256, testKeys.rsa, "old")},
+		{name: "unknown kid", token: signWith(t, jwt.SigningMethodEdDSA, testKeys.ed, "2019-01"), wantErr: true},
+		{name: "no kid", token: signWith(t, jwt.SigningMethodEdDSA, testKeys.ed, ""), wantErr: true},
+		{name: "EdDSA token for the RS256 key", token: signWith(t, jwt.SigningMethodEdDSA, testKeys.ed, "old"), wantErr: true},
+		{name: "RS256 token for the EdDSA key", token: signWith(t, jwt.SigningMethodRS256, testKeys.rsa, "new"), wantErr: true},
+		{name: "signed by another key", token: signWith(t, jwt.SigningMethodEdDSA, otherEd, "new"), wantErr: true},
+		{name: "HMAC token", token: signWith(t, jwt.SigningMethodHS256, []byte("secret"), "new"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verify(k, tt.token); (err != nil) != tt.wantErr {
+				t.Errorf("verify error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetiredKeyCannotSign(t *testing.T) {
+	dir := t.TempDir()
+	writePrivate(t, dir, "new", testKeys.ed)
+	writePublic(t, dir, "old", testKeys.rsa)
+	k, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := k.ActiveKeyID(); got != "new" {
+		t.Errorf("active key = %q, want the only private key", got)
+	}
+	if got := k.ValidMethods(); strings.Join(got, ",") != "EdDSA,RS256" {
+		t.Errorf("valid methods = %v, want EdDSA and RS256", got)
+	}
+}
+
+func TestNewHMAC(t *testing.T) {
+	if _, err := NewHMAC(""); err == nil {
+		t.Error("NewHMAC accepted an empty secret")
+	}
+
+	k, err := NewHMAC("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := k.Sign(jwt.RegisteredClaims{Subject: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(k, signed); err != nil {
+		t.Errorf("HMAC token does not verify: %v", err)
+	}
+
+	other, err := NewHMAC("another secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(other, signed); err == nil {
+		t.Error("token verified with another secret")
+	}
+}
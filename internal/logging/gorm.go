@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// redacted replaces query parameters that may hold personal data or secrets
+const redacted = "[redacted]"
+
+// GormLogger adapts slog to GORM. Failed queries are logged as errors and
+// queries slower than SlowThreshold as warnings; with the logger at debug
+// level every query is logged. Parameters are redacted except numbers,
+// booleans and times, so emails, names and password hashes never reach
+// the logs.
+type GormLogger struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+	level         gormlogger.LogLevel
+}
+
+// NewGormLogger creates the adapter. A zero slowThreshold disables slow
+// query warnings.
+func NewGormLogger(logger *slog.Logger, slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{logger: logger, slowThreshold: slowThreshold, level: gormlogger.Info}
+}
+
+// LogMode implements gormlogger.Interface
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+// Info implements gormlogger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Warn implements gormlogger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Error implements gormlogger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace implements gormlogger.Interface
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+
+	var level slog.Level
+	var msg string
+	switch {
+	// Not finding a row is an expected outcome, not a failed query
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		level, msg = slog.LevelError, "query failed"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		level, msg = slog.LevelWarn, "slow query"
+	case l.level >= gormlogger.Info:
+		level, msg = slog.LevelDebug, "query"
+	default:
+		return
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Duration("duration", elapsed),
+		slog.Int64("rows", rows),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter implements gorm.ParamsFilter; GORM calls it before
+// interpolating parameters into the SQL passed to Trace
+func (l *GormLogger) ParamsFilter(_ context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	filtered := make([]interface{}, len(params))
+	for i, param := range params {
+		filtered[i] = redactParam(param)
+	}
+	return sql, filtered
+}
+
+// redactParam keeps values that cannot identify a person and hides the rest
+func redactParam(param interface{}) interface{} {
+	switch param.(type) {
+	case nil, bool, time.Time, *time.Time,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return param
+	default:
+		return redacted
+	}
+}
@@ -0,0 +1,147 @@
+package logging
+
+import (
+	"context"
+	"go-booking-system/internal/domain"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// recordHandler keeps every record it handles so tests can inspect them
+type recordHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordHandler) Handle(_ context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record.Clone())
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordHandler) WithGroup(string) slog.Handler { return h }
+
+// attrs flattens the attributes of the only record handled so far
+func (h *recordHandler) attrs(t *testing.T) map[string]string {
+	t.Helper()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.records) != 1 {
+		t.Fatalf("got %d log records, want 1", len(h.records))
+	}
+	attrs := map[string]string{}
+	h.records[0].Attrs(func(attr slog.Attr) bool {
+		attrs[attr.Key] = attr.Value.String()
+		return true
+	})
+	return attrs
+}
+
+// newLoggedDB returns a dry-run database, which builds SQL without a server,
+// logging every query through a GormLogger into handler
+func newLoggedDB(t *testing.T, handler *recordHandler) *gorm.DB {
+	t.Helper()
+
+	logger := slog.New(contextHandler{handler})
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 NewGormLogger(logger, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestGormLoggerRedactsParams(t *testing.T) {
+	createdBefore := time.Date(2025, 1, 20, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		run        func(db *gorm.DB) error
+		wantKept   []string
+		wantHidden []string
+	}{
+		{
+			name: "strings",
+			run: func(db *gorm.DB) error {
+				return db.Where("email = ? AND name = ?", "guest@example.com", "Ann Guest").Find(&[]domain.User{}).Error
+			},
+			wantKept:   []string{"email = '[redacted]' AND name = '[redacted]'"},
+			wantHidden: []string{"guest@example.com", "Ann Guest"},
+		},
+		{
+			name: "bytes",
+			run: func(db *gorm.DB) error {
+				return db.Where("password = ?", []byte("$2a$10$secrethash")).Find(&[]domain.User{}).Error
+			},
+			wantKept:   []string{"password = '[redacted]'"},
+			wantHidden: []string{"secrethash"},
+		},
+		{
+			name: "numbers, booleans and times",
+			run: func(db *gorm.DB) error {
+				return db.Where("id = ? AND rate > ? AND is_two_factor_enabled = ? AND created_at < ?", 42, 1.5, true, createdBefore).
+					Find(&[]domain.User{}).Error
+			},
+			wantKept: []string{"id = 42", "rate > 1.5", "is_two_factor_enabled = true", "created_at < '2025-01-20 14:30:00'"},
+		},
+		{
+			name: "time pointers and nil",
+			run: func(db *gorm.DB) error {
+				return db.Where("deleted_at < ? AND phone = ?", &createdBefore, nil).Find(&[]domain.User{}).Error
+			},
+			wantKept: []string{"deleted_at < '2025-01-20 14:30:00'", "phone = NULL"},
+		},
+		{
+			name: "inserted values",
+			run: func(db *gorm.DB) error {
+				return db.Create(&domain.AuditEvent{ActorUUID: "actor-uuid", Detail: "name changed to Ann", CreatedAt: createdBefore}).Error
+			},
+			wantKept:   []string{`INSERT INTO "audit_events"`, "'2025-01-20 14:30:00'"},
+			wantHidden: []string{"actor-uuid", "Ann"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &recordHandler{}
+			db := newLoggedDB(t, handler)
+
+			ctx := WithRequestID(context.Background(), "req-123")
+			if err := tt.run(db.WithContext(ctx)); err != nil {
+				t.Fatal(err)
+			}
+
+			attrs := handler.attrs(t)
+			sql := attrs["sql"]
+			for _, want := range tt.wantKept {
+				if !strings.Contains(sql, want) {
+					t.Errorf("sql = %q, want it to contain %q", sql, want)
+				}
+			}
+			for _, hidden := range tt.wantHidden {
+				if strings.Contains(sql, hidden) {
+					t.Errorf("sql = %q leaks %q", sql, hidden)
+				}
+			}
+			if attrs["request_id"] != "req-123" {
+				t.Errorf("request_id = %q, want req-123", attrs["request_id"])
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Package logging configures structured logging with log/slog
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Format selects how log records are encoded
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatText Format = "text"
+)
+
+// Options configures the default logger
+type Options struct {
+	Level  slog.Level
+	Format Format
+}
+
+// DefaultOptions returns the level and format for an environment: debug text
+// logs for development, info JSON logs for everything else
+func DefaultOptions(env string) Options {
+	if env == "" || env == "development" {
+		return Options{Level: slog.LevelDebug, Format: FormatText}
+	}
+	return Options{Level: slog.LevelInfo, Format: FormatJSON}
+}
+
+// ParseLevel reads a level name: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+// ParseFormat reads a format name: json or text
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case FormatJSON, FormatText:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown log format %q", name)
+	}
+}
+
+// Setup makes a logger writing to w the slog default. The standard log
+// package is routed through it too, so its output stays structured.
+func Setup(w io.Writer, opts Options) *slog.Logger {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+
+	var handler slog.Handler
+	if opts.Format == FormatText {
+		handler = slog.NewTextHandler(w, handlerOpts)
+	} else {
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	}
+
+	logger := slog.New(contextHandler{handler})
+	slog.SetDefault(logger)
+	return logger
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log records carry requestID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler adds the request and trace IDs found in the context to each
+// record, so anything logged with slog.*Context can be tied to its request
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implements slog.Handler
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
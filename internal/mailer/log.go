@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer writes messages to a file, or to the standard logger when no path
+// is given, instead of sending them. Use it for local development.
+type LogMailer struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewLogMailer creates a mailer that appends messages to path
+func NewLogMailer(path string) *LogMailer {
+	return &LogMailer{path: path}
+}
+
+// Send records the message
+func (m *LogMailer) Send(msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	entry := fmt.Sprintf("--- %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+
+	if m.path == "" {
+		log.Print("Mail not sent (log mailer):\n" + entry)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(entry)
+	return err
+}
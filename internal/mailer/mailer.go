@@ -0,0 +1,29 @@
+package mailer
+
+import (
+	"errors"
+	"strings"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// validate rejects messages that could inject extra headers
+func (m Message) validate() error {
+	if m.To == "" {
+		return errors.New("mailer: recipient is empty")
+	}
+	if strings.ContainsAny(m.To, "\r\n") || strings.ContainsAny(m.Subject, "\r\n") {
+		return errors.New("mailer: header contains line break")
+	}
+	return nil
+}
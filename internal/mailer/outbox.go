@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"sync"
+)
+
+// Outbox keeps sent messages in memory instead of delivering them.
+// Swap it in for tests that need to read the token out of an email.
+type Outbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewOutbox creates an empty outbox
+func NewOutbox() *Outbox {
+	return &Outbox{}
+}
+
+// Send stores the message
+func (o *Outbox) Send(msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of every message sent so far
+func (o *Outbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Message(nil), o.messages...)
+}
+
+// Last returns the most recent message sent to an address
+func (o *Outbox) Last(to string) (Message, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if o.messages[i].To == to {
+			return o.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// Reset discards every stored message
+func (o *Outbox) Reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = nil
+}
@@ -1,25 +1,30 @@
 package middleware
 
 import (
-	"go-booking-system/internal/dto"
-	"net/http"
-	"os"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/service"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+)
+
+// Errors the authorization middleware reports through ErrorHandler
+var (
+	errAuthorizationRequired = apperror.Unauthorized("authorization_required", "authorization header required")
+	errAuthorizationFormat   = apperror.Unauthorized("invalid_authorization_format", "invalid authorization format, use: Bearer <token>")
+	errEmailNotVerified      = apperror.Forbidden("email_verification_required", "email address not verified")
+	errInsufficientRole      = apperror.Forbidden("insufficient_role", "insufficient role")
 )
 
 // RequireAuth validates JWT tokens and protects routes
-func RequireAuth() gin.HandlerFunc {
+func RequireAuth(tokenService service.TokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Step 1: Get Authorization header
 		// Expected format: "Authorization: Bearer <token>"
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Error: "Authorization header required",
-			})
+			_ = c.Error(errAuthorizationRequired)
 			c.Abort() // Stop processing, don't call next handler
 			return
 		}
@@ -28,39 +33,102 @@ func RequireAuth() gin.HandlerFunc {
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
 			// TrimPrefix didn't change anything = "Bearer " wasn't there
-			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Error: "Invalid authorization format. Use: Bearer <token>",
-			})
+			_ = c.Error(errAuthorizationFormat)
 			c.Abort()
 			return
 		}
 
-		// Step 3: Parse and verify the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		// Step 3: Parse and verify the token, including the revocation list
+		claims, err := tokenService.ParseAccessToken(c.Request.Context(), tokenString)
 
 		// Step 4: Check if token is valid
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Error: "Invalid or expired token",
-			})
+		if err != nil {
+			_ = c.Error(err)
 			c.Abort()
 			return
 		}
 
-		// Step 5: Extract claims (payload data)
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			// Get the user UUID from the token
-			// This matches the "uuid" claim from account_service.go:137
-			if userUUID, exists := claims["uuid"]; exists {
-				// Store UUID in context so handlers can access it
-				// Handlers can get this with: c.Get("userUUID")
-				c.Set("userUUID", userUUID)
+		// Step 5: Store claims in context so handlers can access them
+		// Handlers can get these with: c.Get("userUUID") / c.Get("tokenClaims")
+		c.Set("userUUID", claims.UUID)
+		c.Set("tokenClaims", claims)
+
+		// Step 6: Token is valid, proceed to the actual handler
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail rejects users who have not confirmed their email address.
+// It must run after RequireAuth, which stores the token claims in context.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := claimsFromContext(c)
+		if !ok {
+			return
+		}
+
+		// The claim is refreshed whenever a new access token is minted
+		if !claims.EmailVerified {
+			_ = c.Error(errEmailNotVerified)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole lets the request through if the user holds any of the roles.
+// It must run after RequireAuth, which stores the token claims in context.
+func RequireRole(roles ...domain.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := claimsFromContext(c)
+		if !ok {
+			return
+		}
+
+		for _, role := range roles {
+			if claims.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+
+		_ = c.Error(errInsufficientRole)
+		c.Abort()
+	}
+}
+
+// RequirePermission lets the request through only if the user holds every
+// one of the permissions. It must run after RequireAuth.
+func RequirePermission(permissions ...domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := claimsFromContext(c)
+		if !ok {
+			return
+		}
+
+		for _, permission := range permissions {
+			if !claims.HasPermission(permission) {
+				_ = c.Error(apperror.Forbidden("missing_permission", "missing permission: "+string(permission)))
+				c.Abort()
+				return
 			}
 		}
 
-		// Step 6: Token is valid, proceed to the actual handler
 		c.Next()
 	}
 }
+
+// claimsFromContext returns the claims RequireAuth stored in context.
+// It aborts with 401 when they are missing.
+func claimsFromContext(c *gin.Context) (*service.AccessClaims, bool) {
+	value, _ := c.Get("tokenClaims")
+	claims, ok := value.(*service.AccessClaims)
+	if !ok {
+		_ = c.Error(errAuthorizationRequired)
+		c.Abort()
+		return nil, false
+	}
+	return claims, true
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/dto"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// registerFieldNames makes validator report fields by their JSON names
+var registerFieldNames sync.Once
+
+// ErrorHandler turns the last error a handler or middleware attached with
+// c.Error into a JSON error response. Errors marked gin.ErrorTypeBind come
+// from request binding and are reported per field; everything else is
+// mapped through apperror.
+func ErrorHandler() gin.HandlerFunc {
+	registerFieldNames.Do(func() {
+		if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			engine.RegisterTagNameFunc(fieldName)
+		}
+	})
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+
+		var appErr *apperror.Error
+		if last.IsType(gin.ErrorTypeBind) {
+			appErr = bindingError(last.Err)
+		} else {
+			appErr = apperror.From(last.Err)
+		}
+
+		// A failure caused by the request context ending is not a server bug
+		if appErr.Kind == apperror.KindInternal {
+			appErr = contextError(c.Request.Context(), last.Err, appErr)
+		}
+
+		// The cause of an internal error stays in the logs
+		if appErr.Kind == apperror.KindInternal {
+			slog.ErrorContext(c.Request.Context(), "request failed",
+				"method", c.Request.Method, "route", c.FullPath(), "error", last.Err)
+		}
+
+		if appErr.RetryAfter > 0 {
+			// Round up so clients never retry a moment too early
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+		}
+
+		c.JSON(statusFor(appErr.Kind), dto.ErrorResponse{
+			Code:      appErr.Code,
+			Message:   appErr.Message,
+			Fields:    appErr.Fields,
+			RequestID: RequestIDFromContext(c),
+		})
+	}
+}
+
+// statusClientClosedRequest is the non-standard status (from nginx) logged
+// for requests the client abandoned
+const statusClientClosedRequest = 499
+
+var (
+	errRequestCanceled = apperror.New(apperror.KindCanceled, "request_canceled", "request canceled by the client")
+	errRequestTimeout  = apperror.New(apperror.KindUnavailable, "request_timeout", "request timed out")
+)
+
+// contextError reports a client disconnect or an expired deadline in place
+// of the internal error it surfaced as, and returns fallback otherwise
+func contextError(ctx context.Context, err error, fallback *apperror.Error) *apperror.Error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return errRequestCanceled.Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return errRequestTimeout.Wrap(err)
+	}
+
+	// Drivers do not always wrap the context error, so also check the context
+	switch ctx.Err() {
+	case context.Canceled:
+		return errRequestCanceled.Wrap(err)
+	case context.DeadlineExceeded:
+		return errRequestTimeout.Wrap(err)
+	}
+	return fallback
+}
+
+// statusFor maps an error kind to its HTTP status code
+func statusFor(kind apperror.Kind) int {
+	switch kind {
+	case apperror.KindInvalid:
+		return http.StatusBadRequest
+	case apperror.KindUnauthorized:
+		return http.StatusUnauthorized
+	case apperror.KindForbidden:
+		return http.StatusForbidden
+	case apperror.KindNotFound:
+		return http.StatusNotFound
+	case apperror.KindConflict:
+		return http.StatusConflict
+	case apperror.KindLocked:
+		return http.StatusLocked
+	case apperror.KindTooManyRequests:
+		return http.StatusTooManyRequests
+	case apperror.KindCanceled:
+		return statusClientClosedRequest
+	case apperror.KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// bindingError describes a ShouldBind failure with one message per field
+func bindingError(err error) *apperror.Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make(map[string]string, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields[fieldPath(fe)] = fieldMessage(fe)
+		}
+		return apperror.Validation(fields)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return apperror.Validation(map[string]string{typeErr.Field: "must be a " + jsonTypeName(typeErr.Type)})
+	}
+
+	if errors.Is(err, io.EOF) {
+		return apperror.Invalid(apperror.CodeMalformedRequest, "request body is required")
+	}
+	return apperror.Invalid(apperror.CodeMalformedRequest, "malformed request body")
+}
+
+// fieldPath drops the top-level struct name from the validator namespace
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+// fieldMessage phrases a failed validation rule for API clients
+func fieldMessage(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "numeric":
+		return "must contain only digits"
+	case "alpha":
+		return "must contain only letters"
+	case "iso4217":
+		return "must be an ISO 4217 currency code, e.g. USD"
+	case "timezone":
+		return "must be an IANA time zone, e.g. Europe/Prague"
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "len":
+		if isString {
+			return "must be exactly " + fe.Param() + " characters long"
+		}
+		return "must contain exactly " + fe.Param() + " items"
+	case "min":
+		if isString {
+			return "must be at least " + fe.Param() + " characters long"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if isString {
+			return "must be at most " + fe.Param() + " characters long"
+		}
+		return "must be at most " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " check"
+	}
+}
+
+// fieldName returns the JSON (or form) name of a struct field
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			break
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// jsonTypeName names a Go type the way a JSON client would
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
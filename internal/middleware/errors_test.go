@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/dto"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveError runs a request through RequestID and ErrorHandler to a handler
+// that fails with err, and returns the recorded response
+func serveError(t *testing.T, err error) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RequestID(), ErrorHandler())
+	router.GET("/fail", func(c *gin.Context) { _ = c.Error(err) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decodeError parses an error response body
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) dto.ErrorResponse {
+	t.Helper()
+
+	var body dto.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response %q: %v", w.Body.String(), err)
+	}
+	return body
+}
+
+func TestErrorHandlerMapsKinds(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{"invalid", apperror.Invalid("bad_input", "bad input"), http.StatusBadRequest, "bad_input", "bad input"},
+		{"unauthorized", apperror.Unauthorized("invalid_token", "invalid token"), http.StatusUnauthorized, "invalid_token", "invalid token"},
+		{"forbidden", apperror.Forbidden("forbidden", "not allowed"), http.StatusForbidden, "forbidden", "not allowed"},
+		{"not found", apperror.NotFound("user_not_found", "user not found"), http.StatusNotFound, "user_not_found", "user not found"},
+		{"conflict", apperror.Conflict("email_taken", "email already registered"), http.StatusConflict, "email_taken", "email already registered"},
+		{"locked", apperror.New(apperror.KindLocked, "account_locked", "account temporarily locked"), http.StatusLocked, "account_locked", "account temporarily locked"},
+		{"too many requests", apperror.TooManyRequests("slow_down", "slow down"), http.StatusTooManyRequests, "slow_down", "slow down"},
+		{"wrapped", fmt.Errorf("saving user: %w", apperror.Conflict("email_taken", "email already registered")), http.StatusConflict, "email_taken", "email already registered"},
+		{"internal", apperror.Internal("failed to save user", errors.New("connection refused")), http.StatusInternalServerError, apperror.CodeInternal, "failed to save user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveError(t, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			body := decodeError(t, w)
+			if body.Code != tt.wantCode || body.Message != tt.wantMessage {
+				t.Errorf("body = %+v, want code %q and message %q", body, tt.wantCode, tt.wantMessage)
+			}
+			if body.RequestID != "req-123" {
+				t.Errorf("request_id = %q, want req-123", body.RequestID)
+			}
+			if got := w.Header().Get("Retry-After"); got != "" {
+				t.Errorf("Retry-After = %q, want none", got)
+			}
+		})
+	}
+}
+
+func TestErrorHandlerRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       apperror.Kind
+		retryAfter time.Duration
+		wantStatus int
+		wantHeader string
+	}{
+		{"locked, whole seconds", apperror.KindLocked, 2 * time.Minute, http.StatusLocked, "120"},
+		{"locked, rounded up", apperror.KindLocked, 1500 * time.Millisecond, http.StatusLocked, "2"},
+		{"too many requests", apperror.KindTooManyRequests, 30 * time.Second, http.StatusTooManyRequests, "30"},
+		{"too many requests, under a second", apperror.KindTooManyRequests, time.Millisecond, http.StatusTooManyRequests, "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &apperror.Error{Kind: tt.kind, Code: "slow_down", Message: "slow down", RetryAfter: tt.retryAfter}
+			w := serveError(t, err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantHeader {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestErrorHandlerHidesUnknownErrors(t *testing.T) {
+	w := serveError(t, errors.New("pq: password authentication failed for user booking"))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	body := decodeError(t, w)
+	if body.Code != apperror.CodeInternal || body.Message != "internal server error" {
+		t.Errorf("body = %+v, want a generic internal error", body)
+	}
+	if strings.Contains(w.Body.String(), "password") {
+		t.Errorf("response leaks the cause: %s", w.Body.String())
+	}
+}
+
+// bindingInput exercises the validation rules fieldMessage phrases
+type bindingInput struct {
+	Email   string   `json:"email" binding:"required,email"`
+	Name    string   `json:"name" binding:"min=2,max=5"`
+	Digits  string   `json:"digits" binding:"omitempty,numeric,len=4"`
+	Role    string   `json:"role" binding:"omitempty,oneof=guest host"`
+	Fee     int      `json:"fee" binding:"max=100"`
+	Tags    []string `json:"tags" binding:"omitempty,len=2"`
+	Address struct {
+		City string `json:"city" binding:"required"`
+	} `json:"address"`
+}
+
+func TestErrorHandlerBindingErrors(t *testing.T) {
+	const valid = `"email":"guest@example.com","name":"Ann","address":{"city":"Oslo"}`
+
+	tests := []struct {
+		name        string
+		body        string
+		wantCode    string
+		wantMessage string
+		wantFields  map[string]string
+	}{
+		{
+			name:        "missing and malformed fields",
+			body:        `{"email":"nobody","name":"A","address":{}}`,
+			wantCode:    apperror.CodeValidationFailed,
+			wantMessage: "request validation failed",
+			wantFields: map[string]string{
+				"email":        "must be a valid email address",
+				"name":         "must be at least 2 characters long",
+				"address.city": "is required",
+			},
+		},
+		{
+			name:        "rule parameters",
+			body:        `{` + valid + `,"digits":"12a4","role":"admin","fee":101,"tags":["a"]}`,
+			wantCode:    apperror.CodeValidationFailed,
+			wantMessage: "request validation failed",
+			wantFields: map[string]string{
+				"digits": "must contain only digits",
+				"role":   "must be one of: guest, host",
+				"fee":    "must be at most 100",
+				"tags":   "must contain exactly 2 items",
+			},
+		},
+		{
+			name:        "wrong JSON type",
+			body:        `{` + valid + `,"fee":"ten"}`,
+			wantCode:    apperror.CodeValidationFailed,
+			wantMessage: "request validation failed",
+			wantFields:  map[string]string{"fee": "must be a number"},
+		},
+		{
+			name:        "empty body",
+			body:        ``,
+			wantCode:    apperror.CodeMalformedRequest,
+			wantMessage: "request body is required",
+		},
+		{
+			name:        "broken JSON",
+			body:        `{"email":`,
+			wantCode:    apperror.CodeMalformedRequest,
+			wantMessage: "malformed request body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.POST("/bind", func(c *gin.Context) {
+				var input bindingInput
+				if err := c.ShouldBindJSON(&input); err != nil {
+					_ = c.Error(err).SetType(gin.ErrorTypeBind)
+					return
+				}
+				c.Status(http.StatusNoContent)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			body := decodeError(t, w)
+			if body.Code != tt.wantCode || body.Message != tt.wantMessage {
+				t.Errorf("body = %+v, want code %q and message %q", body, tt.wantCode, tt.wantMessage)
+			}
+			if !reflect.DeepEqual(body.Fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", body.Fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"go-booking-system/internal/telemetry"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that matched no route, so scanners probing
+// random paths cannot create a metric series per path
+const unmatchedRoute = "unmatched"
+
+// Metrics records the latency of every request by route template and status
+func Metrics(metrics *telemetry.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"go-booking-system/internal/telemetry"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const requestDurationMetric = "booking_http_request_duration_seconds"
+
+// requestCount returns how many requests the duration histogram holds for
+// a method, route and status
+func requestCount(t *testing.T, metrics *telemetry.Metrics, method, route, status string) uint64 {
+	t.Helper()
+
+	families, err := metrics.Gatherer().Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != requestDurationMetric {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["method"] == method && labels["route"] == route && labels["status"] == status {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestRequestsLabelledByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	metrics := telemetry.NewMetrics()
+
+	// Wired up as in main
+	router := gin.New()
+	router.Use(otelgin.Middleware("test", otelgin.WithTracerProvider(provider)), Metrics(metrics))
+	router.GET("/api/admin/users/:uuid/roles", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.DELETE("/api/admin/users/:uuid/roles/:role", func(c *gin.Context) { c.Status(http.StatusConflict) })
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/api/admin/users/1b4e28ba/roles"},
+		{http.MethodGet, "/api/admin/users/6fa459ea/roles"},
+		{http.MethodDelete, "/api/admin/users/1b4e28ba/roles/admin"},
+		{http.MethodGet, "/wp-login.php"},
+		{http.MethodGet, "/.env"},
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	tests := []struct {
+		method, route, status string
+		want                  uint64
+	}{
+		{"GET", "/api/admin/users/:uuid/roles", "200", 2},
+		{"DELETE", "/api/admin/users/:uuid/roles/:role", "409", 1},
+		{"GET", unmatchedRoute, "404", 2},
+	}
+	for _, tt := range tests {
+		if got := requestCount(t, metrics, tt.method, tt.route, tt.status); got != tt.want {
+			t.Errorf("%s %s %s observed %d times, want %d", tt.method, tt.route, tt.status, got, tt.want)
+		}
+	}
+	// Probed paths must not add a series each
+	series, err := testutil.GatherAndCount(metrics.Gatherer(), requestDurationMetric)
+	if err != nil || series != len(tests) {
+		t.Errorf("request duration series = %d, %v; want %d", series, err, len(tests))
+	}
+
+	wantSpans := []struct{ name, route string }{
+		{"GET /api/admin/users/:uuid/roles", "/api/admin/users/:uuid/roles"},
+		{"GET /api/admin/users/:uuid/roles", "/api/admin/users/:uuid/roles"},
+		{"DELETE /api/admin/users/:uuid/roles/:role", "/api/admin/users/:uuid/roles/:role"},
+		{"GET", ""},
+		{"GET", ""},
+	}
+	ended := spans.Ended()
+	if len(ended) != len(wantSpans) {
+		t.Fatalf("spans = %d, want %d", len(ended), len(wantSpans))
+	}
+	for i, want := range wantSpans {
+		span := ended[i]
+		route := ""
+		for _, attr := range span.Attributes() {
+			if attr.Key == semconv.HTTPRouteKey {
+				route = attr.Value.AsString()
+			}
+		}
+		if span.Name() != want.name || route != want.route {
+			t.Errorf("span %d = %q with route %q, want %q with route %q", i, span.Name(), route, want.name, want.route)
+		}
+	}
+}
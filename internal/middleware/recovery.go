@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/dto"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery turns a panic in a handler into a 500 response and logs it with
+// its stack trace
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// The server aborts this on purpose, e.g. for a broken connection
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			slog.ErrorContext(c.Request.Context(), "handler panicked",
+				"panic", recovered, "stack", string(debug.Stack()))
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Code:      apperror.CodeInternal,
+				Message:   "internal server error",
+				RequestID: RequestIDFromContext(c),
+			})
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"go-booking-system/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds IDs accepted from clients or proxies
+const maxRequestIDLength = 64
+
+// RequestID tags every request with an ID, reusing the caller's X-Request-ID
+// when it looks sane, and echoes it in the response. The ID is also stored in
+// the request context so log records written further down carry it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("requestID", requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// RequestIDFromContext returns the ID RequestID stored in context, if any
+func RequestIDFromContext(c *gin.Context) string {
+	return c.GetString("requestID")
+}
+
+// isValidRequestID accepts short IDs made of URL-safe characters so a
+// client cannot inject arbitrary text into logs and headers
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout puts a deadline on the request context. Services hand that context
+// down to the database, so a slow query is cancelled instead of outliving the
+// request. A zero duration leaves the request without a deadline.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		parent := c.Request.Context()
+		ctx, cancel := context.WithTimeout(parent, d)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		// Outer middleware such as ErrorHandler runs after cancel, so give it
+		// back the parent context unless the deadline has already passed;
+		// otherwise every failed request would look cancelled by the client
+		if ctx.Err() == nil {
+			c.Request = c.Request.WithContext(parent)
+		}
+	}
+}
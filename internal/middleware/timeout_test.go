@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-booking-system/internal/apperror"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeoutSetsDeadline(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDeadline bool
+	}{
+		{"positive duration", time.Minute, true},
+		{"zero duration", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			var deadline time.Time
+			var hasDeadline bool
+			router := gin.New()
+			router.Use(Timeout(tt.timeout))
+			router.GET("/", func(c *gin.Context) {
+				deadline, hasDeadline = c.Request.Context().Deadline()
+				c.Status(http.StatusNoContent)
+			})
+
+			before := time.Now()
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if hasDeadline != tt.wantDeadline {
+				t.Fatalf("has deadline = %v, want %v", hasDeadline, tt.wantDeadline)
+			}
+			if tt.wantDeadline && (deadline.Before(before.Add(tt.timeout)) || deadline.After(time.Now().Add(tt.timeout))) {
+				t.Errorf("deadline = %v, want %v from the request", deadline, tt.timeout)
+			}
+		})
+	}
+}
+
+func TestErrorHandlerContextErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		// ctx is the request context; nil means a live one
+		ctx        context.Context
+		timeout    time.Duration
+		handler    func(c *gin.Context) error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "cancelled context in the error chain",
+			handler:    func(c *gin.Context) error { return apperror.Internal("failed to find user", context.Canceled) },
+			wantStatus: statusClientClosedRequest,
+			wantCode:   "request_canceled",
+		},
+		{
+			name:       "expired deadline in the error chain",
+			handler:    func(c *gin.Context) error { return fmt.Errorf("query users: %w", context.DeadlineExceeded) },
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "request_timeout",
+		},
+		{
+			name: "client gone, driver error hides the cause",
+			ctx:  canceled,
+			handler: func(c *gin.Context) error {
+				return apperror.Internal("failed to find user", errors.New("driver: bad connection"))
+			},
+			wantStatus: statusClientClosedRequest,
+			wantCode:   "request_canceled",
+		},
+		{
+			name:    "deadline passed, driver error hides the cause",
+			timeout: time.Millisecond,
+			handler: func(c *gin.Context) error {
+				<-c.Request.Context().Done()
+				return apperror.Internal("failed to find user", errors.New("driver: bad connection"))
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "request_timeout",
+		},
+		{
+			name:       "internal error on a live context",
+			handler:    func(c *gin.Context) error { return errors.New("driver: bad connection") },
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   apperror.CodeInternal,
+		},
+		{
+			name:       "internal error within the deadline",
+			timeout:    time.Minute,
+			handler:    func(c *gin.Context) error { return errors.New("driver: bad connection") },
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   apperror.CodeInternal,
+		},
+		{
+			name:       "client error on a cancelled context",
+			ctx:        canceled,
+			handler:    func(c *gin.Context) error { return apperror.NotFound("user_not_found", "user not found") },
+			wantStatus: http.StatusNotFound,
+			wantCode:   "user_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(ErrorHandler(), Timeout(tt.timeout))
+			router.GET("/", func(c *gin.Context) { _ = c.Error(tt.handler(c)) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.ctx != nil {
+				req = req.WithContext(tt.ctx)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if body := decodeError(t, w); body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// fakeDB is a database/sql connector standing in for Postgres. It keeps
+// schema_migrations in memory, applies bookkeeping only when a transaction
+// commits and tracks the advisory lock, so tests can check that every
+// script ran inside a transaction while the lock was held.
+type fakeDB struct {
+	mu      sync.Mutex
+	applied map[int64]time.Time
+	locked  bool
+	// scripts are the migration scripts run, in order
+	scripts []string
+	// failOn makes a script containing it fail
+	failOn string
+	// lockErr makes acquiring the advisory lock fail
+	lockErr error
+	// outsideLock records statements other than the lock run without it
+	outsideLock []string
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{applied: map[int64]time.Time{}}
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: db}, nil }
+
+func (db *fakeDB) Driver() driver.Driver { return nil }
+
+// markApplied records versions as applied before the test runs
+func (db *fakeDB) markApplied(versions ...int64) {
+	for _, version := range versions {
+		db.applied[version] = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// appliedVersions returns the recorded versions in ascending order
+func (db *fakeDB) appliedVersions() []int64 {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var versions []int64
+	for version := range db.applied {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+type fakeConn struct {
+	db *fakeDB
+	// pending holds the bookkeeping of the open transaction, if any
+	pending []func()
+	inTx    bool
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver does not prepare statements")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.inTx = true
+	c.pending = nil
+	return fakeTx{c}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	db := c.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "pg_advisory_lock"):
+		if db.lockErr != nil {
+			return nil, db.lockErr
+		}
+		db.locked = true
+		return driver.RowsAffected(0), nil
+	case strings.Contains(query, "pg_advisory_unlock"):
+		db.locked = false
+		return driver.RowsAffected(0), nil
+	}
+
+	if !db.locked && !strings.Contains(query, "CREATE TABLE IF NOT EXISTS schema_migrations") {
+		db.outsideLock = append(db.outsideLock, query)
+	}
+
+	switch {
+	case strings.Contains(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		version := args[0].Value.(int64)
+		c.pending = append(c.pending, func() { db.applied[version] = time.Now() })
+	case strings.HasPrefix(query, "DELETE FROM schema_migrations"):
+		version := args[0].Value.(int64)
+		c.pending = append(c.pending, func() { delete(db.applied, version) })
+	default:
+		if !c.inTx {
+			return nil, errors.New("migration script run outside a transaction")
+		}
+		db.scripts = append(db.scripts, query)
+		if db.failOn != "" && strings.Contains(query, db.failOn) {
+			return nil, errors.New("syntax error")
+		}
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if !strings.HasPrefix(query, "SELECT version, applied_at FROM schema_migrations") {
+		return nil, errors.New("unexpected query: " + query)
+	}
+
+	db := c.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	rows := &fakeRows{}
+	for version, appliedAt := range db.applied {
+		rows.values = append(rows.values, []driver.Value{version, appliedAt})
+	}
+	return rows, nil
+}
+
+type fakeTx struct{ c *fakeConn }
+
+func (tx fakeTx) Commit() error {
+	tx.c.db.mu.Lock()
+	defer tx.c.db.mu.Unlock()
+	for _, apply := range tx.c.pending {
+		apply()
+	}
+	tx.c.pending, tx.c.inTx = nil, false
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.c.pending, tx.c.inTx = nil, false
+	return nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version", "applied_at"} }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// testMigrations are three versions whose scripts name themselves
+var testMigrations = []Migration{
+	{Version: 1, Name: "initial_schema", Up: "CREATE TABLE users", Down: "DROP TABLE users"},
+	{Version: 2, Name: "country_admin", Up: "ALTER TABLE country", Down: "ALTER TABLE country DROP"},
+	{Version: 3, Name: "exchange_rates", Up: "CREATE TABLE exchange_rates", Down: "DROP TABLE exchange_rates"},
+}
+
+func versionsOf(migrations []Migration) []int64 {
+	var versions []int64
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	return versions
+}
+
+func TestLoad(t *testing.T) {
+	file := func(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		wantVersions []int64
+		wantErr      string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"0010_later.up.sql":            file("up 10"),
+				"0010_later.down.sql":          file("down 10"),
+				"0002_second.up.sql":           file("up 2"),
+				"0002_second.down.sql":         file("down 2"),
+				"0001_initial_schema.up.sql":   file("up 1"),
+				"0001_initial_schema.down.sql": file("down 1"),
+				"migrations.go":                file("package migrations"),
+				"README.md":                    file("notes"),
+				"0003_Not_Lowercase.up.sql":    file("ignored"),
+			},
+			wantVersions: []int64{1, 2, 10},
+		},
+		{
+			name: "up without down",
+			files: fstest.MapFS{
+				"0001_initial_schema.up.sql": file("up 1"),
+			},
+			wantErr: "1_initial_schema needs both an up and a down file",
+		},
+		{
+			name: "down without up",
+			files: fstest.MapFS{
+				"0001_initial_schema.up.sql":   file("up 1"),
+				"0001_initial_schema.down.sql": file("down 1"),
+				"0002_second.down.sql":         file("down 2"),
+			},
+			wantErr: "2_second needs both an up and a down file",
+		},
+		{
+			name: "version with two names",
+			files: fstest.MapFS{
+				"0002_second.up.sql":   file("up 2"),
+				"0002_second.down.sql": file("down 2"),
+				"0002_other.up.sql":    file("up 2 again"),
+				"0002_other.down.sql":  file("down 2 again"),
+			},
+			wantErr: "migration 2 has two names",
+		},
+		{
+			name: "version written twice",
+			files: fstest.MapFS{
+				"0002_second.up.sql":   file("up 2"),
+				"0002_second.down.sql": file("down 2"),
+				"002_second.up.sql":    file("up 2 again"),
+			},
+			wantErr: "2_second has two up files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load error = %v, want one about %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := versionsOf(migrations); !reflect.DeepEqual(got, tt.wantVersions) {
+				t.Errorf("versions = %v, want %v", got, tt.wantVersions)
+			}
+			for _, migration := range migrations {
+				if migration.Up == "" || migration.Down == "" {
+					t.Errorf("migration %d is missing a script", migration.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestUp(t *testing.T) {
+	tests := []struct {
+		name         string
+		prepare      func(db *fakeDB)
+		wantApplied  []int64
+		wantRecorded []int64
+		wantScripts  []string
+		wantErr      string
+	}{
+		{
+			name:         "fresh database",
+			wantApplied:  []int64{1, 2, 3},
+			wantRecorded: []int64{1, 2, 3},
+			wantScripts:  []string{"CREATE TABLE users", "ALTER TABLE country", "CREATE TABLE exchange_rates"},
+		},
+		{
+			name:         "some applied",
+			prepare:      func(db *fakeDB) { db.markApplied(1) },
+			wantApplied:  []int64{2, 3},
+			wantRecorded: []int64{1, 2, 3},
+			wantScripts:  []string{"ALTER TABLE country", "CREATE TABLE exchange_rates"},
+		},
+		{
+			name:         "up to date",
+			prepare:      func(db *fakeDB) { db.markApplied(1, 2, 3) },
+			wantRecorded: []int64{1, 2, 3},
+		},
+		{
+			name:         "failed migration stops the run",
+			prepare:      func(db *fakeDB) { db.failOn = "ALTER TABLE country" },
+			wantApplied:  []int64{1},
+			wantRecorded: []int64{1},
+			wantScripts:  []string{"CREATE TABLE users", "ALTER TABLE country"},
+			wantErr:      "apply 2_country_admin",
+		},
+		{
+			name:    "lock not acquired",
+			prepare: func(db *fakeDB) { db.lockErr = errors.New("connection reset") },
+			wantErr: "acquire migration lock",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeDB()
+			if tt.prepare != nil {
+				tt.prepare(fake)
+			}
+			db := sql.OpenDB(fake)
+			defer db.Close()
+
+			applied, err := New(db, testMigrations).Up(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Up error = %v, want one about %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := versionsOf(applied); !reflect.DeepEqual(got, tt.wantApplied) {
+				t.Errorf("applied = %v, want %v", got, tt.wantApplied)
+			}
+			if got := fake.appliedVersions(); !reflect.DeepEqual(got, tt.wantRecorded) {
+				t.Errorf("schema_migrations = %v, want %v", got, tt.wantRecorded)
+			}
+			if !reflect.DeepEqual(fake.scripts, tt.wantScripts) {
+				t.Errorf("scripts = %q, want %q", fake.scripts, tt.wantScripts)
+			}
+			if fake.locked {
+				t.Error("migration lock still held")
+			}
+			if len(fake.outsideLock) != 0 {
+				t.Errorf("ran without the migration lock: %q", fake.outsideLock)
+			}
+		})
+	}
+}
+
+func TestDown(t *testing.T) {
+	tests := []struct {
+		name           string
+		applied        []int64
+		n              int
+		wantRolledBack []int64
+		wantRecorded   []int64
+		wantScripts    []string
+	}{
+		{
+			name:           "latest",
+			applied:        []int64{1, 2, 3},
+			n:              1,
+			wantRolledBack: []int64{3},
+			wantRecorded:   []int64{1, 2},
+			wantScripts:    []string{"DROP TABLE exchange_rates"},
+		},
+		{
+			name:           "newest first",
+			applied:        []int64{1, 2, 3},
+			n:              2,
+			wantRolledBack: []int64{3, 2},
+			wantRecorded:   []int64{1},
+			wantScripts:    []string{"DROP TABLE exchange_rates", "ALTER TABLE country DROP"},
+		},
+		{
+			name:           "skips pending migrations",
+			applied:        []int64{1, 2},
+			n:              1,
+			wantRolledBack: []int64{2},
+			wantRecorded:   []int64{1},
+			wantScripts:    []string{"ALTER TABLE country DROP"},
+		},
+		{
+			name:           "more than applied",
+			applied:        []int64{1},
+			n:              5,
+			wantRolledBack: []int64{1},
+			wantScripts:    []string{"DROP TABLE users"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeDB()
+			fake.markApplied(tt.applied...)
+			db := sql.OpenDB(fake)
+			defer db.Close()
+
+			rolledBack, err := New(db, testMigrations).Down(context.Background(), tt.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := versionsOf(rolledBack); !reflect.DeepEqual(got, tt.wantRolledBack) {
+				t.Errorf("rolled back = %v, want %v", got, tt.wantRolledBack)
+			}
+			if got := fake.appliedVersions(); !reflect.DeepEqual(got, tt.wantRecorded) {
+				t.Errorf("schema_migrations = %v, want %v", got, tt.wantRecorded)
+			}
+			if !reflect.DeepEqual(fake.scripts, tt.wantScripts) {
+				t.Errorf("scripts = %q, want %q", fake.scripts, tt.wantScripts)
+			}
+			if fake.locked || len(fake.outsideLock) != 0 {
+				t.Errorf("lock held = %v, ran without the lock: %q", fake.locked, fake.outsideLock)
+			}
+		})
+	}
+}
+
+func TestCheckApplied(t *testing.T) {
+	fake := newFakeDB()
+	fake.markApplied(1, 2)
+	db := sql.OpenDB(fake)
+	defer db.Close()
+	migrator := New(db, testMigrations)
+
+	err := migrator.CheckApplied(context.Background())
+	if !errors.Is(err, ErrSchemaBehind) || !strings.Contains(err.Error(), "3_exchange_rates") {
+		t.Fatalf("CheckApplied error = %v, want schema behind at 3_exchange_rates", err)
+	}
+
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.CheckApplied(context.Background()); err != nil {
+		t.Errorf("CheckApplied error = %v after Up, want none", err)
+	}
+}
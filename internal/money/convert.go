@@ -0,0 +1,161 @@
+package money
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode decides where a converted amount that falls between two
+// minor units ends up
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero: 0.5 becomes 1, -0.5 becomes -1
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the even neighbour (banker's rounding)
+	RoundHalfEven
+	// RoundDown truncates toward zero
+	RoundDown
+	// RoundUp rounds away from zero
+	RoundUp
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	RoundHalfUp:   "half_up",
+	RoundHalfEven: "half_even",
+	RoundDown:     "down",
+	RoundUp:       "up",
+}
+
+// ParseRoundingMode reads half_up, half_even, down or up
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	for mode, name := range roundingModeNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rounding mode %q", s)
+}
+
+// String returns the name ParseRoundingMode accepts
+func (r RoundingMode) String() string {
+	return roundingModeNames[r]
+}
+
+// Round returns x rounded to an integer
+func (r RoundingMode) Round(x *big.Rat) *big.Int {
+	num := new(big.Int).Abs(x.Num())
+	den := x.Denom()
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	if remainder.Sign() != 0 {
+		// Compare the remainder with half the denominator
+		half := new(big.Int).Lsh(remainder, 1).Cmp(den)
+		switch r {
+		case RoundHalfUp:
+			if half >= 0 {
+				quotient.Add(quotient, big.NewInt(1))
+			}
+		case RoundHalfEven:
+			if half > 0 || (half == 0 && quotient.Bit(0) == 1) {
+				quotient.Add(quotient, big.NewInt(1))
+			}
+		case RoundUp:
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	if x.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return quotient
+}
+
+// Rate is how many units of a currency one unit of the base currency buys.
+// The base itself is never named; it is whichever currency has rate 1.
+type Rate struct {
+	Currency Currency
+	PerBase  *big.Rat
+}
+
+// NewRate makes a rate from a stored floating-point value. The value is read
+// as the shortest decimal that represents it, so 0.92 is exactly 92/100
+// rather than the nearest binary fraction.
+func NewRate(currency Currency, perBase float64) (Rate, error) {
+	if math.IsInf(perBase, 0) || math.IsNaN(perBase) {
+		return Rate{}, fmt.Errorf("rate for %s must be positive, got %v", currency.Code, perBase)
+	}
+	return ParseRate(currency, strconv.FormatFloat(perBase, 'f', -1, 64))
+}
+
+// ParseRate makes a rate from a decimal string such as "149.5"
+func ParseRate(currency Currency, perBase string) (Rate, error) {
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(perBase))
+	if !ok || rat.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("rate for %s must be a positive decimal, got %q", currency.Code, perBase)
+	}
+	return Rate{Currency: currency, PerBase: rat}, nil
+}
+
+// Converter converts amounts using a fixed table of rates. It is safe for
+// concurrent use; build a new one when rates change.
+type Converter struct {
+	rates    map[string]Rate
+	rounding RoundingMode
+}
+
+// NewConverter creates a converter. When a currency appears more than once,
+// the first rate wins.
+func NewConverter(rates []Rate, rounding RoundingMode) *Converter {
+	table := make(map[string]Rate, len(rates))
+	for _, rate := range rates {
+		code := strings.ToUpper(rate.Currency.Code)
+		if _, ok := table[code]; !ok {
+			table[code] = rate
+		}
+	}
+	return &Converter{rates: table, rounding: rounding}
+}
+
+// Currency looks up a currency by ISO 4217 code, in any case
+func (c *Converter) Currency(code string) (Currency, error) {
+	rate, ok := c.rates[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+	return rate.Currency, nil
+}
+
+// Rate returns how many units of to one unit of from buys
+func (c *Converter) Rate(from, to string) (*big.Rat, error) {
+	fromRate, ok := c.rates[strings.ToUpper(strings.TrimSpace(from))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCurrency, from)
+	}
+	toRate, ok := c.rates[strings.ToUpper(strings.TrimSpace(to))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCurrency, to)
+	}
+	return new(big.Rat).Quo(toRate.PerBase, fromRate.PerBase), nil
+}
+
+// Convert expresses m in the currency to, rounded to that currency's minor
+// unit with the converter's rounding mode
+func (c *Converter) Convert(m Money, to string) (Money, error) {
+	rate, err := c.Rate(m.currency.Code, to)
+	if err != nil {
+		return Money{}, err
+	}
+	target, _ := c.Currency(to)
+
+	minor := new(big.Rat).Mul(m.Rat(), rate)
+	minor.Mul(minor, new(big.Rat).SetInt(pow10(target.Decimals)))
+	rounded := c.rounding.Round(minor)
+	if !rounded.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s in %s", ErrOutOfRange, m, target.Code)
+	}
+	return New(rounded.Int64(), target), nil
+}
@@ -0,0 +1,154 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		x                          string
+		halfUp, halfEven, down, up int64
+	}{
+		{"2", 2, 2, 2, 2},
+		{"2.4", 2, 2, 2, 3},
+		{"2.5", 3, 2, 2, 3},
+		{"3.5", 4, 4, 3, 4},
+		{"2.6", 3, 3, 2, 3},
+		{"7/3", 2, 2, 2, 3},
+		{"-2.5", -3, -2, -2, -3},
+		{"-3.5", -4, -4, -3, -4},
+		{"-2.6", -3, -3, -2, -3},
+		{"-0.4", 0, 0, 0, -1},
+	}
+
+	for _, tt := range tests {
+		x, ok := new(big.Rat).SetString(tt.x)
+		if !ok {
+			t.Fatalf("bad test value %q", tt.x)
+		}
+		for mode, want := range map[RoundingMode]int64{
+			RoundHalfUp:   tt.halfUp,
+			RoundHalfEven: tt.halfEven,
+			RoundDown:     tt.down,
+			RoundUp:       tt.up,
+		} {
+			if got := mode.Round(x); got.Int64() != want {
+				t.Errorf("%s.Round(%s) = %d, want %d", mode, tt.x, got, want)
+			}
+		}
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    RoundingMode
+		wantErr bool
+	}{
+		{name: "half_up", want: RoundHalfUp},
+		{name: "half_even", want: RoundHalfEven},
+		{name: "down", want: RoundDown},
+		{name: "up", want: RoundUp},
+		{name: " HALF_EVEN ", want: RoundHalfEven},
+		{name: "nearest", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		mode, err := ParseRoundingMode(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseRoundingMode(%q) error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && mode != tt.want {
+			t.Errorf("ParseRoundingMode(%q) = %s, want %s", tt.name, mode, tt.want)
+		}
+	}
+}
+
+func TestRates(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    func() (Rate, error)
+		want    string
+		wantErr bool
+	}{
+		{name: "float read as its shortest decimal", rate: func() (Rate, error) { return NewRate(usd, 0.92) }, want: "23/25"},
+		{name: "whole float", rate: func() (Rate, error) { return NewRate(jpy, 149) }, want: "149"},
+		{name: "zero float", rate: func() (Rate, error) { return NewRate(usd, 0) }, wantErr: true},
+		{name: "negative float", rate: func() (Rate, error) { return NewRate(usd, -1) }, wantErr: true},
+		{name: "NaN", rate: func() (Rate, error) { return NewRate(usd, math.NaN()) }, wantErr: true},
+		{name: "infinity", rate: func() (Rate, error) { return NewRate(usd, math.Inf(1)) }, wantErr: true},
+		{name: "decimal string", rate: func() (Rate, error) { return ParseRate(jpy, " 149.5 ") }, want: "299/2"},
+		{name: "zero string", rate: func() (Rate, error) { return ParseRate(jpy, "0") }, wantErr: true},
+		{name: "not a number", rate: func() (Rate, error) { return ParseRate(jpy, "abc") }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := tt.rate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && rate.PerBase.RatString() != tt.want {
+				t.Errorf("rate = %s, want %s", rate.PerBase, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	eur := Currency{Code: "EUR", Symbol: "€", Decimals: 2}
+	rates := []Rate{
+		{Currency: usd, PerBase: big.NewRat(1, 1)},
+		{Currency: eur, PerBase: big.NewRat(92, 100)},
+		{Currency: jpy, PerBase: big.NewRat(1485, 10)},
+		{Currency: kwd, PerBase: big.NewRat(307, 1000)},
+		// Later duplicates are ignored
+		{Currency: eur, PerBase: big.NewRat(1, 1)},
+	}
+
+	tests := []struct {
+		name     string
+		money    Money
+		to       string
+		rounding RoundingMode
+		want     int64
+		wantErr  error
+	}{
+		{name: "exact", money: New(1000, usd), to: "EUR", want: 920},
+		{name: "same currency", money: New(1234, usd), to: "USD", want: 1234},
+		{name: "code in any case", money: New(1000, usd), to: " eur ", want: 920},
+		{name: "cross rate half up", money: New(100, eur), to: "USD", rounding: RoundHalfUp, want: 109},
+		{name: "cross rate down", money: New(100, eur), to: "USD", rounding: RoundDown, want: 108},
+		{name: "half yen half up", money: New(100, usd), to: "JPY", rounding: RoundHalfUp, want: 149},
+		{name: "half yen half even", money: New(100, usd), to: "JPY", rounding: RoundHalfEven, want: 148},
+		{name: "half yen down", money: New(100, usd), to: "JPY", rounding: RoundDown, want: 148},
+		{name: "half yen up", money: New(100, usd), to: "JPY", rounding: RoundUp, want: 149},
+		{name: "negative half yen half up", money: New(-100, usd), to: "JPY", rounding: RoundHalfUp, want: -149},
+		{name: "negative half yen down", money: New(-100, usd), to: "JPY", rounding: RoundDown, want: -148},
+		{name: "yen to cents up", money: New(100, jpy), to: "USD", rounding: RoundUp, want: 68},
+		{name: "to three decimals", money: New(250, usd), to: "KWD", want: 768},
+		{name: "too small to show", money: New(1, jpy), to: "USD", rounding: RoundDown, want: 0},
+		{name: "unknown target", money: New(100, usd), to: "XXX", wantErr: ErrUnknownCurrency},
+		{name: "unknown source", money: New(100, Currency{Code: "XXX"}), to: "USD", wantErr: ErrUnknownCurrency},
+		{name: "out of range", money: New(math.MaxInt64, usd), to: "JPY", wantErr: ErrOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConverter(rates, tt.rounding).Convert(tt.money, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Convert error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Minor() != tt.want {
+				t.Errorf("Convert(%s %s, %s) = %d, want %d", tt.money, tt.money.Currency().Code, tt.to, got.Minor(), tt.want)
+			}
+		})
+	}
+}
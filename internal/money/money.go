@@ -0,0 +1,182 @@
+// Package money represents amounts exactly, as an integer count of a
+// currency's minor units (cents, or whole yen for zero-decimal currencies),
+// and converts between currencies with rational arithmetic. Amounts never
+// pass through float64, so they cannot pick up binary rounding errors.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxDigits keeps every parsed amount within int64 minor units
+const maxDigits = 18
+
+// Errors returned when parsing or converting amounts; match them with errors.Is
+var (
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrTooManyDecimals = errors.New("too many decimal places")
+	ErrOutOfRange      = errors.New("amount out of range")
+	ErrUnknownCurrency = errors.New("unknown currency")
+)
+
+// Currency describes how amounts in a currency are counted and shown
+type Currency struct {
+	Code     string // ISO 4217 code, e.g. "USD"
+	Symbol   string // e.g. "$"; the code is shown when empty
+	Decimals int    // digits after the decimal point: 2 for USD, 0 for JPY and KRW
+}
+
+// Money is an amount in a currency, held in minor units
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+// New creates an amount from minor units, e.g. New(1250, usd) is $12.50
+func New(minor int64, currency Currency) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// Parse reads a decimal amount in major units such as "12.50" or "-3".
+// Digits past the currency's decimals are rejected unless they are zeros,
+// so "1000.00" is a valid yen amount but "12.345" is not a valid dollar one.
+func Parse(amount string, currency Currency) (Money, error) {
+	s := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+
+	if len(fraction) > currency.Decimals {
+		if strings.Trim(fraction[currency.Decimals:], "0") != "" {
+			return Money{}, fmt.Errorf("%w: %s has %d", ErrTooManyDecimals, currency.Code, currency.Decimals)
+		}
+		fraction = fraction[:currency.Decimals]
+	}
+	fraction += strings.Repeat("0", currency.Decimals-len(fraction))
+
+	digits := strings.TrimLeft(whole+fraction, "0")
+	if len(digits) > maxDigits {
+		return Money{}, fmt.Errorf("%w: %q", ErrOutOfRange, amount)
+	}
+	var minor int64
+	if digits != "" {
+		minor, _ = strconv.ParseInt(digits, 10, 64)
+	}
+	if negative {
+		minor = -minor
+	}
+	return New(minor, currency), nil
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Currency returns the currency of the amount
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// Rat returns the amount in major units as an exact fraction
+func (m Money) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.minor), pow10(m.currency.Decimals))
+}
+
+// String returns the amount as a plain decimal in major units, e.g. "1234.50"
+func (m Money) String() string {
+	whole, fraction := m.digits()
+	s := whole
+	if fraction != "" {
+		s += "." + fraction
+	}
+	if m.minor < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Format returns the amount for display with its symbol and thousands
+// separators, e.g. "$1,234.50" or "¥18,762"
+func (m Money) Format() string {
+	whole, fraction := m.digits()
+	s := groupThousands(whole)
+	if fraction != "" {
+		s += "." + fraction
+	}
+	if m.currency.Symbol != "" {
+		s = m.currency.Symbol + s
+	} else {
+		s += " " + m.currency.Code
+	}
+	if m.minor < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// digits splits the absolute amount into whole and fractional digits
+func (m Money) digits() (whole, fraction string) {
+	// Converting through uint64 keeps math.MinInt64 positive
+	abs := uint64(m.minor)
+	if m.minor < 0 {
+		abs = -abs
+	}
+	s := strconv.FormatUint(abs, 10)
+
+	decimals := m.currency.Decimals
+	if decimals <= 0 {
+		return s, ""
+	}
+	if len(s) <= decimals {
+		s = strings.Repeat("0", decimals-len(s)+1) + s
+	}
+	return s[:len(s)-decimals], s[len(s)-decimals:]
+}
+
+// groupThousands inserts a comma every three digits from the right
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// isDigits reports whether s consists of ASCII digits only
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// pow10 returns 10^n as a big integer
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
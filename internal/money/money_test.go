@@ -0,0 +1,109 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+var (
+	usd = Currency{Code: "USD", Symbol: "$", Decimals: 2}
+	jpy = Currency{Code: "JPY", Symbol: "¥", Decimals: 0}
+	kwd = Currency{Code: "KWD", Decimals: 3}
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    string
+		currency  Currency
+		wantMinor int64
+		wantErr   error
+	}{
+		{name: "dollars and cents", amount: "12.50", currency: usd, wantMinor: 1250},
+		{name: "whole dollars", amount: "12", currency: usd, wantMinor: 1200},
+		{name: "one decimal", amount: "+3.1", currency: usd, wantMinor: 310},
+		{name: "negative", amount: "-3", currency: usd, wantMinor: -300},
+		{name: "surrounding space", amount: " 7.05 ", currency: usd, wantMinor: 705},
+		{name: "zero", amount: "0.00", currency: usd, wantMinor: 0},
+		{name: "trailing zeros past the decimals", amount: "12.340", currency: usd, wantMinor: 1234},
+		{name: "yen with zero decimals", amount: "1000.00", currency: jpy, wantMinor: 1000},
+		{name: "three decimals", amount: "1.005", currency: kwd, wantMinor: 1005},
+		{name: "leading zeros", amount: "000000000000000000001", currency: jpy, wantMinor: 1},
+		{name: "largest amount", amount: "999999999999999999", currency: jpy, wantMinor: 999999999999999999},
+		{name: "too many decimals", amount: "12.345", currency: usd, wantErr: ErrTooManyDecimals},
+		{name: "fractional yen", amount: "100.5", currency: jpy, wantErr: ErrTooManyDecimals},
+		{name: "too many digits", amount: "9223372036854775807", currency: jpy, wantErr: ErrOutOfRange},
+		{name: "too many digits with cents", amount: "10000000000000000.00", currency: usd, wantErr: ErrOutOfRange},
+		{name: "empty", amount: "", currency: usd, wantErr: ErrInvalidAmount},
+		{name: "point without digits", amount: ".", currency: usd, wantErr: ErrInvalidAmount},
+		{name: "no whole part", amount: ".5", currency: usd, wantErr: ErrInvalidAmount},
+		{name: "no fraction after point", amount: "1.", currency: usd, wantErr: ErrInvalidAmount},
+		{name: "double sign", amount: "--1", currency: usd, wantErr: ErrInvalidAmount},
+		{name: "thousands separator", amount: "1,000", currency: usd, wantErr: ErrInvalidAmount},
+		{name: "exponent", amount: "1e3", currency: usd, wantErr: ErrInvalidAmount},
+		{name: "symbol", amount: "$5", currency: usd, wantErr: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.amount, tt.currency)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.amount, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m.Minor() != tt.wantMinor || m.Currency() != tt.currency {
+				t.Errorf("Parse(%q) = %d %s, want %d %s", tt.amount, m.Minor(), m.Currency().Code, tt.wantMinor, tt.currency.Code)
+			}
+		})
+	}
+}
+
+func TestStringAndFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		money      Money
+		wantString string
+		wantFormat string
+	}{
+		{name: "dollars", money: New(123450, usd), wantString: "1234.50", wantFormat: "$1,234.50"},
+		{name: "zero", money: New(0, usd), wantString: "0.00", wantFormat: "$0.00"},
+		{name: "cents only", money: New(-5, usd), wantString: "-0.05", wantFormat: "-$0.05"},
+		{name: "under a thousand", money: New(99999, usd), wantString: "999.99", wantFormat: "$999.99"},
+		{name: "yen", money: New(18762, jpy), wantString: "18762", wantFormat: "¥18,762"},
+		{name: "even thousands", money: New(100000, jpy), wantString: "100000", wantFormat: "¥100,000"},
+		{name: "three decimals", money: New(1, kwd), wantString: "0.001", wantFormat: "0.001 KWD"},
+		{name: "no symbol", money: New(1234567, kwd), wantString: "1234.567", wantFormat: "1,234.567 KWD"},
+		{
+			name:       "smallest int64",
+			money:      New(math.MinInt64, jpy),
+			wantString: "-9223372036854775808",
+			wantFormat: "-¥9,223,372,036,854,775,808",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.wantString {
+				t.Errorf("String() = %q, want %q", got, tt.wantString)
+			}
+			if got := tt.money.Format(); got != tt.wantFormat {
+				t.Errorf("Format() = %q, want %q", got, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestParseStringRoundTrip(t *testing.T) {
+	for _, amount := range []string{"0.00", "0.01", "-0.99", "1234.50", "999999999999999.99"} {
+		m, err := Parse(amount, usd)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", amount, err)
+		}
+		if got := m.String(); got != amount {
+			t.Errorf("Parse(%q).String() = %q", amount, got)
+		}
+	}
+}
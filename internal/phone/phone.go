@@ -0,0 +1,181 @@
+package phone
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// E.164 numbers are a "+" followed by at most 15 digits. Real numbers are
+// never shorter than 8 digits including the country code.
+const (
+	minDigits = 8
+	maxDigits = 15
+)
+
+var (
+	// ErrInvalid is returned for input that is not a valid number
+	ErrInvalid = errors.New("phone: invalid number")
+	// ErrRegionRequired is returned for a national number without a country
+	ErrRegionRequired = errors.New("phone: country required for national number")
+)
+
+// Number is a parsed phone number
+type Number struct {
+	CallingCode int    // country dialling code, e.g. 61
+	NSN         string // national significant number, without trunk prefix
+	plan        *plan  // numbering plan, nil when the country has no metadata
+}
+
+// Parse reads a number as typed by a user. Numbers starting with "+" or the
+// "00" international prefix carry their own dialling code; anything else is
+// read as a national number of region (ISO 3166 alpha-2, e.g. "AU") with
+// callingCode as its dialling code. Regions with numbering plan metadata are
+// validated strictly; others only get the generic E.164 checks.
+func Parse(raw, region string, callingCode int) (Number, error) {
+	digits, international, err := digitsOf(raw)
+	if err != nil {
+		return Number{}, err
+	}
+
+	var number Number
+	if international {
+		number, err = parseInternational(digits)
+	} else {
+		number, err = parseNational(digits, strings.ToUpper(region), callingCode)
+	}
+	if err != nil {
+		return Number{}, err
+	}
+
+	total := len(strconv.Itoa(number.CallingCode)) + len(number.NSN)
+	if total < minDigits || total > maxDigits {
+		return Number{}, ErrInvalid
+	}
+	if number.plan != nil && !number.plan.validLength(len(number.NSN)) {
+		return Number{}, ErrInvalid
+	}
+	return number, nil
+}
+
+// ParseE164 reads a number stored in E.164 form
+func ParseE164(e164 string) (Number, error) {
+	if !strings.HasPrefix(e164, "+") {
+		return Number{}, ErrInvalid
+	}
+	return Parse(e164, "", 0)
+}
+
+// E164 returns the number as "+<calling code><NSN>"
+func (n Number) E164() string {
+	return "+" + strconv.Itoa(n.CallingCode) + n.NSN
+}
+
+// National returns the number as dialled within its country, e.g.
+// "0412 345 678" or "(201) 555-0123". Numbers of countries without
+// metadata are returned in E.164 form.
+func (n Number) National() string {
+	if n.plan == nil {
+		return n.E164()
+	}
+	if n.plan.format != nil {
+		if formatted, ok := n.plan.format(n.NSN); ok {
+			return formatted
+		}
+	}
+	return n.plan.trunkPrefix + groupDigits(n.NSN, n.plan.groupsFor(len(n.NSN)))
+}
+
+// digitsOf strips formatting characters and reports whether the number was
+// written with an international prefix
+func digitsOf(raw string) (string, bool, error) {
+	international := false
+	var digits strings.Builder
+
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			international = true
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')' || r == '/':
+			// formatting only
+		default:
+			return "", false, ErrInvalid
+		}
+	}
+
+	number := digits.String()
+	if !international && strings.HasPrefix(number, "00") {
+		international = true
+		number = number[2:]
+	}
+	if number == "" {
+		return "", false, ErrInvalid
+	}
+	return number, international, nil
+}
+
+// parseInternational splits digits into dialling code and NSN. Dialling codes
+// are prefix-free, so the first known prefix of one to three digits wins.
+func parseInternational(digits string) (Number, error) {
+	if digits[0] == '0' {
+		return Number{}, ErrInvalid
+	}
+
+	for length := 1; length <= 3 && length < len(digits); length++ {
+		code, _ := strconv.Atoi(digits[:length])
+		if p, ok := plansByCallingCode[code]; ok {
+			return Number{CallingCode: code, NSN: digits[length:], plan: p}, nil
+		}
+	}
+
+	// Without metadata the split is a guess, but E164() puts the same
+	// digits back together and National() does not rely on it
+	code, _ := strconv.Atoi(digits[:1])
+	return Number{CallingCode: code, NSN: digits[1:]}, nil
+}
+
+// parseNational strips the trunk prefix of the region and attaches its dialling code
+func parseNational(digits, region string, callingCode int) (Number, error) {
+	p := plansByRegion[region]
+	if p != nil {
+		callingCode = p.callingCode
+	}
+	if callingCode <= 0 {
+		return Number{}, ErrRegionRequired
+	}
+
+	nsn := digits
+	switch {
+	case p == nil:
+		// Most countries without metadata use "0" as trunk prefix
+		nsn = strings.TrimLeft(digits, "0")
+	case p.trunkPrefix != "" && strings.HasPrefix(digits, p.trunkPrefix) && p.validLength(len(digits)-len(p.trunkPrefix)):
+		nsn = digits[len(p.trunkPrefix):]
+	}
+	if nsn == "" {
+		return Number{}, ErrInvalid
+	}
+
+	return Number{CallingCode: callingCode, NSN: nsn, plan: p}, nil
+}
+
+// groupDigits splits digits into groups of the given sizes (threes by
+// default); whatever is left over forms the last group
+func groupDigits(digits string, groups []int) string {
+	if len(groups) == 0 {
+		groups = []int{3, 3, 3, 3}
+	}
+
+	var parts []string
+	for _, size := range groups {
+		if len(digits) <= size {
+			break
+		}
+		parts = append(parts, digits[:size])
+		digits = digits[size:]
+	}
+	parts = append(parts, digits)
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,81 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		region       string
+		callingCode  int
+		wantE164     string
+		wantNational string
+		wantErr      error
+	}{
+		{"national with trunk prefix", "0412 345 678", "AU", 0, "+61412345678", "0412 345 678", nil},
+		{"lower case region", "0412 345 678", "au", 0, "+61412345678", "0412 345 678", nil},
+		{"international with plus", "+61 412 345 678", "", 0, "+61412345678", "0412 345 678", nil},
+		{"international with 00", "0061412345678", "", 0, "+61412345678", "0412 345 678", nil},
+		{"international ignores region", "+64 21 123 4567", "AU", 0, "+64211234567", "021 123 4567", nil},
+		{"NANP punctuation", "(201) 555-0123", "US", 0, "+12015550123", "(201) 555-0123", nil},
+		{"NANP with trunk prefix", "1 201 555 0123", "US", 0, "+12015550123", "(201) 555-0123", nil},
+		{"GB mobile", "07911 123456", "GB", 0, "+447911123456", "07911 123456", nil},
+		{"region without trunk prefix", "612 345 678", "ES", 0, "+34612345678", "612 345 678", nil},
+		{"region without metadata", "082 123 4567", "ZA", 27, "+27821234567", "+27821234567", nil},
+		{"unknown dialling code", "+999 1234 5678", "", 0, "+99912345678", "+99912345678", nil},
+		{"national without region", "0412 345 678", "", 0, "", "", ErrRegionRequired},
+		{"too short for the plan", "0412 345", "AU", 0, "", "", ErrInvalid},
+		{"too long for the plan", "+61 412 345 6789", "", 0, "", "", ErrInvalid},
+		{"too short for E.164", "+1234", "", 0, "", "", ErrInvalid},
+		{"too long for E.164", "+999 1234 5678 90123", "", 0, "", "", ErrInvalid},
+		{"letters", "0412 ABC 678", "AU", 0, "", "", ErrInvalid},
+		{"plus inside", "04+12 345 678", "AU", 0, "", "", ErrInvalid},
+		{"dialling code starting with 0", "+0412345678", "", 0, "", "", ErrInvalid},
+		{"empty", " ", "AU", 0, "", "", ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, err := Parse(tt.raw, tt.region, tt.callingCode)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q, %q) error = %v, want %v", tt.raw, tt.region, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := number.E164(); got != tt.wantE164 {
+				t.Errorf("E164() = %q, want %q", got, tt.wantE164)
+			}
+			if got := number.National(); got != tt.wantNational {
+				t.Errorf("National() = %q, want %q", got, tt.wantNational)
+			}
+		})
+	}
+}
+
+func TestParseE164(t *testing.T) {
+	tests := []struct {
+		stored  string
+		wantErr error
+	}{
+		{"+61412345678", nil},
+		{"+12015550123", nil},
+		{"0412345678", ErrInvalid},
+		{"61412345678", ErrInvalid},
+		{"+61412", ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		number, err := ParseE164(tt.stored)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ParseE164(%q) error = %v, want %v", tt.stored, err, tt.wantErr)
+			continue
+		}
+		if err == nil && number.E164() != tt.stored {
+			t.Errorf("ParseE164(%q).E164() = %q", tt.stored, number.E164())
+		}
+	}
+}
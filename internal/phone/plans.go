@@ -0,0 +1,81 @@
+package phone
+
+import (
+	"fmt"
+)
+
+// plan is the part of a country's numbering plan needed to validate and
+// display numbers. It covers the markets we operate in; other countries fall
+// back to generic E.164 rules.
+type plan struct {
+	callingCode int
+	trunkPrefix string                      // dialled before national numbers, e.g. "0"
+	lengths     []int                       // valid NSN lengths
+	groups      map[int][]int               // display grouping by NSN length
+	format      func(string) (string, bool) // custom display, tried first
+}
+
+// validLength reports whether an NSN of length n is valid in the plan
+func (p *plan) validLength(n int) bool {
+	for _, length := range p.lengths {
+		if length == n {
+			return true
+		}
+	}
+	return false
+}
+
+// groupsFor returns the display grouping for an NSN of length n
+func (p *plan) groupsFor(n int) []int {
+	return p.groups[n]
+}
+
+// formatNANP formats North American numbers as (201) 555-0123
+func formatNANP(nsn string) (string, bool) {
+	if len(nsn) != 10 {
+		return "", false
+	}
+	return fmt.Sprintf("(%s) %s-%s", nsn[:3], nsn[3:6], nsn[6:]), true
+}
+
+// plansByRegion holds numbering plans by ISO 3166 alpha-2 code
+var plansByRegion = map[string]*plan{
+	"US": {callingCode: 1, trunkPrefix: "1", lengths: []int{10}, format: formatNANP},
+	"CA": {callingCode: 1, trunkPrefix: "1", lengths: []int{10}, format: formatNANP},
+	"GB": {callingCode: 44, trunkPrefix: "0", lengths: []int{9, 10}, groups: map[int][]int{10: {4, 6}, 9: {4, 5}}},
+	"UK": {callingCode: 44, trunkPrefix: "0", lengths: []int{9, 10}, groups: map[int][]int{10: {4, 6}, 9: {4, 5}}},
+	"IE": {callingCode: 353, trunkPrefix: "0", lengths: []int{7, 8, 9}, groups: map[int][]int{9: {2, 3, 4}, 8: {2, 3, 3}}},
+	"AU": {callingCode: 61, trunkPrefix: "0", lengths: []int{9}, groups: map[int][]int{9: {3, 3, 3}}},
+	"NZ": {callingCode: 64, trunkPrefix: "0", lengths: []int{8, 9, 10}, groups: map[int][]int{8: {1, 3, 4}, 9: {2, 3, 4}, 10: {2, 4, 4}}},
+	"DE": {callingCode: 49, trunkPrefix: "0", lengths: []int{6, 7, 8, 9, 10, 11, 12, 13}, groups: map[int][]int{10: {3, 7}, 11: {3, 8}}},
+	"FR": {callingCode: 33, trunkPrefix: "0", lengths: []int{9}, groups: map[int][]int{9: {1, 2, 2, 2, 2}}},
+	"NL": {callingCode: 31, trunkPrefix: "0", lengths: []int{9}, groups: map[int][]int{9: {1, 8}}},
+	"ES": {callingCode: 34, lengths: []int{9}, groups: map[int][]int{9: {3, 3, 3}}},
+	"IT": {callingCode: 39, lengths: []int{6, 7, 8, 9, 10, 11}, groups: map[int][]int{10: {3, 3, 4}}},
+	"CZ": {callingCode: 420, lengths: []int{9}, groups: map[int][]int{9: {3, 3, 3}}},
+	"IN": {callingCode: 91, trunkPrefix: "0", lengths: []int{10}, groups: map[int][]int{10: {5, 5}}},
+	"CN": {callingCode: 86, trunkPrefix: "0", lengths: []int{10, 11}, groups: map[int][]int{11: {3, 4, 4}}},
+	"HK": {callingCode: 852, lengths: []int{8}, groups: map[int][]int{8: {4, 4}}},
+	"TW": {callingCode: 886, trunkPrefix: "0", lengths: []int{8, 9}, groups: map[int][]int{9: {3, 3, 3}, 8: {1, 3, 4}}},
+	"JP": {callingCode: 81, trunkPrefix: "0", lengths: []int{9, 10}, groups: map[int][]int{10: {2, 4, 4}, 9: {1, 4, 4}}},
+	"KR": {callingCode: 82, trunkPrefix: "0", lengths: []int{9, 10}, groups: map[int][]int{10: {2, 4, 4}, 9: {2, 3, 4}}},
+	"TH": {callingCode: 66, trunkPrefix: "0", lengths: []int{8, 9}, groups: map[int][]int{9: {2, 3, 4}, 8: {1, 3, 4}}},
+	"SG": {callingCode: 65, lengths: []int{8}, groups: map[int][]int{8: {4, 4}}},
+	"MY": {callingCode: 60, trunkPrefix: "0", lengths: []int{9, 10}, groups: map[int][]int{9: {2, 3, 4}, 10: {2, 4, 4}}},
+	"ID": {callingCode: 62, trunkPrefix: "0", lengths: []int{9, 10, 11, 12}, groups: map[int][]int{10: {3, 3, 4}, 11: {3, 4, 4}}},
+	"PH": {callingCode: 63, trunkPrefix: "0", lengths: []int{10}, groups: map[int][]int{10: {3, 3, 4}}},
+	"VN": {callingCode: 84, trunkPrefix: "0", lengths: []int{9, 10}, groups: map[int][]int{9: {2, 3, 4}}},
+	"BR": {callingCode: 55, trunkPrefix: "0", lengths: []int{10, 11}, groups: map[int][]int{11: {2, 5, 4}, 10: {2, 4, 4}}},
+	"MX": {callingCode: 52, lengths: []int{10}, groups: map[int][]int{10: {2, 4, 4}}},
+}
+
+// plansByCallingCode resolves international numbers to a plan. Regions that
+// share a dialling code (US/CA, GB/UK) share numbering rules, so any of them
+// will do.
+var plansByCallingCode = func() map[int]*plan {
+	plans := map[int]*plan{}
+	for _, p := range plansByRegion {
+		plans[p.callingCode] = p
+	}
+	return plans
+}()
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"go-booking-system/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository defines data access methods for the audit trail
+type AuditRepository interface {
+	Create(ctx context.Context, event *domain.AuditEvent) error
+	FindRecent(ctx context.Context, targetUUID string, limit int) ([]domain.AuditEvent, error)
+	FindAllForUser(ctx context.Context, userUUID string) ([]domain.AuditEvent, error)
+	ClearClientIPs(ctx context.Context, actorUUID string) error
+}
+
+// auditRepository implements AuditRepository
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new audit repository instance
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Create appends an event to the audit trail
+func (r *auditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// FindRecent returns the newest events first, optionally only those about one user
+func (r *auditRepository) FindRecent(ctx context.Context, targetUUID string, limit int) ([]domain.AuditEvent, error) {
+	var events []domain.AuditEvent
+	query := r.db.WithContext(ctx).Order("created_at DESC, id DESC").Limit(limit)
+	if targetUUID != "" {
+		query = query.Where("target_uuid = ?", targetUUID)
+	}
+	err := query.Find(&events).Error
+	return events, err
+}
+
+// FindAllForUser returns every event the user did or was subject to, newest first
+func (r *auditRepository) FindAllForUser(ctx context.Context, userUUID string) ([]domain.AuditEvent, error) {
+	var events []domain.AuditEvent
+	err := r.db.WithContext(ctx).
+		Where("actor_uuid = ? OR target_uuid = ?", userUUID, userUUID).
+		Order("created_at DESC, id DESC").
+		Find(&events).Error
+	return events, err
+}
+
+// ClearClientIPs blanks the client IP of every event the user did. Events
+// about the user keep theirs: it is the IP of the admin who acted.
+func (r *auditRepository) ClearClientIPs(ctx context.Context, actorUUID string) error {
+	return r.db.WithContext(ctx).Model(&domain.AuditEvent{}).
+		Where("actor_uuid = ? AND client_ip <> ''", actorUUID).
+		Update("client_ip", "").Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"go-booking-system/internal/domain"
 
 	"gorm.io/gorm"
@@ -8,9 +9,13 @@ import (
 
 // CountryRepository defines data access methods for Country
 type CountryRepository interface {
-	FindByShortname(shortname string) (*domain.Country, error)
-	FindByID(id uint) (*domain.Country, error)
-	FindAll() ([]domain.Country, error)
+	FindByShortname(ctx context.Context, shortname string) (*domain.Country, error)
+	FindByID(ctx context.Context, id uint) (*domain.Country, error)
+	FindAll(ctx context.Context) ([]domain.Country, error)
+	FindEnabled(ctx context.Context) ([]domain.Country, error)
+	Create(ctx context.Context, country *domain.Country, rates []domain.ExchangeRate, event *domain.AuditEvent) error
+	Update(ctx context.Context, country *domain.Country, rates []domain.ExchangeRate, event *domain.AuditEvent) error
+	Import(ctx context.Context, countries []domain.Country, rates []domain.ExchangeRate, events []domain.AuditEvent) error
 }
 
 // countryRepository implements CountryRepository
@@ -24,9 +29,9 @@ func NewCountryRepository(db *gorm.DB) CountryRepository {
 }
 
 // FindByShortname retrieves country by shortname (e.g., "US", "UK")
-func (r *countryRepository) FindByShortname(shortname string) (*domain.Country, error) {
+func (r *countryRepository) FindByShortname(ctx context.Context, shortname string) (*domain.Country, error) {
 	var country domain.Country
-	err := r.db.Where("shortname = ?", shortname).First(&country).Error
+	err := r.db.WithContext(ctx).Where("shortname = ?", shortname).First(&country).Error
 	if err != nil {
 		return nil, err
 	}
@@ -34,18 +39,80 @@ func (r *countryRepository) FindByShortname(shortname string) (*domain.Country,
 }
 
 // FindByID retrieves country by primary key ID
-func (r *countryRepository) FindByID(id uint) (*domain.Country, error) {
+func (r *countryRepository) FindByID(ctx context.Context, id uint) (*domain.Country, error) {
 	var country domain.Country
-	err := r.db.First(&country, id).Error
+	err := r.db.WithContext(ctx).First(&country, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &country, nil
 }
 
-// FindAll retrieves all countries
-func (r *countryRepository) FindAll() ([]domain.Country, error) {
+// FindAll retrieves all countries, disabled ones included, ordered by
+// shortname so the list is stable
+func (r *countryRepository) FindAll(ctx context.Context) ([]domain.Country, error) {
 	var countries []domain.Country
-	err := r.db.Find(&countries).Error
+	err := r.db.WithContext(ctx).Order("shortname").Find(&countries).Error
 	return countries, err
 }
+
+// FindEnabled retrieves the countries offered to clients, ordered by shortname
+func (r *countryRepository) FindEnabled(ctx context.Context) ([]domain.Country, error) {
+	var countries []domain.Country
+	err := r.db.WithContext(ctx).Where("disabled_at IS NULL").Order("shortname").Find(&countries).Error
+	return countries, err
+}
+
+// Create inserts a country and records the exchange rates it sets and the
+// audit event in the same transaction
+func (r *countryRepository) Create(ctx context.Context, country *domain.Country, rates []domain.ExchangeRate, event *domain.AuditEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(country).Error; err != nil {
+			return err
+		}
+		if err := recordRates(tx, rates); err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+// Update saves every column of a country and records the exchange rates it
+// sets and the audit event in the same transaction
+func (r *countryRepository) Update(ctx context.Context, country *domain.Country, rates []domain.ExchangeRate, event *domain.AuditEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(country).Error; err != nil {
+			return err
+		}
+		if err := recordRates(tx, rates); err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+// Import creates the countries without an ID, saves the others and records
+// the exchange rates and audit events, all in one transaction so a failed
+// import changes nothing
+func (r *countryRepository) Import(ctx context.Context, countries []domain.Country, rates []domain.ExchangeRate, events []domain.AuditEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range countries {
+			var err error
+			if countries[i].ID == 0 {
+				err = tx.Create(&countries[i]).Error
+			} else {
+				err = tx.Save(&countries[i]).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err := recordRates(tx, rates); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return tx.Create(&events).Error
+	})
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"go-booking-system/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExchangeRateRepository defines data access methods for the exchange rate history
+type ExchangeRateRepository interface {
+	Latest(ctx context.Context) ([]domain.ExchangeRate, error)
+	AsOf(ctx context.Context, at time.Time) ([]domain.ExchangeRate, error)
+	Record(ctx context.Context, rates []domain.ExchangeRate) error
+	Set(ctx context.Context, rate *domain.ExchangeRate, event *domain.AuditEvent) error
+}
+
+// exchangeRateRepository implements ExchangeRateRepository
+type exchangeRateRepository struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateRepository creates a new exchange rate repository instance
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &exchangeRateRepository{db: db}
+}
+
+// Latest returns the newest rate of every currency
+func (r *exchangeRateRepository) Latest(ctx context.Context) ([]domain.ExchangeRate, error) {
+	var rates []domain.ExchangeRate
+	err := r.db.WithContext(ctx).
+		Select("DISTINCT ON (currency_code) *").
+		Order("currency_code, effective_at DESC").
+		Find(&rates).Error
+	return rates, err
+}
+
+// AsOf returns, for every currency, the rate that was in effect at the given
+// time; currencies without a rate by then are left out
+func (r *exchangeRateRepository) AsOf(ctx context.Context, at time.Time) ([]domain.ExchangeRate, error) {
+	var rates []domain.ExchangeRate
+	err := r.db.WithContext(ctx).
+		Select("DISTINCT ON (currency_code) *").
+		Where("effective_at <= ?", at).
+		Order("currency_code, effective_at DESC").
+		Find(&rates).Error
+	return rates, err
+}
+
+// Record appends rates to the history and copies them to the countries using
+// each currency, in one transaction
+func (r *exchangeRateRepository) Record(ctx context.Context, rates []domain.ExchangeRate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return recordRates(tx, rates)
+	})
+}
+
+// Set records a rate an admin chose and the audit event in one transaction
+func (r *exchangeRateRepository) Set(ctx context.Context, rate *domain.ExchangeRate, event *domain.AuditEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := recordRates(tx, []domain.ExchangeRate{*rate}); err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+// recordRates appends rates to the history and copies them to the
+// currency_rate column of the countries using each currency. A rate already
+// recorded for the same currency and time is skipped.
+func recordRates(tx *gorm.DB, rates []domain.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rates).Error; err != nil {
+		return err
+	}
+	for _, rate := range rates {
+		err := tx.Model(&domain.Country{}).
+			Where("upper(currency_code) = ?", rate.CurrencyCode).
+			Update("currency_rate", rate.Rate).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrSubjectLinked is returned by Create when the provider's subject is
+	// already linked, possibly to another user
+	ErrSubjectLinked = errors.New("provider subject already linked")
+	// ErrProviderLinked is returned by Create when the user already has an
+	// identity of the provider
+	ErrProviderLinked = errors.New("user already linked to the provider")
+)
+
+// uniqueViolation is the Postgres error code for a duplicate key
+const uniqueViolation = "23505"
+
+// IdentityRepository defines data access methods for linked identities and
+// the short-lived state of sign-ins in progress
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *domain.LinkedIdentity) error
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*domain.LinkedIdentity, error)
+	FindAllForUser(ctx context.Context, userID uint) ([]domain.LinkedIdentity, error)
+	MarkUsed(ctx context.Context, id uint, at time.Time) error
+	DeleteAllForUser(ctx context.Context, userID uint) error
+	CreateState(ctx context.Context, state *domain.OAuthState) error
+	ConsumeState(ctx context.Context, provider, stateHash string, at time.Time) (*domain.OAuthState, error)
+	DeleteExpiredStates(ctx context.Context, before time.Time) (int64, error)
+}
+
+// identityRepository implements IdentityRepository
+type identityRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityRepository creates a new identity repository instance
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+// Create links an identity to a user. A duplicate subject or provider is
+// reported as ErrSubjectLinked or ErrProviderLinked.
+func (r *identityRepository) Create(ctx context.Context, identity *domain.LinkedIdentity) error {
+	err := r.db.WithContext(ctx).Create(identity).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		switch pgErr.ConstraintName {
+		case "idx_linked_identities_provider_subject":
+			return ErrSubjectLinked
+		case "idx_linked_identities_user_provider":
+			return ErrProviderLinked
+		}
+	}
+	return err
+}
+
+// FindByProviderSubject retrieves the identity a provider knows by subject
+func (r *identityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*domain.LinkedIdentity, error) {
+	var identity domain.LinkedIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// FindAllForUser retrieves every identity linked to a user
+func (r *identityRepository) FindAllForUser(ctx context.Context, userID uint) ([]domain.LinkedIdentity, error) {
+	var identities []domain.LinkedIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+// MarkUsed records a sign-in through the identity
+func (r *identityRepository) MarkUsed(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.LinkedIdentity{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+// DeleteAllForUser unlinks every identity of a user
+func (r *identityRepository) DeleteAllForUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.LinkedIdentity{}).Error
+}
+
+// CreateState stores the state of a sign-in that was just started
+func (r *identityRepository) CreateState(ctx context.Context, state *domain.OAuthState) error {
+	return r.db.WithContext(ctx).Create(state).Error
+}
+
+// ConsumeState marks an unexpired state as used and returns it. It succeeds at
+// most once per state, so a callback cannot be replayed; otherwise it
+// returns gorm.ErrRecordNotFound.
+func (r *identityRepository) ConsumeState(ctx context.Context, provider, stateHash string, at time.Time) (*domain.OAuthState, error) {
+	var states []domain.OAuthState
+	err := r.db.WithContext(ctx).Model(&states).
+		Clauses(clause.Returning{}).
+		Where("provider = ? AND state_hash = ? AND consumed_at IS NULL AND expires_at > ?", provider, stateHash, at).
+		Update("consumed_at", at).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &states[0], nil
+}
+
+// DeleteExpiredStates removes states that can no longer be used
+func (r *identityRepository) DeleteExpiredStates(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&domain.OAuthState{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptRepository stores failed sign-in counters
+type LoginAttemptRepository interface {
+	Find(ctx context.Context, key string) (*domain.LoginAttempt, error)
+	RecordFailure(ctx context.Context, key string, now, windowStart time.Time) (*domain.LoginAttempt, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	PurgeExpired(ctx context.Context, now, windowStart time.Time) (int64, error)
+}
+
+// loginAttemptRepository implements LoginAttemptRepository on Postgres, so
+// counters are shared by every replica
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository creates a new Postgres-backed login attempt repository
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+// Find retrieves the counter for a key, or nil if there have been no failures
+func (r *loginAttemptRepository) Find(ctx context.Context, key string) (*domain.LoginAttempt, error) {
+	var attempt domain.LoginAttempt
+	err := r.db.WithContext(ctx).Where("key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// RecordFailure atomically increments the counter. Failures older than
+// windowStart are forgotten and counting starts again from one.
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, key string, now, windowStart time.Time) (*domain.LoginAttempt, error) {
+	attempt := domain.LoginAttempt{Key: key, Failures: 1, LastFailedAt: now}
+	err := r.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures": gorm.Expr(
+					"CASE WHEN login_attempts.last_failed_at < ? THEN 1 ELSE login_attempts.failures + 1 END",
+					windowStart,
+				),
+				"last_failed_at": now,
+			}),
+		},
+		clause.Returning{},
+	).Create(&attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// Lock locks the key out until the given time
+func (r *loginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.LoginAttempt{}).Where("key = ?", key).Update("locked_until", until).Error
+}
+
+// Reset forgets every failure recorded for the key
+func (r *loginAttemptRepository) Reset(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&domain.LoginAttempt{}).Error
+}
+
+// PurgeExpired deletes counters whose failures are older than windowStart and
+// that are no longer locked, and returns how many were deleted
+func (r *loginAttemptRepository) PurgeExpired(ctx context.Context, now, windowStart time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("last_failed_at < ? AND (locked_until IS NULL OR locked_until <= ?)", windowStart, now).
+		Delete(&domain.LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
+
+// memoryLoginAttemptMaxEntries bounds the map before stale entries are swept
+const memoryLoginAttemptMaxEntries = 10000
+
+// memoryLoginAttemptRepository implements LoginAttemptRepository in process
+// memory. Counters are per replica and lost on restart, so use it for a
+// single instance or local development.
+type memoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]domain.LoginAttempt
+}
+
+// NewMemoryLoginAttemptRepository creates a new in-memory login attempt repository
+func NewMemoryLoginAttemptRepository() LoginAttemptRepository {
+	return &memoryLoginAttemptRepository{attempts: make(map[string]domain.LoginAttempt)}
+}
+
+// Find retrieves the counter for a key, or nil if there have been no failures
+func (r *memoryLoginAttemptRepository) Find(ctx context.Context, key string) (*domain.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+// RecordFailure increments the counter, restarting it if the last failure is older than windowStart
+func (r *memoryLoginAttemptRepository) RecordFailure(ctx context.Context, key string, now, windowStart time.Time) (*domain.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.attempts) >= memoryLoginAttemptMaxEntries {
+		r.sweep(now, windowStart)
+	}
+
+	attempt, ok := r.attempts[key]
+	if !ok || attempt.LastFailedAt.Before(windowStart) {
+		attempt = domain.LoginAttempt{Key: key, LockedUntil: attempt.LockedUntil}
+	}
+	attempt.Failures++
+	attempt.LastFailedAt = now
+	r.attempts[key] = attempt
+
+	return &attempt, nil
+}
+
+// Lock locks the key out until the given time
+func (r *memoryLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt, ok := r.attempts[key]; ok {
+		attempt.LockedUntil = &until
+		r.attempts[key] = attempt
+	}
+	return nil
+}
+
+// Reset forgets every failure recorded for the key
+func (r *memoryLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+// PurgeExpired deletes counters that are outside the window and no longer locked
+func (r *memoryLoginAttemptRepository) PurgeExpired(ctx context.Context, now, windowStart time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sweep(now, windowStart), nil
+}
+
+// sweep drops counters that are outside the window and no longer locked
+func (r *memoryLoginAttemptRepository) sweep(now, windowStart time.Time) int64 {
+	var deleted int64
+	for key, attempt := range r.attempts {
+		if attempt.LastFailedAt.Before(windowStart) && !attempt.IsLocked(now) {
+			delete(r.attempts, key)
+			deleted++
+		}
+	}
+	return deleted
+}
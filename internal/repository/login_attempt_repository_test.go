@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLoginAttemptPurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryLoginAttemptRepository()
+	now := time.Now()
+	windowStart := now.Add(-15 * time.Minute)
+
+	for key, lastFailed := range map[string]time.Time{
+		"stale":        now.Add(-time.Hour),
+		"stale-locked": now.Add(-time.Hour),
+		"recent":       now.Add(-time.Minute),
+	} {
+		if _, err := repo.RecordFailure(ctx, key, lastFailed, lastFailed.Add(-15*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Lock(ctx, "stale-locked", now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := repo.PurgeExpired(ctx, now, windowStart)
+	if err != nil || deleted != 1 {
+		t.Fatalf("PurgeExpired = %d, %v; want 1", deleted, err)
+	}
+
+	for key, wantKept := range map[string]bool{"stale": false, "stale-locked": true, "recent": true} {
+		attempt, err := repo.Find(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kept := attempt != nil; kept != wantKept {
+			t.Errorf("%s kept = %v, want %v", key, kept, wantKept)
+		}
+	}
+
+	// Once the lock runs out there is nothing left to keep it for
+	deleted, err = repo.PurgeExpired(ctx, now.Add(2*time.Minute), windowStart)
+	if err != nil || deleted != 1 {
+		t.Errorf("PurgeExpired after unlock = %d, %v; want 1", deleted, err)
+	}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"go-booking-system/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OneTimeTokenRepository defines data access methods for single-use tokens
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, token *domain.OneTimeToken) error
+	FindByHash(ctx context.Context, purpose, tokenHash string) (*domain.OneTimeToken, error)
+	FindLatest(ctx context.Context, userID uint, purpose string) (*domain.OneTimeToken, error)
+	Consume(ctx context.Context, id uint, at time.Time) (bool, error)
+	RecordFailedAttempt(ctx context.Context, id uint) error
+	ConsumeAllForUser(ctx context.Context, userID uint, purpose string) error
+	FindAllForUser(ctx context.Context, userID uint) ([]domain.OneTimeToken, error)
+	DeleteAllForUser(ctx context.Context, userID uint) error
+}
+
+// oneTimeTokenRepository implements OneTimeTokenRepository
+type oneTimeTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewOneTimeTokenRepository creates a new one-time token repository instance
+func NewOneTimeTokenRepository(db *gorm.DB) OneTimeTokenRepository {
+	return &oneTimeTokenRepository{db: db}
+}
+
+// Create inserts a new token into database
+func (r *oneTimeTokenRepository) Create(ctx context.Context, token *domain.OneTimeToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindByHash retrieves a token by purpose and hash
+func (r *oneTimeTokenRepository) FindByHash(ctx context.Context, purpose, tokenHash string) (*domain.OneTimeToken, error) {
+	var token domain.OneTimeToken
+	err := r.db.WithContext(ctx).Where("purpose = ? AND token_hash = ?", purpose, tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindLatest retrieves the most recently issued token of a purpose for a user
+func (r *oneTimeTokenRepository) FindLatest(ctx context.Context, userID uint, purpose string) (*domain.OneTimeToken, error) {
+	var token domain.OneTimeToken
+	err := r.db.WithContext(ctx).Where("user_id = ? AND purpose = ?", userID, purpose).
+		Order("created_at DESC").
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Consume marks a token as used. It only succeeds once per token, so
+// concurrent requests cannot both redeem it.
+func (r *oneTimeTokenRepository) Consume(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.OneTimeToken{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RecordFailedAttempt counts a wrong guess at the token's code
+func (r *oneTimeTokenRepository) RecordFailedAttempt(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&domain.OneTimeToken{}).
+		Where("id = ?", id).
+		Update("failed_attempts", gorm.Expr("failed_attempts + 1")).Error
+}
+
+// ConsumeAllForUser invalidates every outstanding token of a purpose for a user
+func (r *oneTimeTokenRepository) ConsumeAllForUser(ctx context.Context, userID uint, purpose string) error {
+	return r.db.WithContext(ctx).Model(&domain.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", userID, purpose).
+		Update("consumed_at", time.Now()).Error
+}
+
+// FindAllForUser retrieves every token issued to a user, newest first
+func (r *oneTimeTokenRepository) FindAllForUser(ctx context.Context, userID uint) ([]domain.OneTimeToken, error) {
+	var tokens []domain.OneTimeToken
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// DeleteAllForUser permanently removes every token issued to a user
+func (r *oneTimeTokenRepository) DeleteAllForUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.OneTimeToken{}).Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"go-booking-system/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository defines data access methods for two-factor recovery codes
+type RecoveryCodeRepository interface {
+	ReplaceForUser(ctx context.Context, userID uint, codeHashes []string) error
+	Consume(ctx context.Context, userID uint, codeHash string, at time.Time) (bool, error)
+	CountUnused(ctx context.Context, userID uint) (int64, error)
+	DeleteAllForUser(ctx context.Context, userID uint) error
+}
+
+// recoveryCodeRepository implements RecoveryCodeRepository
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository creates a new recovery code repository instance
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// ReplaceForUser discards the user's codes and stores a new set in one transaction
+func (r *recoveryCodeRepository) ReplaceForUser(ctx context.Context, userID uint, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]domain.RecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = domain.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume marks a matching unused code as used; it succeeds at most once per code
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID uint, codeHash string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnused counts the codes the user can still redeem
+func (r *recoveryCodeRepository) CountUnused(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteAllForUser removes every recovery code of a user
+func (r *recoveryCodeRepository) DeleteAllForUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationRepository defines data access methods for revoked access tokens
+type RevocationRepository interface {
+	RevokeToken(ctx context.Context, token *domain.RevokedToken) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeUserTokens(ctx context.Context, userUUID string, before, expiresAt time.Time) error
+	FindUserRevocation(ctx context.Context, userUUID string) (*domain.UserRevocation, error)
+	PurgeExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// revocationRepository implements RevocationRepository
+type revocationRepository struct {
+	db *gorm.DB
+}
+
+// NewRevocationRepository creates a new revocation repository instance
+func NewRevocationRepository(db *gorm.DB) RevocationRepository {
+	return &revocationRepository{db: db}
+}
+
+// RevokeToken records a revoked jti; revoking the same token twice is a no-op
+func (r *revocationRepository) RevokeToken(ctx context.Context, token *domain.RevokedToken) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+// IsTokenRevoked checks whether a jti has been revoked
+func (r *revocationRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeUserTokens revokes every token issued to the user at or before the
+// given time. expiresAt is when the last of those tokens expires.
+func (r *revocationRepository) RevokeUserTokens(ctx context.Context, userUUID string, before, expiresAt time.Time) error {
+	revocation := &domain.UserRevocation{UserUUID: userUUID, RevokedBefore: before, ExpiresAt: expiresAt}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_uuid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "expires_at", "updated_at"}),
+	}).Create(revocation).Error
+}
+
+// FindUserRevocation retrieves the user-wide revocation, or nil if there is none
+func (r *revocationRepository) FindUserRevocation(ctx context.Context, userUUID string) (*domain.UserRevocation, error) {
+	var revocation domain.UserRevocation
+	err := r.db.WithContext(ctx).Where("user_uuid = ?", userUUID).First(&revocation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &revocation, nil
+}
+
+// PurgeExpired deletes revocations of tokens that have expired by now, in one
+// transaction, and returns how many rows were deleted
+func (r *revocationRepository) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("expires_at < ?", now).Delete(&domain.RevokedToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+
+		result = tx.Where("expires_at < ?", now).Delete(&domain.UserRevocation{})
+		deleted += result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
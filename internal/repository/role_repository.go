@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLastRoleHolder is returned by RevokeUnlessLast when the user is the only
+// active user left with the role
+var ErrLastRoleHolder = errors.New("last active user with the role")
+
+// RoleRepository defines data access methods for granted user roles
+type RoleRepository interface {
+	Grant(ctx context.Context, userID uint, role domain.Role, event *domain.AuditEvent) (bool, error)
+	Revoke(ctx context.Context, userID uint, role domain.Role, event *domain.AuditEvent) (bool, error)
+	RevokeUnlessLast(ctx context.Context, userID uint, role domain.Role, event *domain.AuditEvent) (bool, error)
+	DeleteAllForUser(ctx context.Context, userID uint) error
+}
+
+// roleRepository implements RoleRepository
+type roleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new role repository instance
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// Grant gives the user a role and records the audit event in the same
+// transaction. It returns false, and records nothing, if the user already
+// had the role.
+func (r *roleRepository) Grant(ctx context.Context, userID uint, role domain.Role, event *domain.AuditEvent) (bool, error) {
+	granted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&domain.UserRole{UserID: userID, Role: role})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		granted = true
+		return tx.Create(event).Error
+	})
+	return granted, err
+}
+
+// Revoke takes a role away from the user and records the audit event in the
+// same transaction. It returns false, and records nothing, if the user did
+// not have the role.
+func (r *roleRepository) Revoke(ctx context.Context, userID uint, role domain.Role, event *domain.AuditEvent) (bool, error) {
+	revoked := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND role = ?", userID, role).Delete(&domain.UserRole{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		revoked = true
+		return tx.Create(event).Error
+	})
+	return revoked, err
+}
+
+// RevokeUnlessLast is Revoke, except that it fails with ErrLastRoleHolder
+// instead of taking the role from its last active (not deleted) holder. The
+// holders are locked while counting, so concurrent revocations cannot both
+// pass the check.
+func (r *roleRepository) RevokeUnlessLast(ctx context.Context, userID uint, role domain.Role, event *domain.AuditEvent) (bool, error) {
+	revoked := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		holders, err := lockRoleHolders(tx, role)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(holders, userID) {
+			return nil
+		}
+		if len(holders) <= 1 {
+			return ErrLastRoleHolder
+		}
+
+		if err := tx.Where("user_id = ? AND role = ?", userID, role).Delete(&domain.UserRole{}).Error; err != nil {
+			return err
+		}
+
+		revoked = true
+		return tx.Create(event).Error
+	})
+	return revoked, err
+}
+
+// DeleteAllForUser removes every role granted to a user. It is not audited;
+// it only runs when the account itself is erased.
+func (r *roleRepository) DeleteAllForUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.UserRole{}).Error
+}
+
+// lockRoleHolders returns the active (not deleted) users holding a role,
+// locking their grants until the transaction ends
+func lockRoleHolders(tx *gorm.DB, role domain.Role) ([]uint, error) {
+	var holders []uint
+	err := tx.Model(&domain.UserRole{}).
+		Joins("JOIN users ON users.id = user_roles.user_id AND users.deleted_at IS NULL").
+		Where("user_roles.role = ?", role).
+		Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Table: clause.Table{Name: "user_roles"}}).
+		Pluck("user_roles.user_id", &holders).Error
+	return holders, err
+}
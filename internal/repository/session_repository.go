@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"go-booking-system/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository defines data access methods for refresh token sessions
+type SessionRepository interface {
+	Create(ctx context.Context, session *domain.Session) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error)
+	MarkRotated(ctx context.Context, id uint, at time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userUUID string) error
+	FindAllForUser(ctx context.Context, userUUID string) ([]domain.Session, error)
+	DeleteAllForUser(ctx context.Context, userUUID string) error
+}
+
+// sessionRepository implements SessionRepository
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new session repository instance
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create inserts a new session into database
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+// FindByTokenHash retrieves a session by the hash of its refresh token
+func (r *sessionRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error) {
+	var session domain.Session
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// MarkRotated flags a session as used. It only succeeds for a session that is
+// still active, so two concurrent refreshes with the same token cannot both win.
+func (r *sessionRepository) MarkRotated(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.Session{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Update("rotated_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily revokes every session descending from the same sign-in
+func (r *sessionRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).Model(&domain.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser revokes every active session belonging to a user
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userUUID string) error {
+	return r.db.WithContext(ctx).Model(&domain.Session{}).
+		Where("user_uuid = ? AND revoked_at IS NULL", userUUID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// FindAllForUser retrieves every session of a user, newest first
+func (r *sessionRepository) FindAllForUser(ctx context.Context, userUUID string) ([]domain.Session, error) {
+	var sessions []domain.Session
+	err := r.db.WithContext(ctx).Where("user_uuid = ?", userUUID).Order("created_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// DeleteAllForUser permanently removes every session of a user
+func (r *sessionRepository) DeleteAllForUser(ctx context.Context, userUUID string) error {
+	return r.db.WithContext(ctx).Where("user_uuid = ?", userUUID).Delete(&domain.Session{}).Error
+}
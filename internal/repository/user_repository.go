@@ -1,19 +1,29 @@
 package repository
 
 import (
+	"context"
 	"go-booking-system/internal/domain"
+	"slices"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository defines data access methods for User
 type UserRepository interface {
-	Create(user *domain.User) error
-	FindByEmail(email string) (*domain.User, error)
-	FindByID(id uint) (*domain.User, error)
-	FindByUUID(uuid string) (*domain.User, error)
-	Update(user *domain.User) error
-	Delete(id uint) error
+	Create(ctx context.Context, user *domain.User) error
+	FindByEmail(ctx context.Context, email string) (*domain.User, error)
+	FindByEmailIncludingDeleted(ctx context.Context, email string) (*domain.User, error)
+	FindByID(ctx context.Context, id uint) (*domain.User, error)
+	FindByUUID(ctx context.Context, uuid string) (*domain.User, error)
+	Update(ctx context.Context, user *domain.User) error
+	Delete(ctx context.Context, id uint) error
+	DeleteUnlessLastRoleHolder(ctx context.Context, id uint, role domain.Role) error
+	FindDeletedForAnonymization(ctx context.Context, deletedBefore time.Time, limit int) ([]domain.User, error)
+	SaveDeleted(ctx context.Context, user *domain.User) error
+	AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error)
+	FindByVerifiedPhone(ctx context.Context, phone string) (*domain.User, error)
 }
 
 // userRepository implements UserRepository
@@ -27,14 +37,25 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 // Create inserts a new user into database
-func (r *userRepository) Create(user *domain.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
 // FindByEmail retrieves user by email address
-func (r *userRepository) FindByEmail(email string) (*domain.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Preload("Roles").Where("email = ?", email).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByEmailIncludingDeleted retrieves user by email address, including
+// soft-deleted accounts that still hold the address
+func (r *userRepository) FindByEmailIncludingDeleted(ctx context.Context, email string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Unscoped().Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -42,9 +63,9 @@ func (r *userRepository) FindByEmail(email string) (*domain.User, error) {
 }
 
 // FindByID retrieves user by primary key ID
-func (r *userRepository) FindByID(id uint) (*domain.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*domain.User, error) {
 	var user domain.User
-	err := r.db.First(&user, id).Error
+	err := r.db.WithContext(ctx).Preload("Roles").First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -52,21 +73,79 @@ func (r *userRepository) FindByID(id uint) (*domain.User, error) {
 }
 
 // FindByUUID retrieves user by UUID
-func (r *userRepository) FindByUUID(uuid string) (*domain.User, error) {
+func (r *userRepository) FindByUUID(ctx context.Context, uuid string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.Where("uuid = ?", uuid).First(&user).Error
+	err := r.db.WithContext(ctx).Preload("Roles").Where("uuid = ?", uuid).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-// Update saves user changes to database
-func (r *userRepository) Update(user *domain.User) error {
-	return r.db.Save(user).Error
+// FindByVerifiedPhone retrieves the user who verified an E.164 phone number
+func (r *userRepository) FindByVerifiedPhone(ctx context.Context, phone string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Preload("Roles").
+		Where("phone = ? AND phone_verified_at IS NOT NULL", phone).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Update saves user changes to database. Roles are left alone; they change
+// only through RoleRepository.
+func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(user).Error
 }
 
 // Delete soft deletes a user by ID
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&domain.User{}, id).Error
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&domain.User{}, id).Error
+}
+
+// DeleteUnlessLastRoleHolder is Delete, except that it fails with
+// ErrLastRoleHolder instead of deleting the last active holder of the role.
+// The holders are locked while counting, as in RevokeUnlessLast.
+func (r *userRepository) DeleteUnlessLastRoleHolder(ctx context.Context, id uint, role domain.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		holders, err := lockRoleHolders(tx, role)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(holders, id) && len(holders) <= 1 {
+			return ErrLastRoleHolder
+		}
+		return tx.Delete(&domain.User{}, id).Error
+	})
+}
+
+// FindDeletedForAnonymization retrieves soft-deleted users whose grace period
+// has ended and whose personal data has not been scrubbed yet
+func (r *userRepository) FindDeletedForAnonymization(ctx context.Context, deletedBefore time.Time, limit int) ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND anonymized_at IS NULL", deletedBefore).
+		Order("deleted_at").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// SaveDeleted saves changes to a soft-deleted user
+func (r *userRepository) SaveDeleted(ctx context.Context, user *domain.User) error {
+	return r.db.WithContext(ctx).Unscoped().Omit(clause.Associations).Save(user).Error
+}
+
+// AdvanceTOTPStep records the time step of an accepted TOTP code. It fails if
+// that step (or a later one) was already used, so a code cannot be replayed.
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
@@ -1,35 +1,146 @@
 package routes
 
 import (
+	"go-booking-system/internal/domain"
 	"go-booking-system/internal/handler"
 	"go-booking-system/internal/middleware"
+	"go-booking-system/internal/service"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Timeouts holds the request deadline of each route group; zero means none
+type Timeouts struct {
+	Health  time.Duration // health checks and discovery documents
+	Account time.Duration // account routes and public reference data
+	Admin   time.Duration // admin routes, which may scan larger tables
+}
+
 // SetupRoutes configures all application routes with handler dependencies
 func SetupRoutes(
 	router *gin.Engine,
 	accountHandler *handler.AccountHandler,
+	adminHandler *handler.AdminHandler,
+	countryHandler *handler.CountryHandler,
+	currencyHandler *handler.CurrencyHandler,
 	healthHandler *handler.HealthHandler,
+	wellKnownHandler *handler.WellKnownHandler,
+	tokenService service.TokenService,
+	timeouts Timeouts,
 ) {
+	// Tag requests with an ID and render errors handlers attach with c.Error
+	router.Use(middleware.RequestID(), middleware.ErrorHandler())
+
+	// Public discovery documents
+	router.GET("/.well-known/jwks.json", middleware.Timeout(timeouts.Health), wellKnownHandler.JWKS)
+
 	// Health check routes
 	health := router.Group("/api/health")
+	health.Use(middleware.Timeout(timeouts.Health))
 	{
+		health.GET("/live", healthHandler.Live)
+		health.GET("/ready", healthHandler.Ready)
+		// Kept for probes configured before the split
 		health.GET("/", healthHandler.HealthStatus)
 	}
 
+	// Country routes (public reference data, cached by clients)
+	countries := router.Group("/api/countries")
+	countries.Use(middleware.Timeout(timeouts.Account))
+	{
+		countries.GET("", countryHandler.ListCountries)
+		countries.GET("/:shortname", countryHandler.GetCountry)
+	}
+
+	// Currency routes (public)
+	currency := router.Group("/api/currency")
+	currency.Use(middleware.Timeout(timeouts.Account))
+	{
+		currency.GET("/convert", currencyHandler.Convert)
+	}
+
 	// Account routes (public - no authentication required)
 	account := router.Group("/api/account")
+	account.Use(middleware.Timeout(timeouts.Account))
 	{
 		account.POST("/signup", accountHandler.SignUp)
 		account.POST("/signin", accountHandler.SignIn)
+		account.POST("/signin/magic-link", accountHandler.RequestMagicLink)
+		account.POST("/signin/magic-link/verify", accountHandler.SignInWithMagicLink)
+		account.POST("/signin/phone", accountHandler.RequestPhoneCode)
+		account.POST("/signin/phone/verify", accountHandler.SignInWithPhoneCode)
+		account.POST("/token/refresh", accountHandler.RefreshToken)
+		account.POST("/verify-email", accountHandler.VerifyEmail)
+		account.POST("/password/forgot", accountHandler.ForgotPassword)
+		account.POST("/password/reset", accountHandler.ResetPassword)
+		account.POST("/email/confirm", accountHandler.ConfirmEmailChange)
+		account.POST("/2fa/verify", accountHandler.VerifyTwoFactor)
+		account.GET("/oauth/:provider/authorize", accountHandler.AuthorizeSocial)
+		account.POST("/oauth/:provider/callback", accountHandler.SocialCallback)
 	}
 
 	// Protected routes (require JWT authentication)
+	// These stay open to unverified accounts: they are needed to get the
+	// address verified (resend, fix a mistyped address), to secure or leave
+	// the account (password, 2FA, logout, export, delete), or only read
 	protected := router.Group("/api/account")
-	protected.Use(middleware.RequireAuth()) // Apply JWT verification middleware
+	protected.Use(middleware.Timeout(timeouts.Account))
+	protected.Use(middleware.RequireAuth(tokenService)) // Apply JWT verification middleware
 	{
 		protected.GET("/profile", accountHandler.GetProfile)
+		protected.POST("/password", accountHandler.ChangePassword)
+		protected.POST("/email", accountHandler.RequestEmailChange)
+		protected.DELETE("", accountHandler.DeleteAccount)
+		protected.GET("/export", accountHandler.ExportAccount)
+		protected.POST("/logout", accountHandler.Logout)
+		protected.POST("/logout-all", accountHandler.LogoutAll)
+		protected.POST("/verify-email/resend", accountHandler.ResendVerificationEmail)
+		protected.POST("/2fa/setup", accountHandler.SetupTwoFactor)
+		protected.POST("/2fa/confirm", accountHandler.ConfirmTwoFactor)
+		protected.POST("/2fa/disable", accountHandler.DisableTwoFactor)
+		protected.POST("/2fa/recovery-codes", accountHandler.RegenerateRecoveryCodes)
+	}
+
+	// Profile changes need a confirmed email address, so an account someone
+	// signed up with another person's address cannot be built on. Booking
+	// routes belong here too; role- or permission-gated routes add
+	// middleware.RequireRole/RequirePermission instead
+	verified := protected.Group("")
+	verified.Use(middleware.RequireVerifiedEmail())
+	{
+		verified.PATCH("/profile", accountHandler.UpdateProfile)
+		verified.POST("/phone/verify/send", accountHandler.RequestPhoneVerification)
+		verified.POST("/phone/verify", accountHandler.VerifyPhone)
+	}
+
+	// Admin routes (require JWT authentication and the matching permission)
+	admin := router.Group("/api/admin")
+	admin.Use(middleware.Timeout(timeouts.Admin))
+	admin.Use(middleware.RequireAuth(tokenService))
+
+	roles := admin.Group("")
+	roles.Use(middleware.RequirePermission(domain.PermissionRolesManage))
+	{
+		roles.GET("/users/:uuid/roles", adminHandler.GetUserRoles)
+		roles.POST("/users/:uuid/roles", adminHandler.GrantRole)
+		roles.DELETE("/users/:uuid/roles/:role", adminHandler.RevokeRole)
+		roles.GET("/audit-events", adminHandler.ListAuditEvents)
+	}
+
+	adminCountries := admin.Group("/countries")
+	adminCountries.Use(middleware.RequirePermission(domain.PermissionCountriesManage))
+	{
+		adminCountries.GET("", adminHandler.ListCountries)
+		adminCountries.POST("", adminHandler.CreateCountry)
+		adminCountries.PUT("/:shortname", adminHandler.ReplaceCountry)
+		adminCountries.DELETE("/:shortname", adminHandler.DisableCountry)
+	}
+
+	// Exchange rates are country reference data too
+	adminCurrency := admin.Group("/currency")
+	adminCurrency.Use(middleware.RequirePermission(domain.PermissionCountriesManage))
+	{
+		adminCurrency.PUT("/rates/:code", adminHandler.SetExchangeRate)
 	}
 }
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/mailer"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// passwordResetTTL is how long a password reset link stays valid
+	passwordResetTTL = time.Minute * 30
+	// passwordResetCooldown limits how often reset emails go to one account
+	passwordResetCooldown = time.Minute
+)
+
+// ForgotPassword emails a reset link if the address belongs to an account.
+// It never reports whether the account exists; the lookup and delivery run in
+// the background so response time does not reveal it either.
+func (s *accountService) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) {
+	s.runInBackground(ctx, "send password reset email", func(ctx context.Context) error {
+		return s.sendPasswordResetEmail(ctx, req.Email)
+	})
+}
+
+// ResetPassword consumes a reset token, sets the new password and signs the
+// user out of every device
+func (s *accountService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error {
+	token, err := s.consumeOneTimeToken(ctx, domain.TokenPurposePasswordReset, req.Token)
+	if err != nil {
+		if errors.Is(err, errInvalidOneTimeToken) {
+			return ErrInvalidResetToken
+		}
+		return apperror.Internal("failed to reset password", err)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return apperror.Internal("failed to find user", err)
+	}
+
+	// The token only vouches for the address it was sent to
+	if user.Email != token.Target {
+		return ErrInvalidResetToken
+	}
+
+	if err := user.HashPassword(req.Password); err != nil {
+		return apperror.Internal("failed to process password", err)
+	}
+
+	// Following the emailed link also proves the user owns the address
+	if !user.IsEmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return apperror.Internal("failed to reset password", err)
+	}
+
+	// Any other outstanding reset links are now stale
+	if err := s.tokenRepo.ConsumeAllForUser(ctx, user.ID, domain.TokenPurposePasswordReset); err != nil {
+		return apperror.Internal("failed to reset password", err)
+	}
+
+	// Whoever knew the old password must not stay signed in
+	if err := s.LogoutAll(ctx, user.UUID); err != nil {
+		return err
+	}
+
+	notice := mailer.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nThe password for your account was just reset and all devices were signed out.\n\nIf this was not you, reset your password again immediately and contact support.\n",
+			user.Name,
+		),
+	}
+	if err := s.mailer.Send(notice); err != nil {
+		slog.WarnContext(ctx, "failed to send password change notice", "user", user.UUID, "error", err)
+	}
+
+	return nil
+}
+
+// sendPasswordResetEmail mails a reset link to the account registered with email, if any
+func (s *accountService) sendPasswordResetEmail(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Throttle silently; the caller always gets the same answer
+	latest, err := s.tokenRepo.FindLatest(ctx, user.ID, domain.TokenPurposePasswordReset)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < passwordResetCooldown {
+		return nil
+	}
+
+	// Only the newest link should work
+	if err := s.tokenRepo.ConsumeAllForUser(ctx, user.ID, domain.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	rawToken, err := s.issueOneTimeToken(ctx, user.ID, domain.TokenPurposePasswordReset, user.Email, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.settings.AppBaseURL, url.QueryEscape(rawToken))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThe link expires in 30 minutes and can be used once. If you did not ask for this, you can ignore this email.\n",
+			user.Name, link,
+		),
+	})
+}
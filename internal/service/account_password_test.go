@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var resetLinkPattern = regexp.MustCompile(`/reset-password\?token=(\S+)`)
+
+// requestPasswordReset asks for a reset link and returns the token mailed to email
+func (f *accountFixture) requestPasswordReset(t *testing.T, email string) string {
+	t.Helper()
+
+	f.service.ForgotPassword(context.Background(), dto.ForgotPasswordRequest{Email: email})
+	if err := f.service.WaitForBackgroundWork(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, ok := f.mail.Last(email)
+	if !ok {
+		t.Fatalf("no email sent to %s", email)
+	}
+	match := resetLinkPattern.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("no reset link in email: %q", msg.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// prepare runs between mailing the link and using it, and returns
+		// the token to present
+		prepare func(t *testing.T, f *accountFixture, token string) string
+		wantErr error
+	}{
+		{
+			name:    "valid token",
+			prepare: func(t *testing.T, f *accountFixture, token string) string { return token },
+		},
+		{
+			name: "used token",
+			prepare: func(t *testing.T, f *accountFixture, token string) string {
+				err := f.service.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, Password: "first new password"})
+				if err != nil {
+					t.Fatalf("first reset: %v", err)
+				}
+				return token
+			},
+			wantErr: ErrInvalidResetToken,
+		},
+		{
+			name: "just before expiry",
+			prepare: func(t *testing.T, f *accountFixture, token string) string {
+				f.tokens.expire(domain.TokenPurposePasswordReset, time.Now().Add(time.Second))
+				return token
+			},
+		},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, f *accountFixture, token string) string {
+				f.tokens.expire(domain.TokenPurposePasswordReset, time.Now())
+				return token
+			},
+			wantErr: ErrInvalidResetToken,
+		},
+		{
+			name: "email changed since",
+			prepare: func(t *testing.T, f *accountFixture, token string) string {
+				user, err := f.users.FindByEmail(ctx, "guest@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+				user.Email = "new@example.com"
+				if err := f.users.Update(ctx, user); err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantErr: ErrInvalidResetToken,
+		},
+		{
+			name:    "unknown token",
+			prepare: func(t *testing.T, f *accountFixture, token string) string { return "not-a-token" },
+			wantErr: ErrInvalidResetToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			user := f.createUser(t, "guest@example.com", "old password")
+
+			token := tt.prepare(t, f, f.requestPasswordReset(t, user.Email))
+
+			err := f.service.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, Password: "new password"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResetPassword error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			updated, err := f.users.FindByID(ctx, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.CheckPassword("new password") != nil {
+				t.Error("new password does not work")
+			}
+			if !updated.IsEmailVerified() {
+				t.Error("following the link did not verify the email address")
+			}
+			if msg, _ := f.mail.Last(user.Email); msg.Subject != "Your password was changed" {
+				t.Errorf("last email = %q, want the change notice", msg.Subject)
+			}
+		})
+	}
+}
+
+func TestForgotPasswordLinkExpiresAfter30Minutes(t *testing.T) {
+	f := newAccountFixture(t)
+	user := f.createUser(t, "guest@example.com", "old password")
+
+	before := time.Now()
+	f.requestPasswordReset(t, user.Email)
+	after := time.Now()
+
+	token, err := f.tokens.FindLatest(context.Background(), user.ID, domain.TokenPurposePasswordReset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.ExpiresAt.Before(before.Add(30*time.Minute)) || token.ExpiresAt.After(after.Add(30*time.Minute)) {
+		t.Errorf("token expires %v after issue, want 30m", token.ExpiresAt.Sub(token.CreatedAt))
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	f := newAccountFixture(t)
+	f.createUser(t, "guest@example.com", "old password")
+
+	f.service.ForgotPassword(context.Background(), dto.ForgotPasswordRequest{Email: "nobody@example.com"})
+	if err := f.service.WaitForBackgroundWork(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if msgs := f.mail.Messages(); len(msgs) != 0 {
+		t.Errorf("sent %d emails for an unknown address, want none", len(msgs))
+	}
+	if len(f.tokens.tokens) != 0 {
+		t.Errorf("issued %d tokens for an unknown address, want none", len(f.tokens.tokens))
+	}
+}
+
+func TestResetPasswordSignsOutEverywhere(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+	user := f.createUser(t, "guest@example.com", "old password")
+
+	var signIns []*dto.SignIn_Success
+	for _, device := range []string{"phone", "laptop"} {
+		signIn, err := f.service.SignIn(ctx, dto.SignInRequest{Email: user.Email, Password: "old password", Device: device}, "192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		signIns = append(signIns, signIn)
+	}
+
+	token := f.requestPasswordReset(t, user.Email)
+	// Revocation has millisecond resolution, like iat
+	time.Sleep(2 * time.Millisecond)
+	if err := f.service.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, Password: "new password"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, signIn := range signIns {
+		if _, err := f.service.tokenService.ParseAccessToken(ctx, signIn.Token); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("access token error = %v, want token revoked", err)
+		}
+		if _, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{RefreshToken: signIn.RefreshToken}); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("refresh token error = %v, want invalid refresh token", err)
+		}
+	}
+
+	// The old password is gone, the new one signs in
+	if _, err := f.service.SignIn(ctx, dto.SignInRequest{Email: user.Email, Password: "old password"}, "192.0.2.1"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("old password error = %v, want invalid credentials", err)
+	}
+	if _, err := f.service.SignIn(ctx, dto.SignInRequest{Email: user.Email, Password: "new password"}, "192.0.2.1"); err != nil {
+		t.Errorf("new password: %v", err)
+	}
+}
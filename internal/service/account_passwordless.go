@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/mailer"
+	"log/slog"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// magicLinkTTL is how long an emailed sign-in link stays valid
+	magicLinkTTL = time.Minute * 15
+	// phoneCodeTTL is how long an SMS sign-in code stays valid
+	phoneCodeTTL = time.Minute * 10
+	// passwordlessCooldown limits how often links or codes go to one account
+	passwordlessCooldown = time.Minute
+)
+
+// RequestMagicLink emails a single-use sign-in link if the address belongs to
+// an account. Like ForgotPassword it never reveals whether the account exists.
+func (s *accountService) RequestMagicLink(ctx context.Context, req dto.MagicLinkRequest) {
+	s.runInBackground(ctx, "send magic link", func(ctx context.Context) error {
+		return s.sendMagicLink(ctx, req.Email)
+	})
+}
+
+// SignInWithMagicLink redeems a magic link token
+func (s *accountService) SignInWithMagicLink(ctx context.Context, req dto.MagicLinkVerifyRequest) (*dto.SignIn_Success, error) {
+	token, err := s.consumeOneTimeToken(ctx, domain.TokenPurposeMagicLink, req.Token)
+	if err != nil {
+		if errors.Is(err, errInvalidOneTimeToken) {
+			return nil, ErrInvalidSignInLink
+		}
+		return nil, apperror.Internal("failed to sign in", err)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSignInLink
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+
+	// The token only vouches for the address it was sent to
+	if user.Email != token.Target {
+		return nil, ErrInvalidSignInLink
+	}
+
+	// Following the emailed link also proves the user owns the address
+	if !user.IsEmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, apperror.Internal("failed to sign in", err)
+		}
+	}
+
+	return s.beginSession(ctx, user, req.Device)
+}
+
+// RequestPhoneCode texts a sign-in code to the number if an account has
+// verified it. Only malformed input is reported; whether the account exists is not.
+func (s *accountService) RequestPhoneCode(ctx context.Context, req dto.PhoneCodeRequest) error {
+	e164, err := s.normalizeRequestPhone(ctx, req.Phone, req.Country)
+	if err != nil {
+		return err
+	}
+
+	s.runInBackground(ctx, "send sign-in code", func(ctx context.Context) error {
+		return s.sendPhoneCode(ctx, e164)
+	})
+	return nil
+}
+
+// SignInWithPhoneCode checks an SMS code. Wrong codes count towards the same
+// lockout as wrong passwords, so the six digits cannot be brute-forced.
+func (s *accountService) SignInWithPhoneCode(ctx context.Context, req dto.PhoneCodeVerifyRequest, clientIP string) (*dto.SignIn_Success, error) {
+	e164, err := s.normalizeRequestPhone(ctx, req.Phone, req.Country)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.findUserByPhone(ctx, e164)
+	if err != nil {
+		return nil, apperror.Internal("failed to find user", err)
+	}
+
+	// Unknown numbers are throttled under the number itself
+	lockKey := e164
+	if user != nil {
+		lockKey = user.Email
+	}
+	if err := s.loginGuard.Check(ctx, lockKey, clientIP); err != nil {
+		var lockout *LockoutError
+		if errors.As(err, &lockout) {
+			return nil, lockout
+		}
+		return nil, apperror.Internal("failed to check sign-in attempts", err)
+	}
+
+	if user == nil {
+		return nil, s.failedPhoneSignIn(ctx, lockKey, clientIP)
+	}
+
+	ok, err := s.redeemShortCode(ctx, user.ID, domain.TokenPurposePhoneSignIn, e164, req.Code)
+	if err != nil {
+		return nil, apperror.Internal("failed to sign in", err)
+	}
+	if !ok {
+		return nil, s.failedPhoneSignIn(ctx, lockKey, clientIP)
+	}
+
+	return s.beginSession(ctx, user, req.Device)
+}
+
+// failedPhoneSignIn records a failed code attempt and returns the error for the caller
+func (s *accountService) failedPhoneSignIn(ctx context.Context, lockKey, clientIP string) error {
+	if err := s.loginGuard.RecordFailure(ctx, lockKey, clientIP); err != nil {
+		slog.ErrorContext(ctx, "failed to record sign-in attempt", "error", err)
+	}
+	return ErrInvalidSignInCode
+}
+
+// sendMagicLink mails a sign-in link to the account registered with email, if any
+func (s *accountService) sendMagicLink(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Throttle silently; the caller always gets the same answer
+	if recent, err := s.recentlyIssued(ctx, user.ID, domain.TokenPurposeMagicLink); err != nil || recent {
+		return err
+	}
+
+	// Only the newest link should work
+	if err := s.tokenRepo.ConsumeAllForUser(ctx, user.ID, domain.TokenPurposeMagicLink); err != nil {
+		return err
+	}
+
+	rawToken, err := s.issueOneTimeToken(ctx, user.ID, domain.TokenPurposeMagicLink, user.Email, magicLinkTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/magic-link?token=%s", s.settings.AppBaseURL, url.QueryEscape(rawToken))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below to sign in:\n\n%s\n\nThe link expires in 15 minutes and can be used once. If you did not ask for this, you can ignore this email.\n",
+			user.Name, link,
+		),
+	})
+}
+
+// sendPhoneCode texts a sign-in code to the account registered with the number, if any
+func (s *accountService) sendPhoneCode(ctx context.Context, e164 string) error {
+	user, err := s.findUserByPhone(ctx, e164)
+	if err != nil || user == nil {
+		return err
+	}
+
+	// Throttle silently; the caller always gets the same answer
+	if recent, err := s.recentlyIssued(ctx, user.ID, domain.TokenPurposePhoneSignIn); err != nil || recent {
+		return err
+	}
+
+	return s.sendShortCode(ctx, user.ID, domain.TokenPurposePhoneSignIn, e164, phoneCodeTTL,
+		"%s is your sign-in code. It expires in 10 minutes. Never share it with anyone.")
+}
+
+// recentlyIssued reports whether a token of the purpose was issued to the
+// user within passwordlessCooldown
+func (s *accountService) recentlyIssued(ctx context.Context, userID uint, purpose string) (bool, error) {
+	latest, err := s.tokenRepo.FindLatest(ctx, userID, purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return time.Since(latest.CreatedAt) < passwordlessCooldown, nil
+}
+
+// normalizeRequestPhone turns the phone and country of a request into E.164
+func (s *accountService) normalizeRequestPhone(ctx context.Context, raw, countryShortname string) (string, error) {
+	country, err := s.resolveCountry(ctx, countryShortname)
+	if err != nil {
+		return "", err
+	}
+
+	number, err := parsePhone(raw, country)
+	if err != nil {
+		return "", err
+	}
+	return number.E164(), nil
+}
+
+// findUserByPhone returns the account that verified the number, or nil.
+// Unverified numbers cannot be used to sign in since several accounts may
+// claim them.
+func (s *accountService) findUserByPhone(ctx context.Context, e164 string) (*domain.User, error) {
+	user, err := s.userRepo.FindByVerifiedPhone(ctx, e164)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// generateShortCode returns a random six-digit code
+func generateShortCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashShortCode hashes a short code with a random salt, stored as
+// "<salt>$<hash>". The salt keeps the stored value unique even when two
+// accounts happen to receive the same code.
+func hashShortCode(code string) (string, error) {
+	salt, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	return salt + "$" + hashToken(salt+code), nil
+}
+
+// checkShortCode compares a code with a value from hashShortCode
+func checkShortCode(stored, code string) bool {
+	salt, hash, ok := strings.Cut(stored, "$")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashToken(salt+code))) == 1
+}
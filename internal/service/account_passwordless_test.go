@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"testing"
+	"time"
+)
+
+const testPhone = "+64211234567"
+
+// lastCode returns the code in the last text sent to e164
+func (f *accountFixture) lastCode(t *testing.T, e164 string) string {
+	t.Helper()
+
+	msg, ok := f.texts.Last(e164)
+	if !ok {
+		t.Fatalf("no text sent to %s", e164)
+	}
+	return msg.Body[:6]
+}
+
+// wrongCode returns a six-digit code that is not code
+func wrongCode(code string) string {
+	last := '0'
+	if code[5] == '0' {
+		last = '1'
+	}
+	return code[:5] + string(last)
+}
+
+// createPhoneUser stores a user who has verified testPhone
+func (f *accountFixture) createPhoneUser(t *testing.T) *domain.User {
+	t.Helper()
+
+	user := f.createUser(t, "guest@example.com", "correct horse")
+	user.Phone = testPhone
+	user.PhoneVerifiedAt = ptr(time.Now())
+	if err := f.users.Update(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// requestPhoneCode texts a sign-in code to testPhone and returns it
+func (f *accountFixture) requestPhoneCode(t *testing.T) string {
+	t.Helper()
+
+	if err := f.service.RequestPhoneCode(context.Background(), dto.PhoneCodeRequest{Phone: testPhone}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.WaitForBackgroundWork(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return f.lastCode(t, testPhone)
+}
+
+func TestSignInWithPhoneCode(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// prepare runs between texting the code and using it, and returns
+		// the code to present
+		prepare func(t *testing.T, f *accountFixture, code string) string
+		phone   string
+		wantErr error
+	}{
+		{
+			name:    "valid code",
+			prepare: func(t *testing.T, f *accountFixture, code string) string { return code },
+		},
+		{
+			name:    "number written differently",
+			prepare: func(t *testing.T, f *accountFixture, code string) string { return code },
+			phone:   "0064 21 123 4567",
+		},
+		{
+			name:    "wrong code",
+			prepare: func(t *testing.T, f *accountFixture, code string) string { return wrongCode(code) },
+			wantErr: ErrInvalidSignInCode,
+		},
+		{
+			name: "typo before the right code",
+			prepare: func(t *testing.T, f *accountFixture, code string) string {
+				if _, err := f.service.SignInWithPhoneCode(ctx, dto.PhoneCodeVerifyRequest{Phone: testPhone, Code: wrongCode(code)}, "192.0.2.1"); !errors.Is(err, ErrInvalidSignInCode) {
+					t.Fatalf("typo error = %v, want invalid or expired code", err)
+				}
+				return code
+			},
+		},
+		{
+			name: "used code",
+			prepare: func(t *testing.T, f *accountFixture, code string) string {
+				if _, err := f.service.SignInWithPhoneCode(ctx, dto.PhoneCodeVerifyRequest{Phone: testPhone, Code: code}, "192.0.2.1"); err != nil {
+					t.Fatalf("first sign-in: %v", err)
+				}
+				return code
+			},
+			wantErr: ErrInvalidSignInCode,
+		},
+		{
+			name: "expired code",
+			prepare: func(t *testing.T, f *accountFixture, code string) string {
+				f.tokens.expire(domain.TokenPurposePhoneSignIn, time.Now())
+				return code
+			},
+			wantErr: ErrInvalidSignInCode,
+		},
+		{
+			name: "second request within the cooldown",
+			prepare: func(t *testing.T, f *accountFixture, code string) string {
+				if err := f.service.sendPhoneCode(ctx, testPhone); err != nil {
+					t.Fatal(err)
+				}
+				if texts := f.texts.Messages(); len(texts) != 1 {
+					t.Fatalf("sent %d texts, want 1", len(texts))
+				}
+				return code
+			},
+		},
+		{
+			name: "replaced by a newer code",
+			prepare: func(t *testing.T, f *accountFixture, code string) string {
+				if err := f.service.sendShortCode(ctx, 1, domain.TokenPurposePhoneSignIn, testPhone, phoneCodeTTL, "%s"); err != nil {
+					t.Fatal(err)
+				}
+				return code
+			},
+			wantErr: ErrInvalidSignInCode,
+		},
+		{
+			name: "number no longer verified",
+			prepare: func(t *testing.T, f *accountFixture, code string) string {
+				user, err := f.users.FindByID(ctx, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				user.PhoneVerifiedAt = nil
+				if err := f.users.Update(ctx, user); err != nil {
+					t.Fatal(err)
+				}
+				return code
+			},
+			wantErr: ErrInvalidSignInCode,
+		},
+		{
+			name:    "unknown number",
+			prepare: func(t *testing.T, f *accountFixture, code string) string { return code },
+			phone:   "+64219876543",
+			wantErr: ErrInvalidSignInCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			f.createPhoneUser(t)
+
+			code := tt.prepare(t, f, f.requestPhoneCode(t))
+			phone := testPhone
+			if tt.phone != "" {
+				phone = tt.phone
+			}
+
+			signIn, err := f.service.SignInWithPhoneCode(ctx, dto.PhoneCodeVerifyRequest{Phone: phone, Code: code}, "192.0.2.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SignInWithPhoneCode error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && signIn.Token == "" {
+				t.Error("no access token issued")
+			}
+		})
+	}
+}
+
+func TestSignInWithPhoneCodeAttemptLimit(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name         string
+		wrongGuesses int
+		wantLocked   bool
+	}{
+		{"below the limit", domain.MaxOneTimeTokenFailures - 1, false},
+		{"at the limit", domain.MaxOneTimeTokenFailures, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			user := f.createPhoneUser(t)
+			code := f.requestPhoneCode(t)
+
+			for i := 0; i < tt.wrongGuesses; i++ {
+				if _, err := f.service.SignInWithPhoneCode(ctx, dto.PhoneCodeVerifyRequest{Phone: testPhone, Code: wrongCode(code)}, "192.0.2.1"); !errors.Is(err, ErrInvalidSignInCode) {
+					t.Fatalf("guess %d error = %v, want invalid or expired code", i+1, err)
+				}
+			}
+
+			_, err := f.service.SignInWithPhoneCode(ctx, dto.PhoneCodeVerifyRequest{Phone: testPhone, Code: code}, "192.0.2.1")
+			if !tt.wantLocked {
+				if err != nil {
+					t.Fatalf("SignInWithPhoneCode error = %v, want none", err)
+				}
+				return
+			}
+			var lockout *LockoutError
+			if !errors.As(err, &lockout) || !lockout.IsAccountLock() {
+				t.Fatalf("SignInWithPhoneCode error = %v, want an account lockout", err)
+			}
+
+			// Lifting the lockout does not revive the code
+			if err := f.attempts.Reset(ctx, accountKey(user.Email)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.service.SignInWithPhoneCode(ctx, dto.PhoneCodeVerifyRequest{Phone: testPhone, Code: code}, "192.0.2.2"); !errors.Is(err, ErrInvalidSignInCode) {
+				t.Errorf("after lockout error = %v, want invalid or expired code", err)
+			}
+		})
+	}
+}
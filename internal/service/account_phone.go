@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/phone"
+	"go-booking-system/internal/sms"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// phoneVerificationTTL is how long an SMS verification code stays valid
+const phoneVerificationTTL = time.Minute * 10
+
+// RequestPhoneVerification texts a code that confirms the user's phone number
+func (s *accountService) RequestPhoneVerification(ctx context.Context, userUUID string) error {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return apperror.Internal("failed to find user", err)
+	}
+
+	if user.Phone == "" {
+		return ErrNoPhone
+	}
+	if user.IsPhoneVerified() {
+		return ErrPhoneAlreadyVerified
+	}
+
+	// Numbers saved before E.164 normalisation must be updated first
+	if _, err := phone.ParseE164(user.Phone); err != nil {
+		return ErrInvalidPhone
+	}
+
+	// Don't let someone else's verified number receive codes from this account
+	if err := s.checkPhoneAvailable(ctx, user, user.Phone); err != nil {
+		return err
+	}
+
+	// Throttle resends so the endpoint cannot be used to flood a phone
+	recent, err := s.recentlyIssued(ctx, user.ID, domain.TokenPurposePhoneVerification)
+	if err != nil {
+		return apperror.Internal("failed to send verification code", err)
+	}
+	if recent {
+		return ErrVerificationCodeRecentlySent
+	}
+
+	if err := s.sendShortCode(ctx, user.ID, domain.TokenPurposePhoneVerification, user.Phone, phoneVerificationTTL,
+		"%s is your verification code. It expires in 10 minutes."); err != nil {
+		return apperror.Internal("failed to send verification code", err)
+	}
+
+	return nil
+}
+
+// VerifyPhone checks the SMS code and marks the phone number as verified
+func (s *accountService) VerifyPhone(ctx context.Context, userUUID string, req dto.PhoneVerifyRequest) (*dto.UserResponse, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+
+	if user.IsPhoneVerified() {
+		response := toUserResponse(user)
+		return &response, nil
+	}
+
+	// The code only vouches for the number it was sent to
+	ok, err := s.redeemShortCode(ctx, user.ID, domain.TokenPurposePhoneVerification, user.Phone, req.Code)
+	if err != nil {
+		return nil, apperror.Internal("failed to verify phone number", err)
+	}
+	if !ok {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	if err := s.checkPhoneAvailable(ctx, user, user.Phone); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.PhoneVerifiedAt = &now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		// The partial unique index catches a race with another account
+		return nil, apperror.Internal("failed to verify phone number", err)
+	}
+
+	response := toUserResponse(user)
+	return &response, nil
+}
+
+// checkPhoneAvailable enforces that a verified number belongs to one account
+func (s *accountService) checkPhoneAvailable(ctx context.Context, user *domain.User, e164 string) error {
+	owner, err := s.userRepo.FindByVerifiedPhone(ctx, e164)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return apperror.Internal("failed to check phone number", err)
+	}
+	if owner.ID != user.ID {
+		return ErrPhoneTaken
+	}
+	return nil
+}
+
+// resolveCountry looks up a country by shortname; an empty shortname is no country
+func (s *accountService) resolveCountry(ctx context.Context, shortname string) (*domain.Country, error) {
+	shortname = strings.TrimSpace(shortname)
+	if shortname == "" {
+		return nil, nil
+	}
+
+	country, err := s.countryRepo.FindByShortname(ctx, shortname)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnknownCountry
+		}
+		return nil, apperror.Internal("failed to find country", err)
+	}
+	// Disabled countries are no longer offered, so they cannot be chosen either
+	if country.IsDisabled() {
+		return nil, ErrUnknownCountry
+	}
+	return country, nil
+}
+
+// parsePhone validates a phone number as typed, reading national numbers
+// with the numbering plan of country
+func parsePhone(raw string, country *domain.Country) (phone.Number, error) {
+	region, callingCode := "", 0
+	if country != nil {
+		if country.Shortname != nil {
+			region = *country.Shortname
+		}
+		if country.CountryCode != nil {
+			callingCode = *country.CountryCode
+		}
+	}
+
+	number, err := phone.Parse(raw, region, callingCode)
+	if err != nil {
+		if errors.Is(err, phone.ErrRegionRequired) {
+			return phone.Number{}, ErrPhoneCountryRequired
+		}
+		return phone.Number{}, ErrInvalidPhone
+	}
+	return number, nil
+}
+
+// nationalPhone formats a stored E.164 number for display, falling back to
+// the stored value for numbers saved before normalisation
+func nationalPhone(stored string) string {
+	if stored == "" {
+		return ""
+	}
+	number, err := phone.ParseE164(stored)
+	if err != nil {
+		return stored
+	}
+	return number.National()
+}
+
+// sendShortCode replaces any outstanding code of the purpose with a new one
+// and texts it to e164. message must contain one %s for the code.
+func (s *accountService) sendShortCode(ctx context.Context, userID uint, purpose, e164 string, ttl time.Duration, message string) error {
+	// Only the newest code should work
+	if err := s.tokenRepo.ConsumeAllForUser(ctx, userID, purpose); err != nil {
+		return err
+	}
+
+	code, err := generateShortCode()
+	if err != nil {
+		return err
+	}
+	codeHash, err := hashShortCode(code)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.Create(ctx, &domain.OneTimeToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: codeHash,
+		Target:    e164,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	return s.smsSender.Send(sms.Message{To: e164, Body: fmt.Sprintf(message, code)})
+}
+
+// redeemShortCode consumes the user's latest code of the purpose if it was
+// sent to e164 and matches. Wrong guesses are counted on the code, which dies
+// after domain.MaxOneTimeTokenFailures of them.
+func (s *accountService) redeemShortCode(ctx context.Context, userID uint, purpose, e164, code string) (bool, error) {
+	token, err := s.tokenRepo.FindLatest(ctx, userID, purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	now := time.Now()
+	if !token.IsUsable(now) || token.Target != e164 {
+		return false, nil
+	}
+	if !checkShortCode(token.TokenHash, code) {
+		// Count the guess even if the client has already hung up
+		return false, s.tokenRepo.RecordFailedAttempt(context.WithoutCancel(ctx), token.ID)
+	}
+
+	return s.tokenRepo.Consume(ctx, token.ID, now)
+}
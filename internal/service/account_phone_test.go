@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"testing"
+	"time"
+)
+
+// requestPhoneVerification gives user testPhone, unverified, and returns the
+// verification code texted to it
+func (f *accountFixture) requestPhoneVerification(t *testing.T, user *domain.User) string {
+	t.Helper()
+	ctx := context.Background()
+
+	user.Phone = testPhone
+	if err := f.users.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.RequestPhoneVerification(ctx, user.UUID); err != nil {
+		t.Fatal(err)
+	}
+	return f.lastCode(t, testPhone)
+}
+
+func TestVerifyPhone(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare runs between texting the code and using it, and returns
+		// the code to present
+		prepare func(t *testing.T, f *accountFixture, user *domain.User, code string) string
+		wantErr error
+	}{
+		{
+			name:    "valid code",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, code string) string { return code },
+		},
+		{
+			name: "wrong guesses below the limit",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, code string) string {
+				for i := 0; i < domain.MaxOneTimeTokenFailures-1; i++ {
+					f.verifyPhone(t, user, wrongCode(code), ErrInvalidVerificationCode)
+				}
+				return code
+			},
+		},
+		{
+			name: "wrong guesses at the limit",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, code string) string {
+				for i := 0; i < domain.MaxOneTimeTokenFailures; i++ {
+					f.verifyPhone(t, user, wrongCode(code), ErrInvalidVerificationCode)
+				}
+				return code
+			},
+			wantErr: ErrInvalidVerificationCode,
+		},
+		{
+			name: "expired code",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, code string) string {
+				f.tokens.expire(domain.TokenPurposePhoneVerification, time.Now())
+				return code
+			},
+			wantErr: ErrInvalidVerificationCode,
+		},
+		{
+			name: "number changed since",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, code string) string {
+				user.Phone = "+64219876543"
+				if err := f.users.Update(context.Background(), user); err != nil {
+					t.Fatal(err)
+				}
+				return code
+			},
+			wantErr: ErrInvalidVerificationCode,
+		},
+		{
+			name: "number verified by another account meanwhile",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, code string) string {
+				other := f.createUser(t, "other@example.com", "correct horse")
+				now := time.Now()
+				other.Phone, other.PhoneVerifiedAt = testPhone, &now
+				if err := f.users.Update(context.Background(), other); err != nil {
+					t.Fatal(err)
+				}
+				return code
+			},
+			wantErr: ErrPhoneTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			user := f.createUser(t, "guest@example.com", "correct horse")
+
+			code := tt.prepare(t, f, user, f.requestPhoneVerification(t, user))
+			f.verifyPhone(t, user, code, tt.wantErr)
+
+			stored, err := f.users.FindByID(context.Background(), user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verified := stored.IsPhoneVerified(); verified != (tt.wantErr == nil) {
+				t.Errorf("phone verified = %v, want %v", verified, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+// verifyPhone presents code for user and checks the error
+func (f *accountFixture) verifyPhone(t *testing.T, user *domain.User, code string, wantErr error) {
+	t.Helper()
+
+	_, err := f.service.VerifyPhone(context.Background(), user.UUID, dto.PhoneVerifyRequest{Code: code})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("VerifyPhone error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestRequestPhoneVerification(t *testing.T) {
+	tests := []struct {
+		name    string
+		phone   string
+		prepare func(t *testing.T, f *accountFixture)
+		wantErr error
+	}{
+		{name: "unverified number", phone: testPhone},
+		{name: "no number", wantErr: ErrNoPhone},
+		{name: "number saved before normalisation", phone: "021 123 4567", wantErr: ErrInvalidPhone},
+		{
+			name:  "code sent moments ago",
+			phone: testPhone,
+			prepare: func(t *testing.T, f *accountFixture) {
+				if err := f.service.RequestPhoneVerification(context.Background(), f.users.users[1].UUID); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrVerificationCodeRecentlySent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			ctx := context.Background()
+			user := f.createUser(t, "guest@example.com", "correct horse")
+			user.Phone = tt.phone
+			if err := f.users.Update(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			if tt.prepare != nil {
+				tt.prepare(t, f)
+			}
+			sent := len(f.texts.Messages())
+
+			err := f.service.RequestPhoneVerification(ctx, user.UUID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RequestPhoneVerification error = %v, want %v", err, tt.wantErr)
+			}
+			wantSent := 0
+			if tt.wantErr == nil {
+				wantSent = 1
+			}
+			if got := len(f.texts.Messages()) - sent; got != wantSent {
+				t.Errorf("texts sent = %d, want %d", got, wantSent)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// anonymizationBatchSize bounds how many accounts are scrubbed per query
+const anonymizationBatchSize = 100
+
+// DeleteAccount soft-deletes the account after confirming the password and
+// signs it out everywhere. The last admin has to hand the role on first, or
+// nobody would be left to manage roles. Personal data is scrubbed later by
+// AnonymizeDeletedAccounts once the grace period has passed.
+func (s *accountService) DeleteAccount(ctx context.Context, userUUID string, req dto.DeleteAccountRequest) error {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return apperror.Internal("failed to find user", err)
+	}
+
+	if err := user.CheckPassword(req.Password); err != nil {
+		return ErrInvalidPassword
+	}
+
+	if user.HasRole(domain.RoleAdmin) {
+		err = s.userRepo.DeleteUnlessLastRoleHolder(ctx, user.ID, domain.RoleAdmin)
+	} else {
+		err = s.userRepo.Delete(ctx, user.ID)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrLastRoleHolder) {
+			return ErrLastAdminAccount
+		}
+		return apperror.Internal("failed to delete account", err)
+	}
+
+	return s.LogoutAll(ctx, user.UUID)
+}
+
+// ExportAccount collects everything stored about the user
+func (s *accountService) ExportAccount(ctx context.Context, userUUID string) (*dto.AccountExport, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+
+	export := &dto.AccountExport{
+		ExportedAt: time.Now().Format(time.RFC3339),
+		Profile: dto.ExportProfile{
+			UUID:             user.UUID,
+			Email:            user.Email,
+			Name:             user.Name,
+			Phone:            user.Phone,
+			PhoneVerifiedAt:  formatOptionalTime(user.PhoneVerifiedAt),
+			EmailVerifiedAt:  formatOptionalTime(user.EmailVerifiedAt),
+			TwoFactorEnabled: user.IsTwoFactorEnabled(),
+			Roles:            roleStrings(user.RoleNames()),
+			CreatedAt:        user.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:        user.UpdatedAt.Format(time.RFC3339),
+		},
+		Sessions:      []dto.ExportSession{},
+		OneTimeTokens: []dto.ExportToken{},
+		Identities:    []dto.ExportIdentity{},
+		AuditEvents:   []dto.ExportAudit{},
+		LoginAttempts: []dto.ExportAttempts{},
+	}
+
+	if user.MobileCountryId != nil {
+		country, err := s.countryRepo.FindByID(ctx, *user.MobileCountryId)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.Internal("failed to export account", err)
+		}
+		if country != nil && country.Shortname != nil {
+			export.Profile.Country = *country.Shortname
+		}
+	}
+
+	sessions, err := s.sessionRepo.FindAllForUser(ctx, user.UUID)
+	if err != nil {
+		return nil, apperror.Internal("failed to export account", err)
+	}
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, dto.ExportSession{
+			Device:    session.DeviceLabel,
+			CreatedAt: session.CreatedAt.Format(time.RFC3339),
+			ExpiresAt: session.ExpiresAt.Format(time.RFC3339),
+			RotatedAt: formatOptionalTime(session.RotatedAt),
+			RevokedAt: formatOptionalTime(session.RevokedAt),
+		})
+	}
+
+	tokens, err := s.tokenRepo.FindAllForUser(ctx, user.ID)
+	if err != nil {
+		return nil, apperror.Internal("failed to export account", err)
+	}
+	for _, token := range tokens {
+		export.OneTimeTokens = append(export.OneTimeTokens, dto.ExportToken{
+			Purpose:    token.Purpose,
+			Target:     token.Target,
+			CreatedAt:  token.CreatedAt.Format(time.RFC3339),
+			ExpiresAt:  token.ExpiresAt.Format(time.RFC3339),
+			ConsumedAt: formatOptionalTime(token.ConsumedAt),
+		})
+	}
+
+	identities, err := s.identityRepo.FindAllForUser(ctx, user.ID)
+	if err != nil {
+		return nil, apperror.Internal("failed to export account", err)
+	}
+	for _, identity := range identities {
+		export.Identities = append(export.Identities, dto.ExportIdentity{
+			Provider:   identity.Provider,
+			Email:      identity.Email,
+			CreatedAt:  identity.CreatedAt.Format(time.RFC3339),
+			LastUsedAt: formatOptionalTime(identity.LastUsedAt),
+		})
+	}
+
+	events, err := s.auditRepo.FindAllForUser(ctx, user.UUID)
+	if err != nil {
+		return nil, apperror.Internal("failed to export account", err)
+	}
+	for _, event := range events {
+		audit := dto.ExportAudit{
+			Action:    event.Action,
+			Detail:    event.Detail,
+			CreatedAt: event.CreatedAt.Format(time.RFC3339),
+		}
+		switch event.ActorUUID {
+		case user.UUID:
+			audit.Actor = "self"
+			audit.ClientIP = event.ClientIP
+		case "":
+			audit.Actor = "system"
+		default:
+			audit.Actor = "admin"
+		}
+		export.AuditEvents = append(export.AuditEvents, audit)
+	}
+
+	attempt, err := s.loginGuard.AccountAttempts(ctx, user.Email)
+	if err != nil {
+		return nil, apperror.Internal("failed to export account", err)
+	}
+	if attempt != nil {
+		export.LoginAttempts = append(export.LoginAttempts, dto.ExportAttempts{
+			Failures:     attempt.Failures,
+			LastFailedAt: attempt.LastFailedAt.Format(time.RFC3339),
+			LockedUntil:  formatOptionalTime(attempt.LockedUntil),
+		})
+	}
+
+	return export, nil
+}
+
+// AnonymizeDeletedAccounts scrubs personal data from accounts deleted more than
+// gracePeriod ago and returns how many were processed
+func (s *accountService) AnonymizeDeletedAccounts(ctx context.Context, gracePeriod time.Duration) (int, error) {
+	cutoff := time.Now().Add(-gracePeriod)
+	total := 0
+
+	for {
+		users, err := s.userRepo.FindDeletedForAnonymization(ctx, cutoff, anonymizationBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(users) == 0 {
+			return total, nil
+		}
+
+		for i := range users {
+			user := &users[i]
+
+			// Sessions and emailed links carry device names and addresses
+			if err := s.sessionRepo.DeleteAllForUser(ctx, user.UUID); err != nil {
+				return total, err
+			}
+			if err := s.tokenRepo.DeleteAllForUser(ctx, user.ID); err != nil {
+				return total, err
+			}
+			if err := s.recoveryRepo.DeleteAllForUser(ctx, user.ID); err != nil {
+				return total, err
+			}
+			if err := s.identityRepo.DeleteAllForUser(ctx, user.ID); err != nil {
+				return total, err
+			}
+			// The failure counter is keyed by the address being scrubbed
+			if err := s.loginGuard.ForgetAccount(ctx, user.Email); err != nil {
+				return total, err
+			}
+			// Roles go with the account; audit events keep only its UUID
+			if err := s.roleRepo.DeleteAllForUser(ctx, user.ID); err != nil {
+				return total, err
+			}
+			if err := s.auditRepo.ClearClientIPs(ctx, user.UUID); err != nil {
+				return total, err
+			}
+
+			user.Anonymize(time.Now())
+			if err := s.userRepo.SaveDeleted(ctx, user); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+}
+
+// formatOptionalTime formats a nullable timestamp, returning "" for nil
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"testing"
+	"time"
+)
+
+// deleteWithHistory leaves a trail of sessions, emailed links, roles, failed
+// sign-ins and audit events for a user, then deletes the account
+func (f *accountFixture) deleteWithHistory(t *testing.T, email string) *domain.User {
+	t.Helper()
+	ctx := context.Background()
+
+	user := f.createUser(t, email, "correct horse")
+	if _, err := f.roles.Grant(ctx, user.ID, domain.RoleHost, &domain.AuditEvent{
+		ActorUUID: "admin-uuid", Action: domain.AuditActionRoleGranted, TargetUUID: user.UUID, Detail: "host", ClientIP: "198.51.100.1",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.audit.Create(ctx, &domain.AuditEvent{
+		ActorUUID: user.UUID, Action: domain.AuditActionRoleGranted, TargetUUID: "someone-else", Detail: "host", ClientIP: "203.0.113.7",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.SignIn(ctx, dto.SignInRequest{Email: email, Password: "correct horse", Device: "phone"}, "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	f.requestPasswordReset(t, email)
+	// Sign-in cleared the counter; fail once more so there is one to erase
+	if _, err := f.service.SignIn(ctx, dto.SignInRequest{Email: email, Password: "wrong"}, "192.0.2.1"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("SignIn error = %v, want invalid credentials", err)
+	}
+
+	if err := f.service.DeleteAccount(ctx, user.UUID, dto.DeleteAccountRequest{Password: "correct horse"}); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+	user := f.createUser(t, "guest@example.com", "correct horse")
+	signIn, err := f.service.SignIn(ctx, dto.SignInRequest{Email: user.Email, Password: "correct horse"}, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.service.DeleteAccount(ctx, user.UUID, dto.DeleteAccountRequest{Password: "wrong"}); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("wrong password error = %v, want invalid current password", err)
+	}
+	if _, err := f.users.FindByUUID(ctx, user.UUID); err != nil {
+		t.Fatalf("account gone after a wrong password: %v", err)
+	}
+
+	// Revocation has millisecond resolution, like iat
+	time.Sleep(2 * time.Millisecond)
+	if err := f.service.DeleteAccount(ctx, user.UUID, dto.DeleteAccountRequest{Password: "correct horse"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.users.FindByUUID(ctx, user.UUID); err == nil {
+		t.Error("deleted account still found")
+	}
+	if _, err := f.service.tokenService.ParseAccessToken(ctx, signIn.Token); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("access token error = %v, want token revoked", err)
+	}
+	if _, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{RefreshToken: signIn.RefreshToken}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh token error = %v, want invalid refresh token", err)
+	}
+	// The address stays taken until the account is anonymised
+	if _, err := f.service.SignUp(ctx, dto.SignUpRequest{Email: user.Email, Password: "another password", Name: "Guest"}); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("sign-up with the deleted address error = %v, want email already registered", err)
+	}
+}
+
+func TestDeleteAccountKeepsLastAdmin(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// otherAdmin is the state of a second admin: "" for none, "active" or "deleted"
+		otherAdmin string
+		wantErr    error
+	}{
+		{name: "last admin", wantErr: ErrLastAdminAccount},
+		{name: "only other admin is deleted", otherAdmin: "deleted", wantErr: ErrLastAdminAccount},
+		{name: "one of two admins", otherAdmin: "active"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			grantAdmin := func(user *domain.User) {
+				if _, err := f.roles.Grant(ctx, user.ID, domain.RoleAdmin, &domain.AuditEvent{Action: domain.AuditActionRoleGranted}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.otherAdmin != "" {
+				other := f.createUser(t, "other@example.com", "correct horse")
+				grantAdmin(other)
+				if tt.otherAdmin == "deleted" {
+					if err := f.users.Delete(ctx, other.ID); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			admin := f.createUser(t, "admin@example.com", "correct horse")
+			grantAdmin(admin)
+			signIn, err := f.service.SignIn(ctx, dto.SignInRequest{Email: admin.Email, Password: "correct horse"}, "192.0.2.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Revocation has millisecond resolution, like iat
+			time.Sleep(2 * time.Millisecond)
+			err = f.service.DeleteAccount(ctx, admin.UUID, dto.DeleteAccountRequest{Password: "correct horse"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteAccount error = %v, want %v", err, tt.wantErr)
+			}
+
+			_, findErr := f.users.FindByUUID(ctx, admin.UUID)
+			_, parseErr := f.service.tokenService.ParseAccessToken(ctx, signIn.Token)
+			if tt.wantErr != nil && (findErr != nil || parseErr != nil) {
+				t.Errorf("refused deletion still took effect: find %v, access token %v", findErr, parseErr)
+			}
+			if tt.wantErr == nil && (findErr == nil || parseErr == nil) {
+				t.Error("admin account was not deleted and signed out")
+			}
+		})
+	}
+}
+
+func TestAnonymizeDeletedAccounts(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+
+	user := f.deleteWithHistory(t, "guest@example.com")
+	kept := f.createUser(t, "other@example.com", "correct horse")
+	if attempt, _ := f.attempts.Find(ctx, "email:guest@example.com"); attempt == nil {
+		t.Fatal("no failed sign-ins recorded before anonymisation")
+	}
+
+	// Still in the grace period
+	count, err := f.service.AnonymizeDeletedAccounts(ctx, time.Hour)
+	if err != nil || count != 0 {
+		t.Fatalf("AnonymizeDeletedAccounts in grace period = %d, %v; want 0", count, err)
+	}
+
+	count, err = f.service.AnonymizeDeletedAccounts(ctx, 0)
+	if err != nil || count != 1 {
+		t.Fatalf("AnonymizeDeletedAccounts = %d, %v; want 1", count, err)
+	}
+
+	scrubbed := f.users.users[user.ID]
+	if scrubbed.AnonymizedAt == nil || scrubbed.Email == user.Email || scrubbed.Name == user.Name || scrubbed.Password != "" {
+		t.Errorf("account not scrubbed: %+v", scrubbed)
+	}
+	if len(scrubbed.Roles) != 0 {
+		t.Errorf("roles = %v, want none", scrubbed.Roles)
+	}
+	if sessions, _ := f.sessions.FindAllForUser(ctx, user.UUID); len(sessions) != 0 {
+		t.Errorf("sessions = %d, want none", len(sessions))
+	}
+	if tokens, _ := f.tokens.FindAllForUser(ctx, user.ID); len(tokens) != 0 {
+		t.Errorf("one-time tokens = %d, want none", len(tokens))
+	}
+	if attempt, _ := f.attempts.Find(ctx, "email:guest@example.com"); attempt != nil {
+		t.Errorf("failed sign-ins of the scrubbed address kept: %+v", attempt)
+	}
+	// The client counter is about the IP, not the account
+	if attempt, _ := f.attempts.Find(ctx, "ip:192.0.2.1"); attempt == nil {
+		t.Error("client counter was dropped")
+	}
+	// The audit trail survives, keyed by UUID, without the user's own IP;
+	// the IP of the admin who acted on them is the admin's
+	events, _ := f.audit.FindAllForUser(ctx, user.UUID)
+	if len(events) != 2 {
+		t.Fatalf("audit events = %d, want 2", len(events))
+	}
+	for _, event := range events {
+		wantIP := "198.51.100.1"
+		if event.ActorUUID == user.UUID {
+			wantIP = ""
+		}
+		if event.ClientIP != wantIP {
+			t.Errorf("audit event by %s client IP = %q, want %q", event.ActorUUID, event.ClientIP, wantIP)
+		}
+	}
+	if untouched := f.users.users[kept.ID]; untouched.AnonymizedAt != nil || untouched.Email != kept.Email {
+		t.Errorf("live account was scrubbed: %+v", untouched)
+	}
+
+	// Nothing is left to do on the next run, and the address is free again
+	if count, err := f.service.AnonymizeDeletedAccounts(ctx, 0); err != nil || count != 0 {
+		t.Errorf("second run = %d, %v; want 0", count, err)
+	}
+	if _, err := f.service.SignUp(ctx, dto.SignUpRequest{Email: user.Email, Password: "another password", Name: "Guest"}); err != nil {
+		t.Errorf("sign-up with the anonymised address: %v", err)
+	}
+}
+
+func TestExportAccount(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+	user := f.createUser(t, "guest@example.com", "correct horse")
+	other := f.createUser(t, "other@example.com", "correct horse")
+
+	for _, u := range []*domain.User{user, other} {
+		if _, err := f.service.SignIn(ctx, dto.SignInRequest{Email: u.Email, Password: "correct horse", Device: "phone"}, "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.requestPasswordReset(t, user.Email)
+	for _, event := range []domain.AuditEvent{
+		{ActorUUID: "admin-uuid", Action: domain.AuditActionRoleGranted, TargetUUID: user.UUID, Detail: "host", ClientIP: "198.51.100.1"},
+		{ActorUUID: user.UUID, Action: domain.AuditActionRoleRevoked, TargetUUID: other.UUID, Detail: "host", ClientIP: "203.0.113.7"},
+		{Action: domain.AuditActionRoleGranted, TargetUUID: user.UUID, Detail: "admin"},
+		{ActorUUID: "admin-uuid", Action: domain.AuditActionRoleGranted, TargetUUID: other.UUID, Detail: "host"},
+	} {
+		if err := f.audit.Create(ctx, &event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := f.service.SignIn(ctx, dto.SignInRequest{Email: user.Email, Password: "wrong"}, "192.0.2.1"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("SignIn error = %v, want invalid credentials", err)
+		}
+	}
+
+	export, err := f.service.ExportAccount(ctx, user.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if export.Profile.UUID != user.UUID || export.Profile.Email != user.Email || export.Profile.EmailVerifiedAt != "" {
+		t.Errorf("profile = %+v", export.Profile)
+	}
+	if len(export.Sessions) != 1 || export.Sessions[0].Device != "phone" {
+		t.Errorf("sessions = %+v, want the user's phone only", export.Sessions)
+	}
+	if len(export.OneTimeTokens) != 1 || export.OneTimeTokens[0].Purpose != domain.TokenPurposePasswordReset ||
+		export.OneTimeTokens[0].Target != user.Email || export.OneTimeTokens[0].ConsumedAt != "" {
+		t.Errorf("one-time tokens = %+v, want the unused reset link", export.OneTimeTokens)
+	}
+
+	wantEvents := []dto.ExportAudit{
+		{Action: domain.AuditActionRoleGranted, Detail: "admin", Actor: "system"},
+		{Action: domain.AuditActionRoleRevoked, Detail: "host", Actor: "self", ClientIP: "203.0.113.7"},
+		{Action: domain.AuditActionRoleGranted, Detail: "host", Actor: "admin"},
+	}
+	if len(export.AuditEvents) != len(wantEvents) {
+		t.Fatalf("audit events = %+v, want %d", export.AuditEvents, len(wantEvents))
+	}
+	for i, want := range wantEvents {
+		got := export.AuditEvents[i]
+		got.CreatedAt = ""
+		if got != want {
+			t.Errorf("audit event %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	if len(export.LoginAttempts) != 1 || export.LoginAttempts[0].Failures != 2 || export.LoginAttempts[0].LockedUntil != "" {
+		t.Errorf("login attempts = %+v, want one unlocked counter at 2 failures", export.LoginAttempts)
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/mailer"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// emailChangeTTL is how long a link confirming a new email address stays valid
+const emailChangeTTL = time.Hour * 24
+
+// UpdateProfile changes the fields present in the request
+func (s *accountService) UpdateProfile(ctx context.Context, userUUID string, req dto.UpdateProfileRequest) (*dto.UserResponse, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, apperror.Internal("failed to retrieve user profile", err)
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, ErrEmptyName
+		}
+		user.Name = name
+	}
+
+	// Resolve the country shortname the same way SignUp does; an empty
+	// value clears it, an unknown one is rejected
+	if req.Country != nil {
+		country, err := s.resolveCountry(ctx, *req.Country)
+		if err != nil {
+			return nil, err
+		}
+		if country == nil {
+			user.MobileCountryId = nil
+		} else {
+			user.MobileCountryId = &country.ID
+		}
+	}
+
+	// National numbers are read with the (possibly just changed) country
+	if req.Phone != nil {
+		var phoneNumber string
+		if strings.TrimSpace(*req.Phone) != "" {
+			var country *domain.Country
+			if user.MobileCountryId != nil {
+				country, err = s.countryRepo.FindByID(ctx, *user.MobileCountryId)
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, apperror.Internal("failed to find country", err)
+				}
+			}
+			number, err := parsePhone(*req.Phone, country)
+			if err != nil {
+				return nil, err
+			}
+			phoneNumber = number.E164()
+		}
+
+		// A new number has to be verified again
+		if phoneNumber != user.Phone {
+			user.Phone = phoneNumber
+			user.PhoneVerifiedAt = nil
+		}
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, apperror.Internal("failed to update profile", err)
+	}
+
+	response := toUserResponse(user)
+	return &response, nil
+}
+
+// ChangePassword replaces the password after checking the current one. Every
+// existing session is signed out and a fresh token pair is returned for the
+// device making the change.
+func (s *accountService) ChangePassword(ctx context.Context, userUUID string, req dto.ChangePasswordRequest) (*dto.ChangePassword_Success, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+
+	if err := user.CheckPassword(req.CurrentPassword); err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	if err := user.HashPassword(req.NewPassword); err != nil {
+		return nil, apperror.Internal("failed to process password", err)
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, apperror.Internal("failed to change password", err)
+	}
+
+	// Sign out everywhere, then start a new session for this device
+	if err := s.LogoutAll(ctx, user.UUID); err != nil {
+		return nil, err
+	}
+
+	familyID := uuid.New().String()
+	token, err := s.generateToken(user, familyID)
+	if err != nil {
+		return nil, apperror.Internal("failed to generate token", err)
+	}
+
+	refreshToken, err := s.issueRefreshToken(ctx, user.UUID, familyID, req.Device)
+	if err != nil {
+		return nil, apperror.Internal("failed to generate refresh token", err)
+	}
+
+	return &dto.ChangePassword_Success{
+		Message:      "Password changed",
+		Token:        token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// RequestEmailChange sends a confirmation link to the new address. User.Email
+// is only replaced once that link is followed.
+func (s *accountService) RequestEmailChange(ctx context.Context, userUUID string, req dto.ChangeEmailRequest) error {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return apperror.Internal("failed to find user", err)
+	}
+
+	if err := user.CheckPassword(req.Password); err != nil {
+		return ErrInvalidPassword
+	}
+
+	if req.NewEmail == user.Email {
+		return ErrSameEmail
+	}
+
+	existingUser, err := s.userRepo.FindByEmailIncludingDeleted(ctx, req.NewEmail)
+	if err == nil && existingUser != nil {
+		return ErrEmailTaken
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperror.Internal("failed to check existing user", err)
+	}
+
+	// Only the newest request should be confirmable
+	if err := s.tokenRepo.ConsumeAllForUser(ctx, user.ID, domain.TokenPurposeEmailChange); err != nil {
+		return apperror.Internal("failed to request email change", err)
+	}
+
+	rawToken, err := s.issueOneTimeToken(ctx, user.ID, domain.TokenPurposeEmailChange, req.NewEmail, emailChangeTTL)
+	if err != nil {
+		return apperror.Internal("failed to request email change", err)
+	}
+
+	link := fmt.Sprintf("%s/confirm-email-change?token=%s", s.settings.AppBaseURL, url.QueryEscape(rawToken))
+	err = s.mailer.Send(mailer.Message{
+		To:      req.NewEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen the link below to use this address for your account:\n\n%s\n\nThe link expires in 24 hours. If you did not ask for this, you can ignore this email.\n",
+			user.Name, link,
+		),
+	})
+	if err != nil {
+		return apperror.Internal("failed to send confirmation email", err)
+	}
+
+	// Let the current address know, in case the account was taken over
+	notice := mailer.Message{
+		To:      user.Email,
+		Subject: "Email change requested",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nSomeone asked to change the email address on your account to %s. It will only change once the new address is confirmed.\n\nIf this was not you, change your password immediately.\n",
+			user.Name, req.NewEmail,
+		),
+	}
+	if err := s.mailer.Send(notice); err != nil {
+		slog.WarnContext(ctx, "failed to send email change notice", "user", user.UUID, "error", err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange consumes the token sent to the new address and switches to it
+func (s *accountService) ConfirmEmailChange(ctx context.Context, req dto.ConfirmEmailChangeRequest) error {
+	token, err := s.consumeOneTimeToken(ctx, domain.TokenPurposeEmailChange, req.Token)
+	if err != nil {
+		if errors.Is(err, errInvalidOneTimeToken) {
+			return ErrInvalidConfirmationToken
+		}
+		return apperror.Internal("failed to change email", err)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidConfirmationToken
+		}
+		return apperror.Internal("failed to find user", err)
+	}
+
+	// Someone may have registered the address since the link was sent
+	existingUser, err := s.userRepo.FindByEmailIncludingDeleted(ctx, token.Target)
+	if err == nil && existingUser != nil && existingUser.ID != user.ID {
+		return ErrEmailTaken
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperror.Internal("failed to check existing user", err)
+	}
+
+	// Following the link proves ownership of the new address
+	now := time.Now()
+	user.Email = token.Target
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return apperror.Internal("failed to change email", err)
+	}
+
+	// Links sent to the old address no longer match it
+	if err := s.tokenRepo.ConsumeAllForUser(ctx, user.ID, domain.TokenPurposeEmailVerification); err != nil {
+		slog.WarnContext(ctx, "failed to invalidate verification links", "user", user.UUID, "error", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var confirmEmailLinkPattern = regexp.MustCompile(`/confirm-email-change\?token=(\S+)`)
+
+// requestEmailChange asks to move user to newEmail and returns the token
+// mailed to the new address
+func (f *accountFixture) requestEmailChange(t *testing.T, user *domain.User, newEmail string) string {
+	t.Helper()
+
+	if err := f.service.RequestEmailChange(context.Background(), user.UUID, dto.ChangeEmailRequest{NewEmail: newEmail, Password: "correct horse"}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, ok := f.mail.Last(newEmail)
+	if !ok {
+		t.Fatalf("no email sent to %s", newEmail)
+	}
+	match := confirmEmailLinkPattern.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("no confirmation link in email: %q", msg.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// prepare runs between mailing the link and using it, and returns
+		// the token to present
+		prepare   func(t *testing.T, f *accountFixture, token string) string
+		wantErr   error
+		wantEmail string
+	}{
+		{
+			name:      "valid token",
+			prepare:   func(t *testing.T, f *accountFixture, token string) string { return token },
+			wantEmail: "new@example.com",
+		},
+		{
+			name: "used token",
+			prepare: func(t *testing.T, f *accountFixture, token string) string {
+				if err := f.service.ConfirmEmailChange(ctx, dto.ConfirmEmailChangeRequest{Token: token}); err != nil {
+					t.Fatalf("first confirmation: %v", err)
+				}
+				return token
+			},
+			wantErr:   ErrInvalidConfirmationToken,
+			wantEmail: "new@example.com",
+		},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, f *accountFixture, token string) string {
+				f.tokens.expire(domain.TokenPurposeEmailChange, time.Now())
+				return token
+			},
+			wantErr:   ErrInvalidConfirmationToken,
+			wantEmail: "guest@example.com",
+		},
+		{
+			name: "superseded by a newer request",
+			prepare: func(t *testing.T, f *accountFixture, token string) string {
+				user, err := f.users.FindByEmail(ctx, "guest@example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+				f.requestEmailChange(t, user, "other@example.com")
+				return token
+			},
+			wantErr:   ErrInvalidConfirmationToken,
+			wantEmail: "guest@example.com",
+		},
+		{
+			name: "address taken since",
+			prepare: func(t *testing.T, f *accountFixture, token string) string {
+				f.createUser(t, "new@example.com", "another password")
+				return token
+			},
+			wantErr:   ErrEmailTaken,
+			wantEmail: "guest@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			user := f.createUser(t, "guest@example.com", "correct horse")
+			token := tt.prepare(t, f, f.requestEmailChange(t, user, "new@example.com"))
+
+			err := f.service.ConfirmEmailChange(ctx, dto.ConfirmEmailChangeRequest{Token: token})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ConfirmEmailChange error = %v, want %v", err, tt.wantErr)
+			}
+
+			updated, err := f.users.FindByID(ctx, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.Email != tt.wantEmail {
+				t.Errorf("email = %q, want %q", updated.Email, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestEmailChangeNeedsVerificationOfTheNewAddress(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+	user := f.createUser(t, "guest@example.com", "correct horse")
+	// The current address was verified; the new one is not until confirmed
+	user.EmailVerifiedAt = ptr(time.Now().Add(-time.Hour))
+	if err := f.users.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	_, verifyToken := f.signUp(t, "second@example.com")
+
+	token := f.requestEmailChange(t, user, "new@example.com")
+
+	// Asking alone changes nothing and warns the current address
+	pending, err := f.users.FindByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending.Email != "guest@example.com" || !pending.IsEmailVerified() {
+		t.Errorf("after request: email %q verified %v, want the old verified address", pending.Email, pending.IsEmailVerified())
+	}
+	if msg, _ := f.mail.Last("guest@example.com"); msg.Subject != "Email change requested" {
+		t.Errorf("notice to old address = %q, want the change notice", msg.Subject)
+	}
+
+	before := time.Now()
+	if err := f.service.ConfirmEmailChange(ctx, dto.ConfirmEmailChangeRequest{Token: token}); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := f.users.FindByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Email != "new@example.com" || changed.EmailVerifiedAt == nil || changed.EmailVerifiedAt.Before(before) {
+		t.Errorf("after confirmation: email %q verified at %v, want the new address verified now", changed.Email, changed.EmailVerifiedAt)
+	}
+
+	// A change token cannot stand in for an email verification, or the reverse
+	if err := f.service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: token}); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Errorf("change token as verification: %v", err)
+	}
+	if err := f.service.ConfirmEmailChange(ctx, dto.ConfirmEmailChangeRequest{Token: verifyToken}); !errors.Is(err, ErrInvalidConfirmationToken) {
+		t.Errorf("verification token as change: %v", err)
+	}
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		newEmail string
+		password string
+		wantErr  error
+	}{
+		{name: "wrong password", newEmail: "new@example.com", password: "wrong", wantErr: ErrInvalidPassword},
+		{name: "same address", newEmail: "guest@example.com", password: "correct horse", wantErr: ErrSameEmail},
+		{name: "address taken", newEmail: "taken@example.com", password: "correct horse", wantErr: ErrEmailTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			user := f.createUser(t, "guest@example.com", "correct horse")
+			f.createUser(t, "taken@example.com", "another password")
+
+			err := f.service.RequestEmailChange(context.Background(), user.UUID, dto.ChangeEmailRequest{NewEmail: tt.newEmail, Password: tt.password})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RequestEmailChange error = %v, want %v", err, tt.wantErr)
+			}
+			if msgs := f.mail.Messages(); len(msgs) != 0 {
+				t.Errorf("sent %d emails, want none", len(msgs))
+			}
+		})
+	}
+}
+
+func TestChangePasswordSignsOutOtherSessions(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+	user := f.createUser(t, "guest@example.com", "correct horse")
+
+	var others []*dto.SignIn_Success
+	for _, device := range []string{"phone", "laptop"} {
+		signIn, err := f.service.SignIn(ctx, dto.SignInRequest{Email: user.Email, Password: "correct horse", Device: device}, "192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		others = append(others, signIn)
+	}
+
+	if _, err := f.service.ChangePassword(ctx, user.UUID, dto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "battery staple"}); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("wrong current password error = %v", err)
+	}
+
+	// Revocation has millisecond resolution, like iat
+	time.Sleep(2 * time.Millisecond)
+	changed, err := f.service.ChangePassword(ctx, user.UUID, dto.ChangePasswordRequest{
+		CurrentPassword: "correct horse",
+		NewPassword:     "battery staple",
+		Device:          "desktop",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, other := range others {
+		if _, err := f.service.tokenService.ParseAccessToken(ctx, other.Token); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("other access token error = %v, want token revoked", err)
+		}
+		if _, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{RefreshToken: other.RefreshToken}); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("other refresh token error = %v, want invalid refresh token", err)
+		}
+	}
+
+	// The device that made the change stays signed in with its new pair
+	if _, err := f.service.tokenService.ParseAccessToken(ctx, changed.Token); err != nil {
+		t.Errorf("new access token: %v", err)
+	}
+	if _, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{RefreshToken: changed.RefreshToken}); err != nil {
+		t.Errorf("new refresh token: %v", err)
+	}
+
+	if _, err := f.service.SignIn(ctx, dto.SignInRequest{Email: user.Email, Password: "correct horse"}, "192.0.2.1"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("old password error = %v, want invalid credentials", err)
+	}
+}
@@ -1,165 +1,472 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"go-booking-system/internal/apperror"
 	"go-booking-system/internal/domain"
 	"go-booking-system/internal/dto"
+	"go-booking-system/internal/identity"
+	"go-booking-system/internal/mailer"
 	"go-booking-system/internal/repository"
-	"os"
+	"go-booking-system/internal/sms"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 // AccountService defines account management business logic
 type AccountService interface {
-	SignUp(req dto.SignUpRequest) (*dto.SignUp_Success, error)
-	SignIn(req dto.SignInRequest) (*dto.SignUp_Success, error)
-	GetProfile(uuid string) (*dto.UserResponse, error)
+	SignUp(ctx context.Context, req dto.SignUpRequest) (*dto.SignUp_Success, error)
+	SignIn(ctx context.Context, req dto.SignInRequest, clientIP string) (*dto.SignIn_Success, error)
+	GetProfile(ctx context.Context, uuid string) (*dto.UserResponse, error)
+	RefreshToken(ctx context.Context, req dto.RefreshTokenRequest) (*dto.RefreshToken_Success, error)
+	Logout(ctx context.Context, claims *AccessClaims) error
+	LogoutAll(ctx context.Context, userUUID string) error
+	VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) error
+	ResendVerificationEmail(ctx context.Context, userUUID string) error
+	ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest)
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error
+	UpdateProfile(ctx context.Context, uuid string, req dto.UpdateProfileRequest) (*dto.UserResponse, error)
+	ChangePassword(ctx context.Context, uuid string, req dto.ChangePasswordRequest) (*dto.ChangePassword_Success, error)
+	RequestEmailChange(ctx context.Context, uuid string, req dto.ChangeEmailRequest) error
+	ConfirmEmailChange(ctx context.Context, req dto.ConfirmEmailChangeRequest) error
+	DeleteAccount(ctx context.Context, uuid string, req dto.DeleteAccountRequest) error
+	ExportAccount(ctx context.Context, uuid string) (*dto.AccountExport, error)
+	AnonymizeDeletedAccounts(ctx context.Context, gracePeriod time.Duration) (int, error)
+	SetupTwoFactor(ctx context.Context, uuid string) (*dto.TwoFactorSetup_Success, error)
+	ConfirmTwoFactor(ctx context.Context, uuid string, req dto.TwoFactorCodeRequest) (*dto.RecoveryCodes_Success, error)
+	VerifyTwoFactor(ctx context.Context, req dto.TwoFactorVerifyRequest, clientIP string) (*dto.SignIn_Success, error)
+	DisableTwoFactor(ctx context.Context, uuid string, req dto.PasswordConfirmRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, uuid string, req dto.PasswordConfirmRequest) (*dto.RecoveryCodes_Success, error)
+	StartSocialSignIn(ctx context.Context, provider string) (*dto.SocialAuthorize_Success, error)
+	CompleteSocialSignIn(ctx context.Context, provider string, req dto.SocialCallbackRequest) (*dto.SignIn_Success, error)
+	PurgeExpiredSocialSignIns(ctx context.Context) (int64, error)
+	RequestMagicLink(ctx context.Context, req dto.MagicLinkRequest)
+	SignInWithMagicLink(ctx context.Context, req dto.MagicLinkVerifyRequest) (*dto.SignIn_Success, error)
+	RequestPhoneCode(ctx context.Context, req dto.PhoneCodeRequest) error
+	SignInWithPhoneCode(ctx context.Context, req dto.PhoneCodeVerifyRequest, clientIP string) (*dto.SignIn_Success, error)
+	RequestPhoneVerification(ctx context.Context, uuid string) error
+	VerifyPhone(ctx context.Context, uuid string, req dto.PhoneVerifyRequest) (*dto.UserResponse, error)
+	WaitForBackgroundWork(ctx context.Context) error
+}
+
+// backgroundTimeout bounds work that outlives the request that started it
+const backgroundTimeout = time.Second * 30
+
+// AccountSettings holds the deployment-specific parts of account management
+type AccountSettings struct {
+	// AppBaseURL is the frontend origin links in emails point to
+	AppBaseURL string
+	// RefreshTokenTTL is the lifetime of an opaque refresh token
+	RefreshTokenTTL time.Duration
+	// TOTPIssuer is the account label shown in authenticator apps
+	TOTPIssuer string
 }
 
 // accountService implements AccountService
 type accountService struct {
-	userRepo    repository.UserRepository
-	countryRepo repository.CountryRepository
+	userRepo     repository.UserRepository
+	countryRepo  repository.CountryRepository
+	sessionRepo  repository.SessionRepository
+	tokenRepo    repository.OneTimeTokenRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	identityRepo repository.IdentityRepository
+	roleRepo     repository.RoleRepository
+	auditRepo    repository.AuditRepository
+	providers    identity.Registry
+	tokenService TokenService
+	loginGuard   LoginGuard
+	mailer       mailer.Mailer
+	smsSender    sms.Sender
+	settings     AccountSettings
+
+	// background tracks work started by runInBackground
+	background sync.WaitGroup
 }
 
 // NewAccountService creates a new account service instance
 func NewAccountService(
 	userRepo repository.UserRepository,
 	countryRepo repository.CountryRepository,
+	sessionRepo repository.SessionRepository,
+	tokenRepo repository.OneTimeTokenRepository,
+	recoveryRepo repository.RecoveryCodeRepository,
+	identityRepo repository.IdentityRepository,
+	roleRepo repository.RoleRepository,
+	auditRepo repository.AuditRepository,
+	providers identity.Registry,
+	tokenService TokenService,
+	loginGuard LoginGuard,
+	mailer mailer.Mailer,
+	smsSender sms.Sender,
+	settings AccountSettings,
 ) AccountService {
 	return &accountService{
-		userRepo:    userRepo,
-		countryRepo: countryRepo,
+		userRepo:     userRepo,
+		countryRepo:  countryRepo,
+		sessionRepo:  sessionRepo,
+		tokenRepo:    tokenRepo,
+		recoveryRepo: recoveryRepo,
+		identityRepo: identityRepo,
+		roleRepo:     roleRepo,
+		auditRepo:    auditRepo,
+		providers:    providers,
+		tokenService: tokenService,
+		loginGuard:   loginGuard,
+		mailer:       mailer,
+		smsSender:    smsSender,
+		settings:     settings,
 	}
 }
 
 // SignUp registers a new user
-func (s *accountService) SignUp(req dto.SignUpRequest) (*dto.SignUp_Success, error) {
-	// Check if user already exists
-	existingUser, err := s.userRepo.FindByEmail(req.Email)
+func (s *accountService) SignUp(ctx context.Context, req dto.SignUpRequest) (*dto.SignUp_Success, error) {
+	// Check if user already exists; a deleted account keeps its address until anonymized
+	existingUser, err := s.userRepo.FindByEmailIncludingDeleted(ctx, req.Email)
 	if err == nil && existingUser != nil {
-		return nil, errors.New("email already registered")
+		return nil, ErrEmailTaken
 	}
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.New("failed to check existing user")
+		return nil, apperror.Internal("failed to check existing user", err)
 	}
 
-	// Get country ID if provided
+	// Resolve the country if provided; an unknown shortname is an input error
+	country, err := s.resolveCountry(ctx, req.Country)
+	if err != nil {
+		return nil, err
+	}
 	var mobileCountryID *uint
-	if req.Country != "" {
-		country, err := s.countryRepo.FindByShortname(req.Country)
+	if country != nil {
+		mobileCountryID = &country.ID
+	}
+
+	// Store the phone in E.164; national numbers are read with the country's plan
+	var phoneNumber string
+	if strings.TrimSpace(req.Phone) != "" {
+		number, err := parsePhone(req.Phone, country)
 		if err != nil {
-			// Country not found
-		} else {
-			mobileCountryID = &country.ID
+			return nil, err
 		}
+		phoneNumber = number.E164()
 	}
 
 	// Create user domain object
 	user := &domain.User{
 		Email:           req.Email,
 		Name:            req.Name,
-		Phone:           req.Phone,
+		Phone:           phoneNumber,
 		MobileCountryId: mobileCountryID,
 	}
 
 	// Hash password
 	if err := user.HashPassword(req.Password); err != nil {
-		return nil, errors.New("failed to process password")
+		return nil, apperror.Internal("failed to process password", err)
 	}
 
 	// Save to database via repository
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, errors.New("failed to create user")
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, apperror.Internal("failed to create user", err)
+	}
+
+	// Ask the user to confirm the address; they can request another link later
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		slog.WarnContext(ctx, "failed to send verification email", "user", user.UUID, "error", err)
 	}
 
-	// Generate JWT token
-	token, err := s.generateToken(user.UUID)
+	// Generate JWT token for a new refresh token family on this device
+	familyID := uuid.New().String()
+	token, err := s.generateToken(user, familyID)
 	if err != nil {
-		return nil, errors.New("failed to generate token")
+		return nil, apperror.Internal("failed to generate token", err)
+	}
+
+	// Start the refresh token family
+	refreshToken, err := s.issueRefreshToken(ctx, user.UUID, familyID, req.Device)
+	if err != nil {
+		return nil, apperror.Internal("failed to generate refresh token", err)
 	}
 
 	// Build response DTO
 	return &dto.SignUp_Success{
-		Message: "User registered successfully",
-		User: dto.UserResponse{
-			UUID:      user.UUID,
-			Email:     user.Email,
-			Name:      user.Name,
-			Phone:     user.Phone,
-			CreatedAt: user.CreatedAt.Format(time.RFC3339),
-		},
-		Token: token,
+		Message:      "User registered successfully",
+		User:         toUserResponse(user),
+		Token:        token,
+		RefreshToken: refreshToken,
 	}, nil
 }
 
 // SignIn authenticates a user
-func (s *accountService) SignIn(req dto.SignInRequest) (*dto.SignUp_Success, error) {
+func (s *accountService) SignIn(ctx context.Context, req dto.SignInRequest, clientIP string) (*dto.SignIn_Success, error) {
+	// Refuse to evaluate passwords while the account or client is locked out
+	if err := s.loginGuard.Check(ctx, req.Email, clientIP); err != nil {
+		var lockout *LockoutError
+		if errors.As(err, &lockout) {
+			return nil, lockout
+		}
+		return nil, apperror.Internal("failed to check sign-in attempts", err)
+	}
+
 	// Find user by email
-	user, err := s.userRepo.FindByEmail(req.Email)
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invalid credentials")
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.Internal("failed to find user", err)
 		}
-		return nil, errors.New("failed to find user")
+		// Spend the same bcrypt time as for a real account so response
+		// timing does not reveal which emails are registered
+		checkDummyPassword(req.Password)
+		return nil, s.failedSignIn(ctx, req.Email, clientIP)
 	}
 
 	// Check password
 	if err := user.CheckPassword(req.Password); err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, s.failedSignIn(ctx, req.Email, clientIP)
+	}
+
+	return s.beginSession(ctx, user, req.Device)
+}
+
+// beginSession continues a sign-in whose first factor (password, identity
+// provider, ...) checked out: it asks for a TOTP code when two-factor
+// authentication is on and issues tokens otherwise
+func (s *accountService) beginSession(ctx context.Context, user *domain.User, device string) (*dto.SignIn_Success, error) {
+	// The first factor alone is not enough when two-factor authentication is on
+	if user.IsTwoFactorEnabled() {
+		challenge, err := s.issueOneTimeToken(ctx, user.ID, domain.TokenPurposeTwoFactor, "", twoFactorChallengeTTL)
+		if err != nil {
+			return nil, apperror.Internal("failed to start two-factor sign-in", err)
+		}
+		return &dto.SignIn_Success{
+			Message:           "Two-factor authentication required",
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		}, nil
 	}
 
-	// Generate JWT token
-	token, err := s.generateToken(user.UUID)
+	return s.completeSignIn(ctx, user, device)
+}
+
+// completeSignIn clears failed attempts and issues tokens for a new session
+func (s *accountService) completeSignIn(ctx context.Context, user *domain.User, device string) (*dto.SignIn_Success, error) {
+	if err := s.loginGuard.RecordSuccess(ctx, user.Email); err != nil {
+		slog.WarnContext(ctx, "failed to reset sign-in attempts", "user", user.UUID, "error", err)
+	}
+
+	// Generate JWT token for a new refresh token family on this device
+	familyID := uuid.New().String()
+	token, err := s.generateToken(user, familyID)
+	if err != nil {
+		return nil, apperror.Internal("failed to generate token", err)
+	}
+
+	// Start the refresh token family
+	refreshToken, err := s.issueRefreshToken(ctx, user.UUID, familyID, device)
 	if err != nil {
-		return nil, errors.New("failed to generate token")
+		return nil, apperror.Internal("failed to generate refresh token", err)
 	}
 
 	// Build response DTO
-	return &dto.SignUp_Success{
-		Message: "Login successful",
-		User: dto.UserResponse{
-			UUID:      user.UUID,
-			Email:     user.Email,
-			Name:      user.Name,
-			Phone:     user.Phone,
-			CreatedAt: user.CreatedAt.Format(time.RFC3339),
-		},
-		Token: token,
+	response := toUserResponse(user)
+	return &dto.SignIn_Success{
+		Message:      "Login successful",
+		User:         &response,
+		Token:        token,
+		RefreshToken: refreshToken,
 	}, nil
 }
 
 // GetProfile retrieves user profile by UUID
-func (s *accountService) GetProfile(uuid string) (*dto.UserResponse, error) {
+func (s *accountService) GetProfile(ctx context.Context, uuid string) (*dto.UserResponse, error) {
 	// Find user by UUID
-	user, err := s.userRepo.FindByUUID(uuid)
+	user, err := s.userRepo.FindByUUID(ctx, uuid)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
-		return nil, errors.New("failed to retrieve user profile")
+		return nil, apperror.Internal("failed to retrieve user profile", err)
 	}
 
 	// Build response DTO
-	return &dto.UserResponse{
-		UUID:      user.UUID,
-		Email:     user.Email,
-		Name:      user.Name,
-		Phone:     user.Phone,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+	response := toUserResponse(user)
+	return &response, nil
+}
+
+// failedSignIn records a failed attempt and returns the error for the caller
+func (s *accountService) failedSignIn(ctx context.Context, email, clientIP string) error {
+	if err := s.loginGuard.RecordFailure(ctx, email, clientIP); err != nil {
+		slog.ErrorContext(ctx, "failed to record sign-in attempt", "error", err)
+	}
+	return ErrInvalidCredentials
+}
+
+// RefreshToken rotates a refresh token and issues a new access token.
+// Presenting a refresh token that was already rotated is treated as theft:
+// the whole session family is revoked and the user must sign in again.
+func (s *accountService) RefreshToken(ctx context.Context, req dto.RefreshTokenRequest) (*dto.RefreshToken_Success, error) {
+	// Look up the session by token hash; the raw token is never stored
+	session, err := s.sessionRepo.FindByTokenHash(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, apperror.Internal("failed to find session", err)
+	}
+
+	// A rotated token being replayed means someone else holds a copy
+	if session.RotatedAt != nil {
+		if err := s.sessionRepo.RevokeFamily(ctx, session.FamilyID); err != nil {
+			return nil, apperror.Internal("failed to revoke session", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil || session.IsExpired(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Mark the token as used; losing this race is also a replay
+	rotated, err := s.sessionRepo.MarkRotated(ctx, session.ID, now)
+	if err != nil {
+		return nil, apperror.Internal("failed to rotate session", err)
+	}
+	if !rotated {
+		if err := s.sessionRepo.RevokeFamily(ctx, session.FamilyID); err != nil {
+			return nil, apperror.Internal("failed to revoke session", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	// Make sure the user still exists before minting new tokens
+	user, err := s.userRepo.FindByUUID(ctx, session.UserUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+
+	token, err := s.generateToken(user, session.FamilyID)
+	if err != nil {
+		return nil, apperror.Internal("failed to generate token", err)
+	}
+
+	refreshToken, err := s.issueRefreshToken(ctx, user.UUID, session.FamilyID, session.DeviceLabel)
+	if err != nil {
+		return nil, apperror.Internal("failed to generate refresh token", err)
+	}
+
+	return &dto.RefreshToken_Success{
+		Message:      "Token refreshed",
+		Token:        token,
+		RefreshToken: refreshToken,
 	}, nil
 }
 
-// generateToken creates a JWT token for the user
-func (s *accountService) generateToken(UUID string) (string, error) {
-	claims := jwt.MapClaims{
-		"uuid": UUID,
-		"exp":  time.Now().Add(time.Hour * 1).Unix(), // 1 hour
+// Logout revokes the presented access token and the refresh token family it belongs to
+func (s *accountService) Logout(ctx context.Context, claims *AccessClaims) error {
+	if err := s.tokenService.RevokeAccessToken(ctx, claims); err != nil {
+		return apperror.Internal("failed to revoke token", err)
+	}
+
+	if claims.SessionID != "" {
+		if err := s.sessionRepo.RevokeFamily(ctx, claims.SessionID); err != nil {
+			return apperror.Internal("failed to revoke session", err)
+		}
+	}
+
+	return nil
+}
+
+// LogoutAll revokes every access and refresh token the user holds
+func (s *accountService) LogoutAll(ctx context.Context, userUUID string) error {
+	if err := s.tokenService.RevokeAllAccessTokens(ctx, userUUID); err != nil {
+		return apperror.Internal("failed to revoke tokens", err)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err := s.sessionRepo.RevokeAllForUser(ctx, userUUID); err != nil {
+		return apperror.Internal("failed to revoke sessions", err)
+	}
+
+	return nil
+}
+
+// issueRefreshToken creates a session in the given family and returns the raw token
+func (s *accountService) issueRefreshToken(ctx context.Context, userUUID, familyID, deviceLabel string) (string, error) {
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	session := &domain.Session{
+		UserUUID:    userUUID,
+		FamilyID:    familyID,
+		TokenHash:   hashToken(refreshToken),
+		DeviceLabel: deviceLabel,
+		ExpiresAt:   time.Now().Add(s.settings.RefreshTokenTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return "", err
+	}
+
+	return refreshToken, nil
+}
+
+// generateToken creates a JWT token for the user bound to a session family
+func (s *accountService) generateToken(user *domain.User, sessionID string) (string, error) {
+	return s.tokenService.GenerateAccessToken(user, sessionID)
+}
+
+// runInBackground runs fn after the response has been sent. fn keeps the
+// request context's values but not its cancellation, and gets its own deadline.
+func (s *accountService) runInBackground(ctx context.Context, action string, fn func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		ctx, cancel := context.WithTimeout(ctx, backgroundTimeout)
+		defer cancel()
+		if err := fn(ctx); err != nil {
+			slog.ErrorContext(ctx, "failed to "+action, "error", err)
+		}
+	}()
+}
+
+// WaitForBackgroundWork waits for emails and texts still being sent after
+// their response, or until ctx expires. Call it on shutdown once the server
+// has stopped taking requests.
+func (s *accountService) WaitForBackgroundWork(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// toUserResponse maps a user to its API representation
+func toUserResponse(user *domain.User) dto.UserResponse {
+	return dto.UserResponse{
+		UUID:             user.UUID,
+		Email:            user.Email,
+		Name:             user.Name,
+		Phone:            user.Phone,
+		PhoneNational:    nationalPhone(user.Phone),
+		PhoneVerified:    user.IsPhoneVerified(),
+		EmailVerified:    user.IsEmailVerified(),
+		TwoFactorEnabled: user.IsTwoFactorEnabled(),
+		Roles:            roleStrings(user.RoleNames()),
+		CreatedAt:        user.CreatedAt.Format(time.RFC3339),
+	}
 }
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// racingSessionRepo lets another request rotate the session between the
+// lookup and the rotation of a refresh
+type racingSessionRepo struct {
+	*fakeSessionRepo
+}
+
+func (r racingSessionRepo) FindByTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error) {
+	session, err := r.fakeSessionRepo.FindByTokenHash(ctx, tokenHash)
+	if err == nil {
+		_, err = r.fakeSessionRepo.MarkRotated(ctx, session.ID, time.Now())
+	}
+	return session, err
+}
+
+func TestRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// prepare runs after sign-in and returns the refresh token to present
+		prepare func(t *testing.T, f *accountFixture, refreshToken string) string
+		wantErr error
+		// familyRevoked is whether the sign-in's sessions end up revoked
+		familyRevoked bool
+	}{
+		{
+			name:    "valid token rotates",
+			prepare: func(t *testing.T, f *accountFixture, refreshToken string) string { return refreshToken },
+		},
+		{
+			name: "rotated token is reuse",
+			prepare: func(t *testing.T, f *accountFixture, refreshToken string) string {
+				if _, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{RefreshToken: refreshToken}); err != nil {
+					t.Fatalf("first refresh: %v", err)
+				}
+				return refreshToken
+			},
+			wantErr:       ErrRefreshTokenReused,
+			familyRevoked: true,
+		},
+		{
+			name: "lost rotation race is reuse",
+			prepare: func(t *testing.T, f *accountFixture, refreshToken string) string {
+				f.service.sessionRepo = racingSessionRepo{f.sessions}
+				return refreshToken
+			},
+			wantErr:       ErrRefreshTokenReused,
+			familyRevoked: true,
+		},
+		{
+			name: "revoked token",
+			prepare: func(t *testing.T, f *accountFixture, refreshToken string) string {
+				f.sessions.sessions[0].RevokedAt = ptr(time.Now())
+				return refreshToken
+			},
+			wantErr:       ErrInvalidRefreshToken,
+			familyRevoked: true,
+		},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, f *accountFixture, refreshToken string) string {
+				f.sessions.sessions[0].ExpiresAt = time.Now().Add(-time.Second)
+				return refreshToken
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name:    "unknown token",
+			prepare: func(t *testing.T, f *accountFixture, refreshToken string) string { return "not-a-token" },
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "deleted user",
+			prepare: func(t *testing.T, f *accountFixture, refreshToken string) string {
+				if err := f.users.Delete(ctx, 1); err != nil {
+					t.Fatal(err)
+				}
+				return refreshToken
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			user := f.createUser(t, "guest@example.com", "correct horse")
+
+			signIn, err := f.service.SignIn(ctx, dto.SignInRequest{Email: user.Email, Password: "correct horse"}, "192.0.2.1")
+			if err != nil {
+				t.Fatalf("SignIn: %v", err)
+			}
+
+			refreshed, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{
+				RefreshToken: tt.prepare(t, f, signIn.RefreshToken),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RefreshToken error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil {
+				if refreshed.RefreshToken == "" || refreshed.RefreshToken == signIn.RefreshToken {
+					t.Errorf("refresh token was not rotated")
+				}
+				claims := jwt.MapClaims{}
+				if _, err := jwt.ParseWithClaims(refreshed.Token, claims, func(*jwt.Token) (any, error) {
+					return []byte("test-secret"), nil
+				}); err != nil || claims["uuid"] != user.UUID {
+					t.Errorf("new access token does not parse for the user: %v", err)
+				}
+			}
+
+			for _, session := range f.sessions.sessions {
+				if revoked := session.RevokedAt != nil; revoked != tt.familyRevoked {
+					t.Errorf("session %d revoked = %v, want %v", session.ID, revoked, tt.familyRevoked)
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshTokenReuseKeepsOtherFamilies(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+	user := f.createUser(t, "guest@example.com", "correct horse")
+
+	phone, err := f.service.SignIn(ctx, dto.SignInRequest{Email: user.Email, Password: "correct horse", Device: "phone"}, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	laptop, err := f.service.SignIn(ctx, dto.SignInRequest{Email: user.Email, Password: "correct horse", Device: "laptop"}, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{RefreshToken: phone.RefreshToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{RefreshToken: phone.RefreshToken}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("replay error = %v, want refresh token reuse detected", err)
+	}
+
+	// The thief's copy and the rightful successor both die with the family
+	if _, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{RefreshToken: rotated.RefreshToken}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("successor error = %v, want invalid refresh token", err)
+	}
+	// Other devices stay signed in
+	if _, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{RefreshToken: laptop.RefreshToken}); err != nil {
+		t.Errorf("other family: %v", err)
+	}
+}
+
+func TestWaitForBackgroundWork(t *testing.T) {
+	f := newAccountFixture(t)
+
+	// The work outlives the request that started it
+	request, cancelRequest := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	var workErr error
+	f.service.runInBackground(request, "test work", func(ctx context.Context) error {
+		<-release
+		workErr = ctx.Err()
+		return nil
+	})
+	cancelRequest()
+
+	// Gives up when its own context ends first
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := f.service.WaitForBackgroundWork(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait error = %v, want deadline exceeded", err)
+	}
+
+	// Otherwise blocks until the work is done
+	done := make(chan error, 1)
+	go func() { done <- f.service.WaitForBackgroundWork(context.Background()) }()
+	select {
+	case err := <-done:
+		t.Fatalf("returned %v before the work finished", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("wait error = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("still waiting after the work finished")
+	}
+	if workErr != nil {
+		t.Errorf("work context error = %v, want none after the request was cancelled", workErr)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/identity"
+	"go-booking-system/internal/repository"
+	"log/slog"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+const (
+	// socialSignInTTL is how long the user has to finish at the identity provider
+	socialSignInTTL = time.Minute * 10
+	// providerExchangeTimeout bounds the call to the provider's token endpoint
+	providerExchangeTimeout = time.Second * 10
+)
+
+// StartSocialSignIn creates the state, nonce and PKCE verifier for a sign-in
+// at an identity provider and returns where to send the browser
+func (s *accountService) StartSocialSignIn(ctx context.Context, provider string) (*dto.SocialAuthorize_Success, error) {
+	idp, err := s.providers.Get(provider)
+	if err != nil {
+		return nil, ErrUnknownProvider
+	}
+
+	state, err := generateOpaqueToken()
+	if err != nil {
+		return nil, apperror.Internal("failed to start sign-in", err)
+	}
+	nonce, err := generateOpaqueToken()
+	if err != nil {
+		return nil, apperror.Internal("failed to start sign-in", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	if err := s.identityRepo.CreateState(ctx, &domain.OAuthState{
+		StateHash:    hashToken(state),
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(socialSignInTTL),
+	}); err != nil {
+		return nil, apperror.Internal("failed to start sign-in", err)
+	}
+
+	return &dto.SocialAuthorize_Success{
+		AuthorizationURL: idp.AuthCodeURL(state, nonce, verifier),
+		State:            state,
+	}, nil
+}
+
+// CompleteSocialSignIn handles the provider's callback. A known identity signs
+// in its user; otherwise the identity is linked to the account with the same
+// verified email, or a new account is created.
+func (s *accountService) CompleteSocialSignIn(ctx context.Context, provider string, req dto.SocialCallbackRequest) (*dto.SignIn_Success, error) {
+	idp, err := s.providers.Get(provider)
+	if err != nil {
+		return nil, ErrUnknownProvider
+	}
+
+	// The state ties the callback to a sign-in this server started
+	state, err := s.identityRepo.ConsumeState(ctx, provider, hashToken(req.State), time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSignInState
+		}
+		return nil, apperror.Internal("failed to complete sign-in", err)
+	}
+
+	exchangeCtx, cancel := context.WithTimeout(ctx, providerExchangeTimeout)
+	defer cancel()
+	claims, err := idp.Exchange(exchangeCtx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		slog.WarnContext(ctx, "identity provider rejected sign-in", "provider", provider, "error", err)
+		return nil, ErrProviderSignInFailed
+	}
+
+	user, err := s.findOrLinkUser(ctx, provider, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.beginSession(ctx, user, req.Device)
+}
+
+// PurgeExpiredSocialSignIns deletes sign-in states that can no longer be used
+func (s *accountService) PurgeExpiredSocialSignIns(ctx context.Context) (int64, error) {
+	return s.identityRepo.DeleteExpiredStates(ctx, time.Now())
+}
+
+// findOrLinkUser resolves the user behind a provider identity
+func (s *accountService) findOrLinkUser(ctx context.Context, provider string, claims *identity.Claims) (*domain.User, error) {
+	user, err := s.findLinkedUser(ctx, provider, claims.Subject)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	// Only an address the provider has verified may be matched to an account
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrProviderEmailNotVerified
+	}
+
+	user, err = s.userRepo.FindByEmailIncludingDeleted(ctx, claims.Email)
+	switch {
+	case err == nil:
+		if user.DeletedAt.Valid {
+			return nil, ErrAccountDeleted
+		}
+		// Linking to an unconfirmed account would hand it to whoever
+		// registered the address first, so the owner must verify it
+		if !user.IsEmailVerified() {
+			return nil, ErrEmailNotVerified
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user, err = s.createSocialUser(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, apperror.Internal("failed to find user", err)
+	}
+
+	now := time.Now()
+	err = s.identityRepo.Create(ctx, &domain.LinkedIdentity{
+		UserID:     user.ID,
+		Provider:   provider,
+		Subject:    claims.Subject,
+		Email:      claims.Email,
+		LastUsedAt: &now,
+	})
+	switch {
+	case errors.Is(err, repository.ErrSubjectLinked):
+		// A parallel callback for the same identity linked it first
+		return s.findLinkedUser(ctx, provider, claims.Subject)
+	case errors.Is(err, repository.ErrProviderLinked):
+		// The account already signs in with another identity of this provider
+		return nil, ErrProviderAlreadyLinked
+	case err != nil:
+		return nil, apperror.Internal("failed to link identity", err)
+	}
+
+	return user, nil
+}
+
+// findLinkedUser returns the user a provider identity is linked to, or
+// gorm.ErrRecordNotFound if the identity is not linked yet
+func (s *accountService) findLinkedUser(ctx context.Context, provider, subject string) (*domain.User, error) {
+	linked, err := s.identityRepo.FindByProviderSubject(ctx, provider, subject)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		return nil, apperror.Internal("failed to find linked identity", err)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, linked.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountDeleted
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+	if err := s.identityRepo.MarkUsed(ctx, linked.ID, time.Now()); err != nil {
+		slog.WarnContext(ctx, "failed to record sign-in for identity", "identity", linked.ID, "error", err)
+	}
+	return user, nil
+}
+
+// createSocialUser registers an account for a first-time provider sign-in.
+// It gets an unusable random password; "forgot password" sets a real one.
+func (s *accountService) createSocialUser(ctx context.Context, claims *identity.Claims) (*domain.User, error) {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	password, err := generateOpaqueToken()
+	if err != nil {
+		return nil, apperror.Internal("failed to process password", err)
+	}
+
+	now := time.Now()
+	user := &domain.User{
+		Email:           claims.Email,
+		Name:            name,
+		EmailVerifiedAt: &now,
+	}
+	if err := user.HashPassword(password); err != nil {
+		return nil, apperror.Internal("failed to process password", err)
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, apperror.Internal("failed to create user", err)
+	}
+	return user, nil
+}
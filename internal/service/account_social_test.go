@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/identity"
+	"go-booking-system/internal/identity/identitytest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// withIssuer registers a fake OpenID Connect issuer as the "test" provider
+func (f *accountFixture) withIssuer(t *testing.T) *identitytest.Issuer {
+	t.Helper()
+
+	issuer := identitytest.NewIssuer(t)
+	provider, err := identity.NewOIDCProvider(context.Background(), identity.OIDCConfig{
+		Issuer:       issuer.URL,
+		ClientID:     "booking-app",
+		ClientSecret: "client-secret",
+		RedirectURL:  "https://app.example.com/auth/test/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.service.providers = identity.Registry{"test": provider}
+	return issuer
+}
+
+// socialSignIn starts a sign-in and approves it at the issuer as user,
+// returning the callback the browser would make
+func (f *accountFixture) socialSignIn(t *testing.T, issuer *identitytest.Issuer, user identitytest.User) dto.SocialCallbackRequest {
+	t.Helper()
+
+	start, err := f.service.StartSocialSignIn(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dto.SocialCallbackRequest{
+		Code:  issuer.Authorize(t, start.AuthorizationURL, user),
+		State: start.State,
+	}
+}
+
+func TestCompleteSocialSignIn(t *testing.T) {
+	ctx := context.Background()
+	verified := identitytest.User{Subject: "sub-123", Email: "guest@example.com", EmailVerified: true, Name: "Guest"}
+
+	tests := []struct {
+		name string
+		// existing registers a local account for the address first
+		existing         bool
+		existingVerified bool
+		user             identitytest.User
+		// tamper changes the callback or the stored sign-in state
+		tamper  func(f *accountFixture, callback *dto.SocialCallbackRequest)
+		wantErr error
+		// wantUserID is the account signed in to; 0 means a new one
+		wantUserID uint
+	}{
+		{
+			name: "new account",
+			user: verified,
+		},
+		{
+			name:             "links to verified account",
+			existing:         true,
+			existingVerified: true,
+			user:             verified,
+			wantUserID:       1,
+		},
+		{
+			name:     "refuses unverified account",
+			existing: true,
+			user:     verified,
+			wantErr:  ErrEmailNotVerified,
+		},
+		{
+			name:             "refuses address the provider has not verified",
+			existing:         true,
+			existingVerified: true,
+			user:             identitytest.User{Subject: "sub-123", Email: "guest@example.com"},
+			wantErr:          ErrProviderEmailNotVerified,
+		},
+		{
+			name:    "bad nonce",
+			user:    identitytest.User{Subject: "sub-123", Email: "guest@example.com", EmailVerified: true, Nonce: "replayed-nonce"},
+			wantErr: ErrProviderSignInFailed,
+		},
+		{
+			name: "PKCE verifier mismatch",
+			user: verified,
+			tamper: func(f *accountFixture, callback *dto.SocialCallbackRequest) {
+				f.identities.states[0].CodeVerifier = oauth2.GenerateVerifier()
+			},
+			wantErr: ErrProviderSignInFailed,
+		},
+		{
+			name: "expired state",
+			user: verified,
+			tamper: func(f *accountFixture, callback *dto.SocialCallbackRequest) {
+				f.identities.states[0].ExpiresAt = time.Now()
+			},
+			wantErr: ErrInvalidSignInState,
+		},
+		{
+			name: "unknown state",
+			user: verified,
+			tamper: func(f *accountFixture, callback *dto.SocialCallbackRequest) {
+				callback.State = "not-a-state"
+			},
+			wantErr: ErrInvalidSignInState,
+		},
+		{
+			name: "state of another provider",
+			user: verified,
+			tamper: func(f *accountFixture, callback *dto.SocialCallbackRequest) {
+				f.identities.states[0].Provider = "other"
+			},
+			wantErr: ErrInvalidSignInState,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			issuer := f.withIssuer(t)
+
+			if tt.existing {
+				user := f.createUser(t, "guest@example.com", "correct horse")
+				if tt.existingVerified {
+					now := time.Now()
+					user.EmailVerifiedAt = &now
+					if err := f.users.Update(ctx, user); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			callback := f.socialSignIn(t, issuer, tt.user)
+			if tt.tamper != nil {
+				tt.tamper(f, &callback)
+			}
+
+			signIn, err := f.service.CompleteSocialSignIn(ctx, "test", callback)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompleteSocialSignIn error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(f.identities.identities) != 0 {
+					t.Errorf("linked identities = %+v, want none", f.identities.identities)
+				}
+				return
+			}
+
+			if signIn.Token == "" || signIn.RefreshToken == "" {
+				t.Errorf("sign-in = %+v, want a token pair", signIn)
+			}
+			user, err := f.users.FindByEmail(ctx, "guest@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantUserID != 0 && user.ID != tt.wantUserID {
+				t.Errorf("signed in to user %d, want %d", user.ID, tt.wantUserID)
+			}
+			if !user.IsEmailVerified() {
+				t.Error("account email is not verified")
+			}
+			linked, err := f.identities.FindByProviderSubject(ctx, "test", "sub-123")
+			if err != nil || linked.UserID != user.ID {
+				t.Errorf("linked identity = %+v, %v; want one for user %d", linked, err, user.ID)
+			}
+		})
+	}
+}
+
+func TestCompleteSocialSignInKnownIdentity(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+	issuer := f.withIssuer(t)
+	user := identitytest.User{Subject: "sub-123", Email: "guest@example.com", EmailVerified: true}
+
+	callback := f.socialSignIn(t, issuer, user)
+	if _, err := f.service.CompleteSocialSignIn(ctx, "test", callback); err != nil {
+		t.Fatal(err)
+	}
+
+	// The state is used up with the first callback
+	if _, err := f.service.CompleteSocialSignIn(ctx, "test", callback); !errors.Is(err, ErrInvalidSignInState) {
+		t.Errorf("replayed callback error = %v, want invalid or expired sign-in state", err)
+	}
+
+	// The provider may report another address later; the subject decides
+	user.Email = "renamed@example.com"
+	if _, err := f.service.CompleteSocialSignIn(ctx, "test", f.socialSignIn(t, issuer, user)); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.users.users) != 1 || len(f.identities.identities) != 1 {
+		t.Errorf("users = %d, identities = %d; want one of each", len(f.users.users), len(f.identities.identities))
+	}
+
+	// Deleting the account closes the door for its identity too
+	if err := f.users.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.CompleteSocialSignIn(ctx, "test", f.socialSignIn(t, issuer, user)); !errors.Is(err, ErrAccountDeleted) {
+		t.Errorf("deleted account error = %v, want account has been deleted", err)
+	}
+}
+
+// racingIdentityRepo links an identity just before the first Create, as a
+// parallel callback for the same sign-in would
+type racingIdentityRepo struct {
+	*fakeIdentityRepo
+	first *domain.LinkedIdentity
+}
+
+func (r *racingIdentityRepo) Create(ctx context.Context, identity *domain.LinkedIdentity) error {
+	if r.first != nil {
+		if err := r.fakeIdentityRepo.Create(ctx, r.first); err != nil {
+			return err
+		}
+		r.first = nil
+	}
+	return r.fakeIdentityRepo.Create(ctx, identity)
+}
+
+func TestCompleteSocialSignInLinkConflicts(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare runs once the account exists and before the callback
+		prepare        func(f *accountFixture, user *domain.User)
+		wantErr        error
+		wantIdentities int
+	}{
+		{
+			name: "account linked to another identity of the provider",
+			prepare: func(f *accountFixture, user *domain.User) {
+				f.identities.identities = append(f.identities.identities, domain.LinkedIdentity{
+					ID: 1, UserID: user.ID, Provider: "test", Subject: "sub-old", Email: user.Email,
+				})
+			},
+			wantErr:        ErrProviderAlreadyLinked,
+			wantIdentities: 1,
+		},
+		{
+			name: "parallel callback linked the identity first",
+			prepare: func(f *accountFixture, user *domain.User) {
+				f.service.identityRepo = &racingIdentityRepo{
+					fakeIdentityRepo: f.identities,
+					first:            &domain.LinkedIdentity{UserID: user.ID, Provider: "test", Subject: "sub-123", Email: user.Email},
+				}
+			},
+			wantIdentities: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			issuer := f.withIssuer(t)
+			user := f.createUser(t, "guest@example.com", "correct horse")
+			user.EmailVerifiedAt = ptr(time.Now())
+			if err := f.users.Update(context.Background(), user); err != nil {
+				t.Fatal(err)
+			}
+			tt.prepare(f, user)
+
+			callback := f.socialSignIn(t, issuer, identitytest.User{Subject: "sub-123", Email: user.Email, EmailVerified: true})
+			signIn, err := f.service.CompleteSocialSignIn(context.Background(), "test", callback)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompleteSocialSignIn error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && signIn.User.UUID != user.UUID {
+				t.Errorf("signed in as %s, want %s", signIn.User.UUID, user.UUID)
+			}
+			if len(f.identities.identities) != tt.wantIdentities {
+				t.Errorf("linked identities = %+v, want %d", f.identities.identities, tt.wantIdentities)
+			}
+		})
+	}
+}
+
+func TestStartSocialSignInStoresState(t *testing.T) {
+	f := newAccountFixture(t)
+	f.withIssuer(t)
+
+	start, err := f.service.StartSocialSignIn(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.StartSocialSignIn(context.Background(), "unknown"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("unknown provider error = %v, want unknown identity provider", err)
+	}
+
+	if len(f.identities.states) != 1 {
+		t.Fatalf("states = %d, want 1", len(f.identities.states))
+	}
+	state := f.identities.states[0]
+	// Only a hash of the state is kept
+	if state.StateHash != hashToken(start.State) || state.StateHash == start.State {
+		t.Errorf("state hash = %q, want the hash of %q", state.StateHash, start.State)
+	}
+	if ttl := time.Until(state.ExpiresAt); ttl <= 0 || ttl > socialSignInTTL {
+		t.Errorf("state expires in %v, want within %v", ttl, socialSignInTTL)
+	}
+}
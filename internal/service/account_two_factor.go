@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	// twoFactorChallengeTTL is how long a password-verified sign-in waits for its TOTP code
+	twoFactorChallengeTTL = time.Minute * 5
+	// totpPeriod and totpSkew accept the current code and one on either side of it
+	totpPeriod = 30
+	totpSkew   = 1
+	// recoveryCodeCount is how many recovery codes are issued at a time
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet leaves out characters that are easy to misread (0/o, 1/l/i)
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// SetupTwoFactor generates a new TOTP secret. It only takes effect once
+// ConfirmTwoFactor proves the authenticator app has it.
+func (s *accountService) SetupTwoFactor(ctx context.Context, userUUID string) (*dto.TwoFactorSetup_Success, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+
+	if user.IsTwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.settings.TOTPIssuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return nil, apperror.Internal("failed to generate two-factor secret", err)
+	}
+
+	user.TOTPSecret = key.Secret()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, apperror.Internal("failed to set up two-factor authentication", err)
+	}
+
+	return &dto.TwoFactorSetup_Success{
+		Secret:     key.Secret(),
+		OtpauthURI: key.URL(),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor sign-in once the user proves their
+// authenticator produces valid codes, and returns the first recovery codes
+func (s *accountService) ConfirmTwoFactor(ctx context.Context, userUUID string, req dto.TwoFactorCodeRequest) (*dto.RecoveryCodes_Success, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+
+	if user.IsTwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorSetupNotStarted
+	}
+
+	step, ok := matchTOTP(user.TOTPSecret, req.Code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, ErrInvalidSetupCode
+	}
+
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	user.TOTPLastStep = step
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, apperror.Internal("failed to enable two-factor authentication", err)
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, apperror.Internal("failed to generate recovery codes", err)
+	}
+
+	return &dto.RecoveryCodes_Success{
+		Message:       "Two-factor authentication enabled",
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// VerifyTwoFactor completes a sign-in started by SignIn with a TOTP or recovery code
+func (s *accountService) VerifyTwoFactor(ctx context.Context, req dto.TwoFactorVerifyRequest, clientIP string) (*dto.SignIn_Success, error) {
+	challenge, err := s.tokenRepo.FindByHash(ctx, domain.TokenPurposeTwoFactor, hashToken(req.ChallengeToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidChallengeToken
+		}
+		return nil, apperror.Internal("failed to verify two-factor code", err)
+	}
+	if !challenge.IsUsable(time.Now()) {
+		return nil, ErrInvalidChallengeToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidChallengeToken
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+	if !user.IsTwoFactorEnabled() {
+		return nil, ErrInvalidChallengeToken
+	}
+
+	// Codes are short, so guesses count against the same lockout as passwords
+	if err := s.loginGuard.Check(ctx, user.Email, clientIP); err != nil {
+		var lockout *LockoutError
+		if errors.As(err, &lockout) {
+			return nil, lockout
+		}
+		return nil, apperror.Internal("failed to check sign-in attempts", err)
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user, req.Code)
+	if err != nil {
+		return nil, apperror.Internal("failed to verify two-factor code", err)
+	}
+	if !ok {
+		if err := s.loginGuard.RecordFailure(ctx, user.Email, clientIP); err != nil {
+			return nil, apperror.Internal("failed to verify two-factor code", err)
+		}
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	// The challenge stays usable across typos but completes only one sign-in
+	consumed, err := s.tokenRepo.Consume(ctx, challenge.ID, time.Now())
+	if err != nil {
+		return nil, apperror.Internal("failed to verify two-factor code", err)
+	}
+	if !consumed {
+		return nil, ErrInvalidChallengeToken
+	}
+
+	return s.completeSignIn(ctx, user, req.Device)
+}
+
+// DisableTwoFactor turns two-factor sign-in off after confirming the password
+func (s *accountService) DisableTwoFactor(ctx context.Context, userUUID string, req dto.PasswordConfirmRequest) error {
+	user, err := s.findUserWithPassword(ctx, userUUID, req.Password)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsTwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return apperror.Internal("failed to disable two-factor authentication", err)
+	}
+
+	if err := s.recoveryRepo.DeleteAllForUser(ctx, user.ID); err != nil {
+		return apperror.Internal("failed to disable two-factor authentication", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after confirming the password
+func (s *accountService) RegenerateRecoveryCodes(ctx context.Context, userUUID string, req dto.PasswordConfirmRequest) (*dto.RecoveryCodes_Success, error) {
+	user, err := s.findUserWithPassword(ctx, userUUID, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsTwoFactorEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, apperror.Internal("failed to generate recovery codes", err)
+	}
+
+	return &dto.RecoveryCodes_Success{
+		Message:       "Recovery codes regenerated",
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// findUserWithPassword loads the user and checks their current password
+func (s *accountService) findUserWithPassword(ctx context.Context, userUUID, password string) (*domain.User, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+
+	if err := user.CheckPassword(password); err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	return user, nil
+}
+
+// checkSecondFactor accepts either an unused TOTP code or an unused recovery code
+func (s *accountService) checkSecondFactor(ctx context.Context, user *domain.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if step, ok := matchTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		// Losing this race means the same code was just used elsewhere
+		return s.userRepo.AdvanceTOTPStep(ctx, user.ID, step)
+	}
+
+	return s.recoveryRepo.Consume(ctx, user.ID, hashToken(normalizeRecoveryCode(code)), time.Now())
+}
+
+// replaceRecoveryCodes generates a fresh set of recovery codes and stores their hashes
+func (s *accountService) replaceRecoveryCodes(ctx context.Context, userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := s.recoveryRepo.ReplaceForUser(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// matchTOTP checks code against the time steps around now, skipping steps at
+// or before lastStep, and returns the step that matched
+func matchTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	if secret == "" || len(code) != int(otp.DigitsSix) {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// generateRecoveryCode returns a random code formatted as xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeRecoveryCode ignores case, dashes and spaces the user may type
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
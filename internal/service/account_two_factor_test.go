@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+// totpCodeAt returns the code for the time step step periods away from now
+func totpCodeAt(t *testing.T, secret string, now time.Time, step int) string {
+	t.Helper()
+	code, err := totp.GenerateCode(secret, now.Add(time.Duration(step*totpPeriod)*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Unix(1_700_000_010, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", testTOTPSecret, totpCodeAt(t, testTOTPSecret, now, 0), 0, current, true},
+		{"previous step", testTOTPSecret, totpCodeAt(t, testTOTPSecret, now, -1), 0, current - 1, true},
+		{"next step", testTOTPSecret, totpCodeAt(t, testTOTPSecret, now, 1), 0, current + 1, true},
+		{"outside skew", testTOTPSecret, totpCodeAt(t, testTOTPSecret, now, -2), 0, 0, false},
+		{"replayed step", testTOTPSecret, totpCodeAt(t, testTOTPSecret, now, 0), current, 0, false},
+		{"earlier than last step", testTOTPSecret, totpCodeAt(t, testTOTPSecret, now, -1), current, 0, false},
+		{"later than last step", testTOTPSecret, totpCodeAt(t, testTOTPSecret, now, 1), current, current + 1, true},
+		{"wrong length", testTOTPSecret, "12345", 0, 0, false},
+		{"no secret", "", totpCodeAt(t, testTOTPSecret, now, 0), 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := matchTOTP(tt.secret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("matchTOTP = %d, %v; want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// enableTwoFactor turns on two-factor sign-in for user and returns the secret
+// and the recovery codes
+func (f *accountFixture) enableTwoFactor(t *testing.T, user *domain.User) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	setup, err := f.service.SetupTwoFactor(ctx, user.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmed, err := f.service.ConfirmTwoFactor(ctx, user.UUID, dto.TwoFactorCodeRequest{Code: totpCodeAt(t, setup.Secret, time.Now(), 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return setup.Secret, confirmed.RecoveryCodes
+}
+
+// challenge signs in with the password and returns the two-factor challenge token
+func (f *accountFixture) challenge(t *testing.T, email, password string) string {
+	t.Helper()
+
+	signIn, err := f.service.SignIn(context.Background(), dto.SignInRequest{Email: email, Password: password}, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signIn.TwoFactorRequired || signIn.Token != "" {
+		t.Fatalf("sign-in = %+v, want only a challenge", signIn)
+	}
+	return signIn.ChallengeToken
+}
+
+func TestVerifyTwoFactor(t *testing.T) {
+	tests := []struct {
+		name string
+		// code returns the code to present, after any earlier sign-ins
+		code    func(t *testing.T, f *accountFixture, secret string, recovery []string) string
+		wantErr error
+	}{
+		{
+			name: "code of the next step",
+			code: func(t *testing.T, f *accountFixture, secret string, recovery []string) string {
+				return totpCodeAt(t, secret, time.Now(), 1)
+			},
+		},
+		{
+			name: "code already used to confirm setup",
+			code: func(t *testing.T, f *accountFixture, secret string, recovery []string) string {
+				confirmedAt := time.Unix(f.users.users[1].TOTPLastStep*totpPeriod, 0)
+				return totpCodeAt(t, secret, confirmedAt, 0)
+			},
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "code replayed after sign-in",
+			code: func(t *testing.T, f *accountFixture, secret string, recovery []string) string {
+				code := totpCodeAt(t, secret, time.Now(), 1)
+				if _, err := f.service.VerifyTwoFactor(context.Background(), dto.TwoFactorVerifyRequest{
+					ChallengeToken: f.challenge(t, "guest@example.com", "correct horse"), Code: code,
+				}, "192.0.2.1"); err != nil {
+					t.Fatalf("first sign-in: %v", err)
+				}
+				return code
+			},
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "recovery code",
+			code: func(t *testing.T, f *accountFixture, secret string, recovery []string) string {
+				return recovery[0]
+			},
+		},
+		{
+			name: "recovery code typed loosely",
+			code: func(t *testing.T, f *accountFixture, secret string, recovery []string) string {
+				return " " + strings.ToUpper(strings.ReplaceAll(recovery[0], "-", " ")) + " "
+			},
+		},
+		{
+			name: "recovery code used twice",
+			code: func(t *testing.T, f *accountFixture, secret string, recovery []string) string {
+				if _, err := f.service.VerifyTwoFactor(context.Background(), dto.TwoFactorVerifyRequest{
+					ChallengeToken: f.challenge(t, "guest@example.com", "correct horse"), Code: recovery[0],
+				}, "192.0.2.1"); err != nil {
+					t.Fatalf("first sign-in: %v", err)
+				}
+				return recovery[0]
+			},
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "wrong code",
+			code: func(t *testing.T, f *accountFixture, secret string, recovery []string) string {
+				return "abcde-fghjk"
+			},
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			user := f.createUser(t, "guest@example.com", "correct horse")
+			secret, recovery := f.enableTwoFactor(t, user)
+
+			code := tt.code(t, f, secret, recovery)
+			challenge := f.challenge(t, user.Email, "correct horse")
+
+			signIn, err := f.service.VerifyTwoFactor(context.Background(), dto.TwoFactorVerifyRequest{ChallengeToken: challenge, Code: code}, "192.0.2.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyTwoFactor error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && signIn.Token == "" {
+				t.Error("no access token issued")
+			}
+		})
+	}
+}
+
+func TestVerifyTwoFactorChallengeCompletesOneSignIn(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+	user := f.createUser(t, "guest@example.com", "correct horse")
+	_, recovery := f.enableTwoFactor(t, user)
+
+	challenge := f.challenge(t, user.Email, "correct horse")
+
+	// A typo does not use up the challenge
+	if _, err := f.service.VerifyTwoFactor(ctx, dto.TwoFactorVerifyRequest{ChallengeToken: challenge, Code: "000000"}, "192.0.2.1"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("typo error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+	if _, err := f.service.VerifyTwoFactor(ctx, dto.TwoFactorVerifyRequest{ChallengeToken: challenge, Code: recovery[0]}, "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.VerifyTwoFactor(ctx, dto.TwoFactorVerifyRequest{ChallengeToken: challenge, Code: recovery[1]}, "192.0.2.1"); !errors.Is(err, ErrInvalidChallengeToken) {
+		t.Errorf("second use error = %v, want %v", err, ErrInvalidChallengeToken)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/mailer"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// emailVerificationTTL is how long a verification link stays valid
+	emailVerificationTTL = time.Hour * 24
+	// verificationResendCooldown limits how often a user can request a new link
+	verificationResendCooldown = time.Minute
+)
+
+// VerifyEmail consumes a verification token and marks the address as verified
+func (s *accountService) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) error {
+	token, err := s.consumeOneTimeToken(ctx, domain.TokenPurposeEmailVerification, req.Token)
+	if err != nil {
+		if errors.Is(err, errInvalidOneTimeToken) {
+			return ErrInvalidVerificationToken
+		}
+		return apperror.Internal("failed to verify email", err)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		return apperror.Internal("failed to find user", err)
+	}
+
+	// The token only vouches for the address it was sent to
+	if user.Email != token.Target {
+		return ErrInvalidVerificationToken
+	}
+
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return apperror.Internal("failed to verify email", err)
+	}
+
+	return nil
+}
+
+// ResendVerificationEmail issues a fresh verification link, invalidating older ones
+func (s *accountService) ResendVerificationEmail(ctx context.Context, userUUID string) error {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return apperror.Internal("failed to find user", err)
+	}
+
+	if user.IsEmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	// Throttle resends so the endpoint cannot be used to flood an inbox
+	latest, err := s.tokenRepo.FindLatest(ctx, user.ID, domain.TokenPurposeEmailVerification)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperror.Internal("failed to send verification email", err)
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < verificationResendCooldown {
+		return ErrVerificationEmailRecentlySent
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		return apperror.Internal("failed to send verification email", err)
+	}
+
+	return nil
+}
+
+// sendVerificationEmail mails a new verification link to the user's current address
+func (s *accountService) sendVerificationEmail(ctx context.Context, user *domain.User) error {
+	// Only the newest link should work
+	if err := s.tokenRepo.ConsumeAllForUser(ctx, user.ID, domain.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	rawToken, err := s.issueOneTimeToken(ctx, user.ID, domain.TokenPurposeEmailVerification, user.Email, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", s.settings.AppBaseURL, url.QueryEscape(rawToken))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in 24 hours. If you did not create an account, you can ignore this email.\n",
+			user.Name, link,
+		),
+	})
+}
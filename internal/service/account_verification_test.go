@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var verifyLinkPattern = regexp.MustCompile(`/verify-email\?token=(\S+)`)
+
+// signUp registers a user and returns the verification token mailed to them
+func (f *accountFixture) signUp(t *testing.T, email string) (*dto.SignUp_Success, string) {
+	t.Helper()
+
+	signUp, err := f.service.SignUp(context.Background(), dto.SignUpRequest{Email: email, Password: "correct horse", Name: "Guest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signUp, f.verificationToken(t, email)
+}
+
+// verificationToken returns the token of the last verification link mailed to email
+func (f *accountFixture) verificationToken(t *testing.T, email string) string {
+	t.Helper()
+
+	msg, ok := f.mail.Last(email)
+	if !ok {
+		t.Fatalf("no email sent to %s", email)
+	}
+	match := verifyLinkPattern.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("no verification link in email: %q", msg.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// prepare runs between mailing the link and using it, and returns
+		// the token to present
+		prepare func(t *testing.T, f *accountFixture, user *domain.User, token string) string
+		wantErr error
+	}{
+		{
+			name: "valid token",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, token string) string {
+				return token
+			},
+		},
+		{
+			name: "used token",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, token string) string {
+				if err := f.service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: token}); err != nil {
+					t.Fatalf("first verification: %v", err)
+				}
+				return token
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, token string) string {
+				f.tokens.expire(domain.TokenPurposeEmailVerification, time.Now().Add(-time.Second))
+				return token
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "superseded by a resend",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, token string) string {
+				// Step past the resend cooldown
+				f.tokens.tokens[0].CreatedAt = time.Now().Add(-verificationResendCooldown)
+				if err := f.service.ResendVerificationEmail(ctx, user.UUID); err != nil {
+					t.Fatalf("resend: %v", err)
+				}
+				return token
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "address changed since",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, token string) string {
+				user.Email = "other@example.com"
+				if err := f.users.Update(ctx, user); err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "unknown token",
+			prepare: func(t *testing.T, f *accountFixture, user *domain.User, token string) string {
+				return "not-a-token"
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			_, token := f.signUp(t, "guest@example.com")
+			user, err := f.users.FindByEmail(ctx, "guest@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = f.service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: tt.prepare(t, f, user, token)})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyEmail error = %v, want %v", err, tt.wantErr)
+			}
+
+			// Only the used-token case was verified by its first attempt
+			user, err = f.users.FindByID(ctx, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantVerified := tt.wantErr == nil || tt.name == "used token"
+			if user.IsEmailVerified() != wantVerified {
+				t.Errorf("email verified = %v, want %v", user.IsEmailVerified(), wantVerified)
+			}
+		})
+	}
+}
+
+func TestVerifyEmailReachesAccessToken(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+	signUp, token := f.signUp(t, "guest@example.com")
+
+	claims, err := f.service.tokenService.ParseAccessToken(ctx, signUp.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.EmailVerified {
+		t.Fatal("new account's access token claims a verified email")
+	}
+
+	if err := f.service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: token}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The flag is read when a token is minted, so a refresh picks it up
+	refreshed, err := f.service.RefreshToken(ctx, dto.RefreshTokenRequest{RefreshToken: signUp.RefreshToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err = f.service.tokenService.ParseAccessToken(ctx, refreshed.Token); err != nil || !claims.EmailVerified {
+		t.Errorf("refreshed access token claims = %+v, %v, want a verified email", claims, err)
+	}
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+	signUp, first := f.signUp(t, "guest@example.com")
+	userUUID := signUp.User.UUID
+
+	if err := f.service.ResendVerificationEmail(ctx, userUUID); !errors.Is(err, ErrVerificationEmailRecentlySent) {
+		t.Fatalf("resend within cooldown error = %v", err)
+	}
+
+	f.tokens.tokens[0].CreatedAt = time.Now().Add(-verificationResendCooldown)
+	if err := f.service.ResendVerificationEmail(ctx, userUUID); err != nil {
+		t.Fatal(err)
+	}
+	second := f.verificationToken(t, "guest@example.com")
+	if second == first {
+		t.Fatal("resend mailed the same token")
+	}
+	if err := f.service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: second}); err != nil {
+		t.Fatalf("new link: %v", err)
+	}
+
+	if err := f.service.ResendVerificationEmail(ctx, userUUID); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Errorf("resend after verification error = %v", err)
+	}
+}
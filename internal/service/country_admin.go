@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListAllCountries returns every country with all its settings, disabled ones included
+func (s *countryService) ListAllCountries(ctx context.Context) ([]dto.CountryAdminResponse, error) {
+	countries, err := s.countryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to list countries", err)
+	}
+
+	result := make([]dto.CountryAdminResponse, len(countries))
+	for i := range countries {
+		result[i] = toCountryAdminResponse(&countries[i])
+	}
+	return result, nil
+}
+
+// CreateCountry adds a country. actorUUID is empty when run from the command line.
+func (s *countryService) CreateCountry(ctx context.Context, actorUUID string, input dto.CountryRequest, clientIP string) (*dto.CountryAdminResponse, error) {
+	input.Shortname = normalizeShortname(input.Shortname)
+
+	existing, err := s.findCountry(ctx, input.Shortname)
+	if err != nil && !errors.Is(err, ErrCountryNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrCountryExists
+	}
+
+	now := time.Now()
+	country := &domain.Country{}
+	applyCountryRequest(country, input, now)
+	event := &domain.AuditEvent{
+		ActorUUID: actorUUID,
+		Action:    domain.AuditActionCountryCreated,
+		Detail:    input.Shortname,
+		ClientIP:  clientIP,
+	}
+	if err := s.countryRepo.Create(ctx, country, adminRates(nil, country, now), event); err != nil {
+		return nil, apperror.Internal("failed to create country", err)
+	}
+
+	response := toCountryAdminResponse(country)
+	return &response, nil
+}
+
+// ReplaceCountry overwrites every setting of a country. Replacing a country
+// with identical settings changes nothing and is not audited. A new currency
+// rate applies to every country using the currency.
+func (s *countryService) ReplaceCountry(ctx context.Context, actorUUID, shortname string, input dto.CountryRequest, clientIP string) (*dto.CountryAdminResponse, error) {
+	shortname = normalizeShortname(shortname)
+	input.Shortname = normalizeShortname(input.Shortname)
+	if input.Shortname != shortname {
+		return nil, apperror.Validation(map[string]string{"shortname": "must match the country in the URL"})
+	}
+
+	country, err := s.findCountry(ctx, shortname)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updated := *country
+	applyCountryRequest(&updated, input, now)
+	changed := changedCountryFields(toCountryRequest(country), toCountryRequest(&updated))
+	if len(changed) > 0 {
+		rates := adminRates(country, &updated, now)
+		country = &updated
+		event := &domain.AuditEvent{
+			ActorUUID: actorUUID,
+			Action:    domain.AuditActionCountryUpdated,
+			Detail:    shortname + ": " + strings.Join(changed, ", "),
+			ClientIP:  clientIP,
+		}
+		if err := s.countryRepo.Update(ctx, country, rates, event); err != nil {
+			return nil, apperror.Internal("failed to update country", err)
+		}
+	}
+
+	response := toCountryAdminResponse(country)
+	return &response, nil
+}
+
+// DisableCountry withdraws a country from clients. Users and bookings keep
+// referencing it; disabling an already disabled country is a no-op.
+func (s *countryService) DisableCountry(ctx context.Context, actorUUID, shortname, clientIP string) (*dto.CountryAdminResponse, error) {
+	country, err := s.findCountry(ctx, normalizeShortname(shortname))
+	if err != nil {
+		return nil, err
+	}
+
+	if !country.IsDisabled() {
+		now := time.Now()
+		country.DisabledAt = &now
+		event := &domain.AuditEvent{
+			ActorUUID: actorUUID,
+			Action:    domain.AuditActionCountryDisabled,
+			Detail:    deref(country.Shortname),
+			ClientIP:  clientIP,
+		}
+		if err := s.countryRepo.Update(ctx, country, nil, event); err != nil {
+			return nil, apperror.Internal("failed to disable country", err)
+		}
+	}
+
+	response := toCountryAdminResponse(country)
+	return &response, nil
+}
+
+// ImportCountries creates or replaces countries by shortname in a single
+// transaction. Records must already be validated; countries missing from
+// records are left alone. Records sharing a currency must agree on its rate.
+func (s *countryService) ImportCountries(ctx context.Context, actorUUID string, records []dto.CountryRequest) (*dto.CountryImport_Result, error) {
+	existing, err := s.countryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to list countries", err)
+	}
+	byShortname := make(map[string]*domain.Country, len(existing))
+	for i := range existing {
+		byShortname[normalizeShortname(deref(existing[i].Shortname))] = &existing[i]
+	}
+
+	now := time.Now()
+	result := &dto.CountryImport_Result{}
+	seen := make(map[string]bool, len(records))
+	rateRecords := make(map[string]int) // currency code to the first record giving it a rate
+	var countries []domain.Country
+	var rates []domain.ExchangeRate
+	var events []domain.AuditEvent
+	for i, record := range records {
+		record.Shortname = normalizeShortname(record.Shortname)
+		if seen[record.Shortname] {
+			return nil, apperror.Invalid("duplicate_country", fmt.Sprintf("record %d: %s appears more than once", i+1, record.Shortname))
+		}
+		seen[record.Shortname] = true
+		if record.CurrencyRate != nil {
+			code := normalizeCurrencyCode(record.CurrencyCode)
+			if first, ok := rateRecords[code]; !ok {
+				rateRecords[code] = i
+			} else if *records[first].CurrencyRate != *record.CurrencyRate {
+				return nil, apperror.Invalid("conflicting_currency_rate", fmt.Sprintf("records %d and %d give %s different rates", first+1, i+1, code))
+			}
+		}
+
+		var country domain.Country
+		event := domain.AuditEvent{ActorUUID: actorUUID, Detail: record.Shortname}
+		if current, ok := byShortname[record.Shortname]; ok {
+			country = *current
+			applyCountryRequest(&country, record, now)
+			changed := changedCountryFields(toCountryRequest(current), toCountryRequest(&country))
+			if len(changed) == 0 {
+				result.Unchanged++
+				continue
+			}
+			rates = append(rates, adminRates(current, &country, now)...)
+			event.Action = domain.AuditActionCountryUpdated
+			event.Detail += ": " + strings.Join(changed, ", ")
+			result.Updated++
+		} else {
+			applyCountryRequest(&country, record, now)
+			rates = append(rates, adminRates(nil, &country, now)...)
+			event.Action = domain.AuditActionCountryCreated
+			result.Created++
+		}
+
+		countries = append(countries, country)
+		events = append(events, event)
+	}
+
+	if len(countries) > 0 {
+		if err := s.countryRepo.Import(ctx, countries, rates, events); err != nil {
+			return nil, apperror.Internal("failed to import countries", err)
+		}
+	}
+	return result, nil
+}
+
+// findCountry loads a country by its normalized shortname
+func (s *countryService) findCountry(ctx context.Context, shortname string) (*domain.Country, error) {
+	if shortname == "" {
+		return nil, ErrCountryNotFound
+	}
+	country, err := s.countryRepo.FindByShortname(ctx, shortname)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCountryNotFound
+		}
+		return nil, apperror.Internal("failed to find country", err)
+	}
+	return country, nil
+}
+
+// normalizeShortname stores and looks up shortnames in upper case
+func normalizeShortname(shortname string) string {
+	return strings.ToUpper(strings.TrimSpace(shortname))
+}
+
+// applyCountryRequest copies input onto country. The timestamps are kept by
+// GORM, except DisabledAt, which records when the country was first disabled.
+func applyCountryRequest(country *domain.Country, input dto.CountryRequest, now time.Time) {
+	country.Shortname = optionalString(normalizeShortname(input.Shortname))
+	country.Name = optionalString(input.Name)
+	country.NameVariant = optionalString(input.NameVariant)
+	country.NameZhCn = optionalString(input.NameZhCn)
+	country.NameZhTw = optionalString(input.NameZhTw)
+	country.NameJaJp = optionalString(input.NameJaJp)
+	country.NameKoKr = optionalString(input.NameKoKr)
+	country.NameTh = optionalString(input.NameTh)
+	country.NameCsCz = optionalString(input.NameCsCz)
+	country.CountryCode = input.CallingCode
+	country.CurrencyCode = optionalString(input.CurrencyCode)
+	country.CurrencyName = optionalString(input.CurrencyName)
+	country.CurrencySymbol = optionalString(input.CurrencySymbol)
+	country.CurrencyRate = input.CurrencyRate
+	country.NoDecimalCurrency = flag(input.NoDecimalCurrency)
+	country.TimezoneName = optionalString(input.TimezoneName)
+	country.GMT = optionalString(input.GMT)
+	country.Latitude = input.Latitude
+	country.Longitude = input.Longitude
+	country.PlatformFeePercent = input.PlatformFeePercent
+	country.PaypalTransactionSurcharge = input.PaypalTransactionSurcharge
+	country.PaypalPayoutFee = input.PaypalPayoutFee
+	country.HasVatGst = flag(input.HasVatGst)
+	country.DepositOnly = flag(input.DepositOnly)
+	country.AllowShare = flag(input.AllowShare)
+	country.ReferralReward = input.ReferralReward
+	country.ReferralHostReward = input.ReferralHostReward
+	country.HostExpectedEarnings = input.HostExpectedEarnings
+	country.PointsMultiplier = input.PointsMultiplier
+	country.SearchRadius = input.SearchRadius
+
+	switch {
+	case !input.Disabled:
+		country.DisabledAt = nil
+	case country.DisabledAt == nil:
+		country.DisabledAt = &now
+	}
+}
+
+// toCountryRequest is the inverse of applyCountryRequest
+func toCountryRequest(country *domain.Country) dto.CountryRequest {
+	return dto.CountryRequest{
+		Shortname:                  deref(country.Shortname),
+		Name:                       deref(country.Name),
+		NameVariant:                deref(country.NameVariant),
+		NameZhCn:                   deref(country.NameZhCn),
+		NameZhTw:                   deref(country.NameZhTw),
+		NameJaJp:                   deref(country.NameJaJp),
+		NameKoKr:                   deref(country.NameKoKr),
+		NameTh:                     deref(country.NameTh),
+		NameCsCz:                   deref(country.NameCsCz),
+		CallingCode:                country.CountryCode,
+		CurrencyCode:               deref(country.CurrencyCode),
+		CurrencyName:               deref(country.CurrencyName),
+		CurrencySymbol:             deref(country.CurrencySymbol),
+		CurrencyRate:               country.CurrencyRate,
+		NoDecimalCurrency:          isSet(country.NoDecimalCurrency),
+		TimezoneName:               deref(country.TimezoneName),
+		GMT:                        deref(country.GMT),
+		Latitude:                   country.Latitude,
+		Longitude:                  country.Longitude,
+		PlatformFeePercent:         country.PlatformFeePercent,
+		PaypalTransactionSurcharge: country.PaypalTransactionSurcharge,
+		PaypalPayoutFee:            country.PaypalPayoutFee,
+		HasVatGst:                  isSet(country.HasVatGst),
+		DepositOnly:                isSet(country.DepositOnly),
+		AllowShare:                 isSet(country.AllowShare),
+		ReferralReward:             country.ReferralReward,
+		ReferralHostReward:         country.ReferralHostReward,
+		HostExpectedEarnings:       country.HostExpectedEarnings,
+		PointsMultiplier:           country.PointsMultiplier,
+		SearchRadius:               country.SearchRadius,
+		Disabled:                   country.IsDisabled(),
+	}
+}
+
+// toCountryAdminResponse maps a country to its admin representation
+func toCountryAdminResponse(country *domain.Country) dto.CountryAdminResponse {
+	response := dto.CountryAdminResponse{CountryRequest: toCountryRequest(country)}
+	if country.CreatedTime != nil {
+		response.CreatedTime = country.CreatedTime.UTC().Format(time.RFC3339)
+	}
+	if country.LastModified != nil {
+		response.LastModified = country.LastModified.UTC().Format(time.RFC3339)
+	}
+	return response
+}
+
+// adminRates returns the exchange rate to record when after sets a currency
+// rate that before, nil for a new country, did not have
+func adminRates(before, after *domain.Country, now time.Time) []domain.ExchangeRate {
+	code := normalizeCurrencyCode(deref(after.CurrencyCode))
+	if after.CurrencyRate == nil || code == "" {
+		return nil
+	}
+	if before != nil && before.CurrencyRate != nil && *before.CurrencyRate == *after.CurrencyRate &&
+		normalizeCurrencyCode(deref(before.CurrencyCode)) == code {
+		return nil
+	}
+	return []domain.ExchangeRate{{
+		CurrencyCode: code,
+		Rate:         strconv.FormatFloat(*after.CurrencyRate, 'f', -1, 64),
+		Source:       domain.ExchangeRateSourceAdmin,
+		EffectiveAt:  now.UTC(),
+	}}
+}
+
+// changedCountryFields lists, by JSON name, the settings that differ between
+// before and after; the audit trail records them
+func changedCountryFields(before, after dto.CountryRequest) []string {
+	var changed []string
+	b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+	for i := 0; i < b.NumField(); i++ {
+		if reflect.DeepEqual(b.Field(i).Interface(), a.Field(i).Interface()) {
+			continue
+		}
+		name, _, _ := strings.Cut(b.Type().Field(i).Tag.Get("json"), ",")
+		changed = append(changed, name)
+	}
+	return changed
+}
+
+// optionalString stores an empty string as NULL
+func optionalString(s string) *string {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+	return &s
+}
+
+// flag stores a boolean in the integer columns the country table uses for them
+func flag(b bool) *int {
+	v := 0
+	if b {
+		v = 1
+	}
+	return &v
+}
+
+// isSet reads an integer flag column; NULL counts as false
+func isSet(v *int) bool {
+	return v != nil && *v != 0
+}
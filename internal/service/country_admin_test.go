@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func countryRequest(shortname, name, currency string) dto.CountryRequest {
+	return dto.CountryRequest{Shortname: shortname, Name: name, CurrencyCode: currency}
+}
+
+func TestImportCountries(t *testing.T) {
+	countries := newFakeCountryRepo()
+	svc := NewCountryService(countries)
+	ctx := context.Background()
+
+	for _, record := range []dto.CountryRequest{
+		countryRequest("JP", "Japan", "JPY"),
+		countryRequest("TH", "Thailand", "THB"),
+	} {
+		if _, err := svc.CreateCountry(ctx, "admin-uuid", record, "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	countries.events = nil
+
+	fee := 12
+	japan := countryRequest("JP", "Japan", "JPY")
+	japan.PlatformFeePercent = &fee
+	records := []dto.CountryRequest{
+		japan,
+		countryRequest("th", "Thailand", "THB"),
+		countryRequest("cz", "Czechia", "CZK"),
+		countryRequest("KR", "South Korea", "KRW"),
+	}
+
+	result, err := svc.ImportCountries(ctx, "", records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := dto.CountryImport_Result{Created: 2, Updated: 1, Unchanged: 1}
+	if *result != want {
+		t.Errorf("result = %+v, want %+v", *result, want)
+	}
+	if countries.imports != 1 {
+		t.Errorf("import transactions = %d, want 1", countries.imports)
+	}
+
+	// Only real changes are audited, with what changed
+	var details []string
+	for _, event := range countries.events {
+		details = append(details, event.Action+" "+event.Detail)
+	}
+	wantDetails := []string{
+		domain.AuditActionCountryUpdated + " JP: platform_fee_percent",
+		domain.AuditActionCountryCreated + " CZ",
+		domain.AuditActionCountryCreated + " KR",
+	}
+	if !reflect.DeepEqual(details, wantDetails) {
+		t.Errorf("audit = %q, want %q", details, wantDetails)
+	}
+
+	all, err := svc.ListAllCountries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Errorf("countries = %d, want 4", len(all))
+	}
+
+	// Importing the same records again changes nothing and opens no transaction
+	result, err = svc.ImportCountries(ctx, "", records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (dto.CountryImport_Result{Unchanged: 4}); *result != want {
+		t.Errorf("second result = %+v, want %+v", *result, want)
+	}
+	if countries.imports != 1 {
+		t.Errorf("import transactions = %d after a no-op import, want 1", countries.imports)
+	}
+}
+
+func TestImportCountriesRejectsDuplicates(t *testing.T) {
+	countries := newFakeCountryRepo()
+	svc := NewCountryService(countries)
+
+	_, err := svc.ImportCountries(context.Background(), "", []dto.CountryRequest{
+		countryRequest("JP", "Japan", "JPY"),
+		countryRequest("jp ", "Nippon", "JPY"),
+	})
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || appErr.Code != "duplicate_country" {
+		t.Fatalf("error = %v, want duplicate_country", err)
+	}
+	if countries.imports != 0 || len(countries.countries) != 0 {
+		t.Errorf("imports = %d, countries = %d, want nothing written", countries.imports, len(countries.countries))
+	}
+}
+
+func TestChangedCountryFields(t *testing.T) {
+	fee, otherFee := 12, 15
+	rate := 149.5
+
+	tests := []struct {
+		name   string
+		change func(r *dto.CountryRequest)
+		want   []string
+	}{
+		{"identical", func(r *dto.CountryRequest) {}, nil},
+		{"string", func(r *dto.CountryRequest) { r.Name = "Nippon" }, []string{"name"}},
+		{"pointer set", func(r *dto.CountryRequest) { r.CurrencyRate = &rate }, []string{"currency_rate"}},
+		{"pointer value", func(r *dto.CountryRequest) { r.PlatformFeePercent = &otherFee }, []string{"platform_fee_percent"}},
+		{"pointer cleared", func(r *dto.CountryRequest) { r.PlatformFeePercent = nil }, []string{"platform_fee_percent"}},
+		{"equal value behind another pointer", func(r *dto.CountryRequest) { v := fee; r.PlatformFeePercent = &v }, nil},
+		{
+			name: "several, in field order",
+			change: func(r *dto.CountryRequest) {
+				r.Disabled = true
+				r.CurrencyCode = "USD"
+				r.DepositOnly = true
+			},
+			want: []string{"currency_code", "deposit_only", "disabled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := countryRequest("JP", "Japan", "JPY")
+			before.PlatformFeePercent = &fee
+			after := before
+			tt.change(&after)
+
+			if got := changedCountryFields(before, after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changed = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceCountryAuditsChanges(t *testing.T) {
+	countries := newFakeCountryRepo()
+	svc := NewCountryService(countries)
+	ctx := context.Background()
+
+	if _, err := svc.CreateCountry(ctx, "admin-uuid", countryRequest("JP", "Japan", "JPY"), "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	fee := 12
+	input := countryRequest("JP", "Japan", "JPY")
+	input.PlatformFeePercent = &fee
+	input.Disabled = true
+	response, err := svc.ReplaceCountry(ctx, "admin-uuid", "jp", input, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.Disabled || response.PlatformFeePercent == nil || *response.PlatformFeePercent != fee {
+		t.Errorf("response = %+v, want the new settings", response.CountryRequest)
+	}
+
+	// Replacing with the same settings is not audited
+	if _, err := svc.ReplaceCountry(ctx, "admin-uuid", "JP", input, "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(countries.events) != 2 {
+		t.Fatalf("audit events = %d, want 2", len(countries.events))
+	}
+	event := countries.events[1]
+	if event.Action != domain.AuditActionCountryUpdated || event.Detail != "JP: platform_fee_percent, disabled" {
+		t.Errorf("audit = %s %q, want %s %q", event.Action, event.Detail, domain.AuditActionCountryUpdated, "JP: platform_fee_percent, disabled")
+	}
+
+	// The shortname in the body must match the URL
+	_, err = svc.ReplaceCountry(ctx, "admin-uuid", "TH", input, "192.0.2.1")
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || appErr.Code != apperror.CodeValidationFailed {
+		t.Errorf("mismatched shortname error = %v, want %s", err, apperror.CodeValidationFailed)
+	}
+}
+
+// countryFixture is a country service over fakes
+type countryFixture struct {
+	service   CountryService
+	countries *fakeCountryRepo
+}
+
+func newCountryFixture() *countryFixture {
+	countries := newFakeCountryRepo()
+	return &countryFixture{service: NewCountryService(countries), countries: countries}
+}
+
+// create adds a country through the service
+func (f *countryFixture) create(t *testing.T, input dto.CountryRequest) {
+	t.Helper()
+
+	if _, err := f.service.CreateCountry(context.Background(), "admin-uuid", input, "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rate returns the stored currency rate of a country, or 0 when it has none
+func (f *countryFixture) rate(t *testing.T, shortname string) float64 {
+	t.Helper()
+
+	country, err := f.countries.FindByShortname(context.Background(), shortname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if country.CurrencyRate == nil {
+		return 0
+	}
+	return *country.CurrencyRate
+}
+
+// recordedRates lists the exchange rate history as "CODE rate source"
+func (f *countryFixture) recordedRates() []string {
+	f.countries.mu.Lock()
+	defer f.countries.mu.Unlock()
+
+	var rates []string
+	for _, rate := range f.countries.rates {
+		rates = append(rates, rate.CurrencyCode+" "+rate.Rate+" "+rate.Source)
+	}
+	return rates
+}
+
+func japan(rate *float64) dto.CountryRequest {
+	return dto.CountryRequest{Shortname: "JP", Name: "Japan", CurrencyCode: "JPY", CurrencyRate: rate, NoDecimalCurrency: true}
+}
+
+func germany(rate *float64) dto.CountryRequest {
+	return dto.CountryRequest{Shortname: "DE", Name: "Germany", CurrencyCode: "EUR", CurrencyRate: rate}
+}
+
+func france(rate *float64) dto.CountryRequest {
+	return dto.CountryRequest{Shortname: "FR", Name: "France", CurrencyCode: "EUR", CurrencyRate: rate}
+}
+
+func TestCountryCurrencyRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, f *countryFixture)
+		replace dto.CountryRequest
+		// wantRates is the history the replace adds
+		wantRates []string
+		wantRate  float64
+	}{
+		{
+			name:      "rate set on a country without one",
+			prepare:   func(t *testing.T, f *countryFixture) { f.create(t, germany(nil)) },
+			replace:   germany(ptr(0.92)),
+			wantRates: []string{"EUR 0.92 admin"},
+			wantRate:  0.92,
+		},
+		{
+			name:      "rate changed",
+			prepare:   func(t *testing.T, f *countryFixture) { f.create(t, germany(ptr(0.92))) },
+			replace:   germany(ptr(0.9)),
+			wantRates: []string{"EUR 0.9 admin"},
+			wantRate:  0.9,
+		},
+		{
+			name:     "rate unchanged",
+			prepare:  func(t *testing.T, f *countryFixture) { f.create(t, germany(ptr(0.92))) },
+			replace:  func() dto.CountryRequest { c := germany(ptr(0.92)); c.Name = "Deutschland"; return c }(),
+			wantRate: 0.92,
+		},
+		{
+			name:     "rate cleared",
+			prepare:  func(t *testing.T, f *countryFixture) { f.create(t, germany(ptr(0.92))) },
+			replace:  germany(nil),
+			wantRate: 0,
+		},
+		{
+			name:    "currency changed keeping the rate",
+			prepare: func(t *testing.T, f *countryFixture) { f.create(t, germany(ptr(0.92))) },
+			replace: func() dto.CountryRequest {
+				c := germany(ptr(0.92))
+				c.CurrencyCode = "DEM"
+				return c
+			}(),
+			wantRates: []string{"DEM 0.92 admin"},
+			wantRate:  0.92,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCountryFixture()
+			tt.prepare(t, f)
+			before := f.recordedRates()
+			events := len(f.countries.events)
+
+			_, err := f.service.ReplaceCountry(context.Background(), "admin-uuid", "DE", tt.replace, "192.0.2.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if added := f.recordedRates()[len(before):]; !slices.Equal(added, tt.wantRates) {
+				t.Errorf("recorded rates = %v, want %v", added, tt.wantRates)
+			}
+			if got := f.rate(t, "DE"); got != tt.wantRate {
+				t.Errorf("DE currency_rate = %v, want %v", got, tt.wantRate)
+			}
+			// Every replace here changes a setting, so each is audited
+			if got := len(f.countries.events) - events; got != 1 {
+				t.Errorf("audit events = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestCreateCountryRecordsRate(t *testing.T) {
+	f := newCountryFixture()
+	f.create(t, japan(ptr(149.5)))
+	f.create(t, germany(nil))
+
+	want := []string{"JPY 149.5 admin"}
+	if got := f.recordedRates(); !slices.Equal(got, want) {
+		t.Errorf("recorded rates = %v, want %v", got, want)
+	}
+}
+
+func TestRateAppliesToEveryCountryUsingTheCurrency(t *testing.T) {
+	f := newCountryFixture()
+	f.create(t, germany(ptr(0.92)))
+	f.create(t, france(ptr(0.92)))
+
+	if _, err := f.service.ReplaceCountry(context.Background(), "admin-uuid", "DE", germany(ptr(0.95)), ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.rate(t, "FR"); got != 0.95 {
+		t.Errorf("FR currency_rate = %v, want the new EUR rate 0.95", got)
+	}
+}
+
+func TestImportCountriesCurrencyRates(t *testing.T) {
+	tests := []struct {
+		name      string
+		records   []dto.CountryRequest
+		wantRates []string
+		wantCode  string
+	}{
+		{
+			name:      "rates recorded once per currency",
+			records:   []dto.CountryRequest{germany(ptr(0.92)), france(ptr(0.92)), japan(ptr(149.5))},
+			wantRates: []string{"EUR 0.92 admin", "JPY 149.5 admin"},
+		},
+		{
+			name:      "records without a rate",
+			records:   []dto.CountryRequest{germany(ptr(0.92)), france(nil)},
+			wantRates: []string{"EUR 0.92 admin"},
+		},
+		{
+			name:     "records disagreeing on a rate",
+			records:  []dto.CountryRequest{germany(ptr(0.92)), japan(nil), france(ptr(0.91))},
+			wantCode: "conflicting_currency_rate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCountryFixture()
+
+			_, err := f.service.ImportCountries(context.Background(), "", tt.records)
+			if tt.wantCode != "" {
+				var appErr *apperror.Error
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("ImportCountries error = %v, want %s", err, tt.wantCode)
+				}
+				if countries, _ := f.countries.FindAll(context.Background()); len(countries) != 0 {
+					t.Errorf("a rejected import stored %d countries", len(countries))
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := f.recordedRates(); !slices.Equal(got, tt.wantRates) {
+				t.Errorf("recorded rates = %v, want %v", got, tt.wantRates)
+			}
+		})
+	}
+}
+
+func TestDisableCountryRecordsNoRate(t *testing.T) {
+	f := newCountryFixture()
+	f.create(t, japan(ptr(149.5)))
+
+	if _, err := f.service.DisableCountry(context.Background(), "admin-uuid", "jp", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.recordedRates(); len(got) != 1 {
+		t.Errorf("recorded rates = %v, want only the rate set on creation", got)
+	}
+	if last := f.countries.events[len(f.countries.events)-1]; last.Action != domain.AuditActionCountryDisabled {
+		t.Errorf("last audit action = %q, want %q", last.Action, domain.AuditActionCountryDisabled)
+	}
+}
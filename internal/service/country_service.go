@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/repository"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// countryLanguages are the languages country names are translated into. The
+// first is the fallback and is served from Country.Name.
+var countryLanguages = []language.Tag{
+	language.English,
+	language.SimplifiedChinese,
+	language.TraditionalChinese,
+	language.Japanese,
+	language.Korean,
+	language.Thai,
+	language.Czech,
+}
+
+var countryLanguageMatcher = language.NewMatcher(countryLanguages)
+
+// MatchCountryLanguage picks the language to show country names in. Each
+// preference is a language tag or an Accept-Language header value; the first
+// one with a translation wins, and English is used when none has.
+func MatchCountryLanguage(preferences ...string) language.Tag {
+	for _, preference := range preferences {
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		if _, index, confidence := countryLanguageMatcher.Match(tags...); confidence != language.No {
+			return countryLanguages[index]
+		}
+	}
+	return countryLanguages[0]
+}
+
+// CountryService defines the public country list and its administration
+type CountryService interface {
+	ListCountries(ctx context.Context, lang language.Tag) ([]dto.CountryResponse, error)
+	GetCountry(ctx context.Context, shortname string, lang language.Tag) (*dto.CountryResponse, error)
+	ListAllCountries(ctx context.Context) ([]dto.CountryAdminResponse, error)
+	CreateCountry(ctx context.Context, actorUUID string, input dto.CountryRequest, clientIP string) (*dto.CountryAdminResponse, error)
+	ReplaceCountry(ctx context.Context, actorUUID, shortname string, input dto.CountryRequest, clientIP string) (*dto.CountryAdminResponse, error)
+	DisableCountry(ctx context.Context, actorUUID, shortname, clientIP string) (*dto.CountryAdminResponse, error)
+	ImportCountries(ctx context.Context, actorUUID string, records []dto.CountryRequest) (*dto.CountryImport_Result, error)
+}
+
+// countryService implements CountryService
+type countryService struct {
+	countryRepo repository.CountryRepository
+}
+
+// NewCountryService creates a new country service instance
+func NewCountryService(countryRepo repository.CountryRepository) CountryService {
+	return &countryService{countryRepo: countryRepo}
+}
+
+// ListCountries returns every enabled country with its name in lang
+func (s *countryService) ListCountries(ctx context.Context, lang language.Tag) ([]dto.CountryResponse, error) {
+	countries, err := s.countryRepo.FindEnabled(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to list countries", err)
+	}
+
+	result := make([]dto.CountryResponse, 0, len(countries))
+	for i := range countries {
+		// Rows without a shortname cannot be looked up or referenced by clients
+		if deref(countries[i].Shortname) == "" {
+			continue
+		}
+		result = append(result, toCountryResponse(&countries[i], lang))
+	}
+	return result, nil
+}
+
+// GetCountry returns one enabled country by shortname (e.g. "US"), matched case-insensitively
+func (s *countryService) GetCountry(ctx context.Context, shortname string, lang language.Tag) (*dto.CountryResponse, error) {
+	country, err := s.findCountry(ctx, normalizeShortname(shortname))
+	if err != nil {
+		return nil, err
+	}
+	if country.IsDisabled() {
+		return nil, ErrCountryNotFound
+	}
+
+	response := toCountryResponse(country, lang)
+	return &response, nil
+}
+
+// toCountryResponse keeps the fields clients need. Fees, commissions and
+// rewards are internal and must stay out of the public response.
+func toCountryResponse(country *domain.Country, lang language.Tag) dto.CountryResponse {
+	return dto.CountryResponse{
+		Shortname:      deref(country.Shortname),
+		Name:           localizedCountryName(country, lang),
+		CallingCode:    country.CountryCode,
+		CurrencyCode:   deref(country.CurrencyCode),
+		CurrencyName:   deref(country.CurrencyName),
+		CurrencySymbol: deref(country.CurrencySymbol),
+		TimezoneName:   deref(country.TimezoneName),
+		Latitude:       country.Latitude,
+		Longitude:      country.Longitude,
+	}
+}
+
+// localizedCountryName returns the name of country in lang, falling back to
+// the English name when there is no translation
+func localizedCountryName(country *domain.Country, lang language.Tag) string {
+	var translated *string
+	switch lang {
+	case language.SimplifiedChinese:
+		translated = country.NameZhCn
+	case language.TraditionalChinese:
+		translated = country.NameZhTw
+	case language.Japanese:
+		translated = country.NameJaJp
+	case language.Korean:
+		translated = country.NameKoKr
+	case language.Thai:
+		translated = country.NameTh
+	case language.Czech:
+		translated = country.NameCsCz
+	}
+	if name := strings.TrimSpace(deref(translated)); name != "" {
+		return name
+	}
+	return deref(country.Name)
+}
+
+// deref returns the value of an optional column, or "" when it is NULL
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestMatchCountryLanguage(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferences []string
+		want        language.Tag
+	}{
+		{"nothing given", nil, language.English},
+		{"empty values", []string{"", ""}, language.English},
+		{"exact tag", []string{"ja"}, language.Japanese},
+		{"region narrows a language", []string{"ko-KR"}, language.Korean},
+		{"script picks the Chinese variant", []string{"zh-Hant"}, language.TraditionalChinese},
+		{"region picks the Chinese variant", []string{"zh-TW"}, language.TraditionalChinese},
+		{"query wins over the header", []string{"cs", "ja,en;q=0.8"}, language.Czech},
+		{"unsupported query falls through to the header", []string{"de", "th,en;q=0.8"}, language.Thai},
+		{"malformed query falls through to the header", []string{"@@", "ja"}, language.Japanese},
+		{"header quality order", []string{"", "fr;q=0.9,ko;q=0.8,ja;q=0.5"}, language.Korean},
+		{"nothing translated", []string{"de", "fr-CA,pt;q=0.5"}, language.English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchCountryLanguage(tt.preferences...); got != tt.want {
+				t.Errorf("MatchCountryLanguage(%q) = %v, want %v", tt.preferences, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountryNameFallsBackToEnglish(t *testing.T) {
+	countries := newFakeCountryRepo()
+	countries.add(domain.Country{Shortname: ptr("JP"), Name: ptr("Japan"), NameJaJp: ptr("日本"), NameKoKr: ptr(" ")})
+	svc := NewCountryService(countries)
+	ctx := context.Background()
+
+	tests := []struct {
+		lang language.Tag
+		want string
+	}{
+		{language.English, "Japan"},
+		{language.Japanese, "日本"},
+		// Blank and missing translations show the English name
+		{language.Korean, "Japan"},
+		{language.Thai, "Japan"},
+	}
+	for _, tt := range tests {
+		got, err := svc.GetCountry(ctx, " jp ", tt.lang)
+		if err != nil {
+			t.Fatalf("GetCountry(%v): %v", tt.lang, err)
+		}
+		if got.Name != tt.want {
+			t.Errorf("name in %v = %q, want %q", tt.lang, got.Name, tt.want)
+		}
+	}
+
+	if _, err := svc.GetCountry(ctx, "XX", language.English); !errors.Is(err, ErrCountryNotFound) {
+		t.Errorf("unknown country error = %v, want %v", err, ErrCountryNotFound)
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/money"
+	"log/slog"
+	"math/big"
+	"slices"
+	"time"
+)
+
+// rateDecimals is the precision rates are stored with after rebasing
+const rateDecimals = 10
+
+// maxQuoteClockSkew tolerates a source whose clock runs slightly ahead
+const maxQuoteClockSkew = 5 * time.Minute
+
+// RefreshRates fetches the latest rates and records those of the currencies
+// countries use. Rates are rebased to the configured base currency. A rate
+// that moved more than the configured threshold is rejected and logged, and
+// the previous rate stays in effect until an admin looks into it.
+func (s *currencyService) RefreshRates(ctx context.Context) (*dto.RateRefresh_Result, error) {
+	if s.rateProvider == nil {
+		return nil, ErrNoRateSource
+	}
+
+	quote, err := s.rateProvider.Latest(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to fetch exchange rates", err)
+	}
+	if quote.AsOf.After(time.Now().Add(maxQuoteClockSkew)) {
+		return nil, apperror.Internal("failed to fetch exchange rates", fmt.Errorf("rates are dated in the future: %s", quote.AsOf))
+	}
+
+	// Rates of the base currency itself are implied, not published
+	published := func(code string) (*big.Rat, bool) {
+		if code == quote.Base {
+			return big.NewRat(1, 1), true
+		}
+		rate, ok := new(big.Rat).SetString(quote.Rates[code])
+		return rate, ok && rate.Sign() > 0
+	}
+	base, ok := published(s.settings.RateBase)
+	if !ok {
+		return nil, apperror.Internal("failed to fetch exchange rates", fmt.Errorf("source has no rate for base currency %s", s.settings.RateBase))
+	}
+
+	countries, err := s.countryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to load currencies", err)
+	}
+	latest, err := s.rateRepo.Latest(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to load exchange rate history", err)
+	}
+	previous := make(map[string]domain.ExchangeRate, len(latest))
+	for _, rate := range latest {
+		previous[rate.CurrencyCode] = rate
+	}
+
+	var codes []string
+	for code := range currencies(countries) {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	result := &dto.RateRefresh_Result{}
+	var rates []domain.ExchangeRate
+	for _, code := range codes {
+		rate, ok := published(code)
+		if !ok {
+			result.Missing++
+			continue
+		}
+		// Round once, so the comparison below sees the value that is stored
+		value := decimalString(new(big.Rat).Quo(rate, base), rateDecimals)
+		rate, _ = new(big.Rat).SetString(value)
+		if rate.Sign() <= 0 {
+			slog.WarnContext(ctx, "rejected exchange rate", "currency", code, "rate", value, "reason", "rounds to zero")
+			result.Rejected++
+			continue
+		}
+
+		if prev, ok := previous[code]; ok {
+			if !quote.AsOf.After(prev.EffectiveAt) {
+				// The source has not published anything newer than we have
+				result.Unchanged++
+				continue
+			}
+			if prevRate, ok := new(big.Rat).SetString(prev.Rate); ok && prevRate.Sign() > 0 {
+				if rate.Cmp(prevRate) == 0 {
+					result.Unchanged++
+					continue
+				}
+				if change := relativeChange(prevRate, rate); s.settings.RateMaxChange > 0 && change > s.settings.RateMaxChange {
+					slog.WarnContext(ctx, "rejected exchange rate", "currency", code, "rate", value, "previous", prev.Rate, "change", change, "max_change", s.settings.RateMaxChange)
+					result.Rejected++
+					continue
+				}
+			}
+		}
+
+		rates = append(rates, domain.ExchangeRate{
+			CurrencyCode: code,
+			Rate:         value,
+			Source:       s.rateProvider.Name(),
+			EffectiveAt:  quote.AsOf.UTC(),
+		})
+	}
+
+	if len(rates) > 0 {
+		if err := s.rateRepo.Record(ctx, rates); err != nil {
+			return nil, apperror.Internal("failed to record exchange rates", err)
+		}
+		// Convert with the new rates right away rather than after the TTL
+		s.converters.delete("")
+	}
+	result.Recorded = len(rates)
+	return result, nil
+}
+
+// SetRate records rate for the currency code from now on. Later refreshes
+// compare against it, so accepting a rejected move also lets the refresh
+// carry on from there.
+func (s *currencyService) SetRate(ctx context.Context, actorUUID, code, rate, clientIP string) (*dto.ExchangeRateResponse, error) {
+	code = normalizeCurrencyCode(code)
+	if code == s.settings.RateBase {
+		return nil, ErrBaseCurrencyRate
+	}
+
+	countries, err := s.countryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to load currencies", err)
+	}
+	currency, ok := currencies(countries)[code]
+	if !ok {
+		return nil, ErrUnknownCurrency
+	}
+	parsed, err := money.ParseRate(currency, rate)
+	if err != nil {
+		return nil, apperror.Validation(map[string]string{"rate": "must be a positive decimal, e.g. 149.5"})
+	}
+	// Stored with the precision of refreshed rates
+	value := decimalString(parsed.PerBase, rateDecimals)
+	if value == "0" {
+		return nil, apperror.Validation(map[string]string{"rate": fmt.Sprintf("must be at least 1e-%d", rateDecimals)})
+	}
+
+	latest, err := s.rateRepo.Latest(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to load exchange rate history", err)
+	}
+	var previous string
+	for _, entry := range latest {
+		if entry.CurrencyCode == code {
+			previous = entry.Rate
+		}
+	}
+
+	entry := &domain.ExchangeRate{
+		CurrencyCode: code,
+		Rate:         value,
+		Source:       domain.ExchangeRateSourceAdmin,
+		EffectiveAt:  time.Now().UTC(),
+	}
+	detail := code + ": " + value
+	if previous != "" {
+		detail = code + ": " + previous + " -> " + value
+	}
+	event := &domain.AuditEvent{
+		ActorUUID: actorUUID,
+		Action:    domain.AuditActionExchangeRateSet,
+		Detail:    detail,
+		ClientIP:  clientIP,
+	}
+	if err := s.rateRepo.Set(ctx, entry, event); err != nil {
+		return nil, apperror.Internal("failed to record exchange rate", err)
+	}
+	s.converters.delete("")
+
+	return &dto.ExchangeRateResponse{
+		CurrencyCode: code,
+		Rate:         value,
+		Previous:     previous,
+		Source:       entry.Source,
+		EffectiveAt:  entry.EffectiveAt.Format(time.RFC3339),
+	}, nil
+}
+
+// relativeChange returns |to - from| / from
+func relativeChange(from, to *big.Rat) float64 {
+	change := new(big.Rat).Sub(to, from)
+	change.Quo(change.Abs(change), from)
+	f, _ := change.Float64()
+	return f
+}
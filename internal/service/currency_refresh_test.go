@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/exchange"
+	"go-booking-system/internal/money"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeRateProvider serves a fixed quote
+type fakeRateProvider struct {
+	quote *exchange.Quote
+	err   error
+}
+
+func (p *fakeRateProvider) Name() string { return "fake" }
+
+func (p *fakeRateProvider) Latest(ctx context.Context) (*exchange.Quote, error) {
+	return p.quote, p.err
+}
+
+// newCurrencyService returns a currency service over the fixture's
+// countries, quoting rates against USD and caching converters
+func (f *countryFixture) newCurrencyService(provider exchange.Provider, maxChange float64) CurrencyService {
+	return NewCurrencyService(f.countries, &fakeExchangeRateRepo{countries: f.countries}, provider, CurrencySettings{
+		RateBase:      "USD",
+		RateCacheTTL:  time.Hour,
+		RateMaxChange: maxChange,
+	})
+}
+
+func TestSetRate(t *testing.T) {
+	unitedStates := dto.CountryRequest{Shortname: "US", Name: "United States", CurrencyCode: "USD", CurrencyRate: ptr(1.0)}
+
+	tests := []struct {
+		name         string
+		japanRate    *float64 // the rate JP was created with
+		code, rate   string
+		wantRate     string
+		wantPrevious string
+		wantErr      error
+		wantCode     string // for validation errors
+	}{
+		{name: "first rate", code: "JPY", rate: "149.5", wantRate: "149.5"},
+		{name: "override", japanRate: ptr(148.5), code: "JPY", rate: "151", wantRate: "151", wantPrevious: "148.5"},
+		{name: "code in lower case", japanRate: ptr(148.5), code: "jpy", rate: "151", wantRate: "151", wantPrevious: "148.5"},
+		{name: "trailing zeros dropped", code: "JPY", rate: " 149.50 ", wantRate: "149.5"},
+		{name: "currency no country uses", code: "GBP", rate: "0.79", wantErr: ErrUnknownCurrency},
+		{name: "base currency", code: "USD", rate: "1.1", wantErr: ErrBaseCurrencyRate},
+		{name: "not a number", code: "JPY", rate: "abc", wantCode: apperror.CodeValidationFailed},
+		{name: "zero", code: "JPY", rate: "0", wantCode: apperror.CodeValidationFailed},
+		{name: "below the stored precision", code: "JPY", rate: "0.00000000001", wantCode: apperror.CodeValidationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCountryFixture()
+			f.create(t, unitedStates)
+			f.create(t, japan(tt.japanRate))
+			currency := f.newCurrencyService(nil, 0)
+			rates := len(f.recordedRates())
+			events := len(f.countries.events)
+
+			result, err := currency.SetRate(context.Background(), "admin-uuid", tt.code, tt.rate, "192.0.2.1")
+			if tt.wantCode != "" {
+				var appErr *apperror.Error
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("SetRate error = %v, want %s", err, tt.wantCode)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetRate error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(f.recordedRates()) != rates || len(f.countries.events) != events {
+					t.Error("a rejected rate was recorded")
+				}
+				return
+			}
+
+			if result.CurrencyCode != "JPY" || result.Rate != tt.wantRate || result.Previous != tt.wantPrevious || result.Source != domain.ExchangeRateSourceAdmin {
+				t.Errorf("SetRate = %+v, want JPY %s replacing %q from admin", result, tt.wantRate, tt.wantPrevious)
+			}
+			if got := f.recordedRates()[rates:]; len(got) != 1 || got[0] != "JPY "+tt.wantRate+" admin" {
+				t.Errorf("recorded rates = %v, want JPY %s", got, tt.wantRate)
+			}
+			if got := f.rate(t, "JP"); got <= 0 {
+				t.Errorf("JP currency_rate = %v, want the new rate", got)
+			}
+
+			if len(f.countries.events) != events+1 {
+				t.Fatalf("audit events = %d, want %d", len(f.countries.events), events+1)
+			}
+			event := f.countries.events[events]
+			wantDetail := "JPY: " + tt.wantRate
+			if tt.wantPrevious != "" {
+				wantDetail = "JPY: " + tt.wantPrevious + " -> " + tt.wantRate
+			}
+			if event.Action != domain.AuditActionExchangeRateSet || event.Detail != wantDetail || event.ActorUUID != "admin-uuid" {
+				t.Errorf("audit event = %s %q by %q, want %s %q by admin-uuid", event.Action, event.Detail, event.ActorUUID, domain.AuditActionExchangeRateSet, wantDetail)
+			}
+		})
+	}
+}
+
+func TestSetRateTakesEffectImmediately(t *testing.T) {
+	ctx := context.Background()
+	f := newCountryFixture()
+	f.create(t, dto.CountryRequest{Shortname: "US", Name: "United States", CurrencyCode: "USD", CurrencyRate: ptr(1.0)})
+	f.create(t, japan(ptr(148.5)))
+	currency := f.newCurrencyService(nil, 0)
+
+	usd, err := currency.Currency(ctx, "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	convert := func() int64 {
+		t.Helper()
+		converted, err := currency.Convert(ctx, money.New(100, usd), "JPY")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return converted.Minor()
+	}
+
+	// The converter is cached for an hour from here
+	if got := convert(); got != 149 {
+		t.Fatalf("$1.00 = ¥%d, want ¥149", got)
+	}
+	if _, err := currency.SetRate(ctx, "admin-uuid", "JPY", "200", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := convert(); got != 200 {
+		t.Errorf("$1.00 = ¥%d after setting the rate, want ¥200", got)
+	}
+}
+
+func TestRefreshRates(t *testing.T) {
+	yesterday := time.Now().Add(-24 * time.Hour).Truncate(time.Second).UTC()
+	today := yesterday.Add(24 * time.Hour)
+	unitedStates := dto.CountryRequest{Shortname: "US", Name: "United States", CurrencyCode: "USD"}
+	thailand := dto.CountryRequest{Shortname: "TH", Name: "Thailand", CurrencyCode: "THB"}
+
+	tests := []struct {
+		name string
+		// history is recorded yesterday, before the refresh
+		history   map[string]string
+		quote     exchange.Quote
+		want      dto.RateRefresh_Result
+		wantRates []string // the history the refresh adds
+	}{
+		{
+			name:      "quoted against the configured base",
+			quote:     exchange.Quote{Base: "USD", Rates: map[string]string{"EUR": "0.92", "JPY": "149.5", "THB": "36.1"}, AsOf: today},
+			want:      dto.RateRefresh_Result{Recorded: 4},
+			wantRates: []string{"EUR 0.92 fake", "JPY 149.5 fake", "THB 36.1 fake", "USD 1 fake"},
+		},
+		{
+			name:      "rebased to the configured base",
+			quote:     exchange.Quote{Base: "EUR", Rates: map[string]string{"USD": "1.08", "JPY": "161.4", "THB": "39"}, AsOf: today},
+			want:      dto.RateRefresh_Result{Recorded: 4},
+			wantRates: []string{"EUR 0.9259259259 fake", "JPY 149.4444444444 fake", "THB 36.1111111111 fake", "USD 1 fake"},
+		},
+		{
+			name:      "unpublished currency",
+			quote:     exchange.Quote{Base: "USD", Rates: map[string]string{"EUR": "0.92", "JPY": "149.5"}, AsOf: today},
+			want:      dto.RateRefresh_Result{Recorded: 3, Missing: 1},
+			wantRates: []string{"EUR 0.92 fake", "JPY 149.5 fake", "USD 1 fake"},
+		},
+		{
+			name:      "move above the threshold",
+			history:   map[string]string{"USD": "1", "EUR": "0.92", "JPY": "149.5", "THB": "36.1"},
+			quote:     exchange.Quote{Base: "USD", Rates: map[string]string{"EUR": "0.93", "JPY": "190", "THB": "36.1"}, AsOf: today},
+			want:      dto.RateRefresh_Result{Recorded: 1, Unchanged: 2, Rejected: 1},
+			wantRates: []string{"EUR 0.93 fake"},
+		},
+		{
+			name:      "move at the threshold",
+			history:   map[string]string{"JPY": "150"},
+			quote:     exchange.Quote{Base: "USD", Rates: map[string]string{"JPY": "180"}, AsOf: today},
+			want:      dto.RateRefresh_Result{Recorded: 2, Missing: 2},
+			wantRates: []string{"JPY 180 fake", "USD 1 fake"},
+		},
+		{
+			name:    "equal rate",
+			history: map[string]string{"USD": "1", "EUR": "0.92", "JPY": "149.5", "THB": "36.1"},
+			quote:   exchange.Quote{Base: "USD", Rates: map[string]string{"EUR": "0.920", "JPY": "149.5", "THB": "36.1"}, AsOf: today},
+			want:    dto.RateRefresh_Result{Unchanged: 4},
+		},
+		{
+			name:    "quote as old as the history",
+			history: map[string]string{"USD": "1", "EUR": "0.92", "JPY": "149.5", "THB": "36.1"},
+			quote:   exchange.Quote{Base: "USD", Rates: map[string]string{"EUR": "0.95", "JPY": "151", "THB": "36.5"}, AsOf: yesterday},
+			want:    dto.RateRefresh_Result{Unchanged: 4},
+		},
+		{
+			name:    "quote older than the history",
+			history: map[string]string{"USD": "1", "EUR": "0.92", "JPY": "149.5", "THB": "36.1"},
+			quote:   exchange.Quote{Base: "USD", Rates: map[string]string{"EUR": "0.95", "JPY": "151", "THB": "36.5"}, AsOf: yesterday.Add(-time.Hour)},
+			want:    dto.RateRefresh_Result{Unchanged: 4},
+		},
+		{
+			name:      "rate that rounds to zero",
+			quote:     exchange.Quote{Base: "USD", Rates: map[string]string{"EUR": "0.00000000001", "JPY": "149.5", "THB": "36.1"}, AsOf: today},
+			want:      dto.RateRefresh_Result{Recorded: 3, Rejected: 1},
+			wantRates: []string{"JPY 149.5 fake", "THB 36.1 fake", "USD 1 fake"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCountryFixture()
+			for _, country := range []dto.CountryRequest{unitedStates, germany(nil), japan(nil), thailand} {
+				f.create(t, country)
+			}
+			var history []domain.ExchangeRate
+			for code, rate := range tt.history {
+				history = append(history, domain.ExchangeRate{CurrencyCode: code, Rate: rate, Source: "fake", EffectiveAt: yesterday})
+			}
+			rateRepo := &fakeExchangeRateRepo{countries: f.countries}
+			if err := rateRepo.Record(context.Background(), history); err != nil {
+				t.Fatal(err)
+			}
+			before := len(f.recordedRates())
+			currency := f.newCurrencyService(&fakeRateProvider{quote: &tt.quote}, 0.2)
+
+			result, err := currency.RefreshRates(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *result != tt.want {
+				t.Errorf("result = %+v, want %+v", *result, tt.want)
+			}
+			added := f.recordedRates()[before:]
+			slices.Sort(added)
+			if !slices.Equal(added, tt.wantRates) {
+				t.Errorf("recorded rates = %v, want %v", added, tt.wantRates)
+			}
+		})
+	}
+}
+
+func TestRefreshRatesFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider exchange.Provider
+		wantErr  error
+	}{
+		{name: "no source", wantErr: ErrNoRateSource},
+		{name: "source down", provider: &fakeRateProvider{err: errors.New("connection refused")}},
+		{
+			name:     "dated in the future",
+			provider: &fakeRateProvider{quote: &exchange.Quote{Base: "USD", Rates: map[string]string{"JPY": "149.5"}, AsOf: time.Now().Add(time.Hour)}},
+		},
+		{
+			name:     "base currency not published",
+			provider: &fakeRateProvider{quote: &exchange.Quote{Base: "EUR", Rates: map[string]string{"JPY": "161.4"}, AsOf: time.Now()}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCountryFixture()
+			f.create(t, japan(nil))
+			currency := f.newCurrencyService(tt.provider, 0)
+
+			_, err := currency.RefreshRates(context.Background())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("RefreshRates error = %v, want %v", err, tt.wantErr)
+				}
+			} else {
+				var appErr *apperror.Error
+				if !errors.As(err, &appErr) || appErr.Kind != apperror.KindInternal {
+					t.Fatalf("RefreshRates error = %v, want an internal error", err)
+				}
+			}
+			if got := f.recordedRates(); len(got) != 0 {
+				t.Errorf("recorded rates = %v, want none", got)
+			}
+		})
+	}
+}
+
+func TestRefreshRatesTakeEffectImmediately(t *testing.T) {
+	ctx := context.Background()
+	f := newCountryFixture()
+	f.create(t, dto.CountryRequest{Shortname: "US", Name: "United States", CurrencyCode: "USD", CurrencyRate: ptr(1.0)})
+	f.create(t, japan(ptr(148.5)))
+	provider := &fakeRateProvider{quote: &exchange.Quote{Base: "USD", Rates: map[string]string{"JPY": "200"}, AsOf: time.Now()}}
+	currency := f.newCurrencyService(provider, 0)
+
+	usd, err := currency.Currency(ctx, "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	convert := func() int64 {
+		t.Helper()
+		converted, err := currency.Convert(ctx, money.New(100, usd), "JPY")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return converted.Minor()
+	}
+
+	// The converter is cached for an hour from here
+	if got := convert(); got != 149 {
+		t.Fatalf("$1.00 = ¥%d, want ¥149", got)
+	}
+	if _, err := currency.RefreshRates(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := convert(); got != 200 {
+		t.Errorf("$1.00 = ¥%d after the refresh, want ¥200", got)
+	}
+}
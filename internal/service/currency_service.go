@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/exchange"
+	"go-booking-system/internal/money"
+	"go-booking-system/internal/repository"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// CurrencySettings holds configuration for currency conversion
+type CurrencySettings struct {
+	Rounding     money.RoundingMode
+	RateCacheTTL time.Duration // zero reads the rates on every conversion
+	RateBase     string        // currency the stored rates are quoted against
+	// RateMaxChange is the largest relative move a refresh accepts, e.g. 0.2;
+	// zero accepts any change
+	RateMaxChange float64
+}
+
+// CurrencyService converts amounts between the currencies of the country table
+type CurrencyService interface {
+	// Convert expresses an amount in another currency, e.g. a price in the
+	// guest's currency
+	Convert(ctx context.Context, amount money.Money, to string) (money.Money, error)
+	// ConvertAsOf converts with the rates in effect at a past time, so
+	// financial records can be reproduced
+	ConvertAsOf(ctx context.Context, amount money.Money, to string, at time.Time) (money.Money, error)
+	// Currency looks up a currency by ISO 4217 code
+	Currency(ctx context.Context, code string) (money.Currency, error)
+	ConvertAmount(ctx context.Context, from, to, amount string, at time.Time) (*dto.CurrencyConversionResponse, error)
+	RefreshRates(ctx context.Context) (*dto.RateRefresh_Result, error)
+	// SetRate records a rate chosen by an admin, e.g. to accept a move the
+	// refresh rejected or to override the source
+	SetRate(ctx context.Context, actorUUID, code, rate, clientIP string) (*dto.ExchangeRateResponse, error)
+}
+
+// currencyService implements CurrencyService
+type currencyService struct {
+	countryRepo  repository.CountryRepository
+	rateRepo     repository.ExchangeRateRepository
+	rateProvider exchange.Provider // nil when rates are not refreshed
+	settings     CurrencySettings
+	converters   *ttlCache[*money.Converter]
+}
+
+// NewCurrencyService creates a new currency service instance. rateProvider
+// may be nil, in which case RefreshRates fails and rates stay as they are.
+func NewCurrencyService(
+	countryRepo repository.CountryRepository,
+	rateRepo repository.ExchangeRateRepository,
+	rateProvider exchange.Provider,
+	settings CurrencySettings,
+) CurrencyService {
+	return &currencyService{
+		countryRepo:  countryRepo,
+		rateRepo:     rateRepo,
+		rateProvider: rateProvider,
+		settings:     settings,
+		converters:   newTTLCache[*money.Converter](),
+	}
+}
+
+// Convert expresses amount in the currency to
+func (s *currencyService) Convert(ctx context.Context, amount money.Money, to string) (money.Money, error) {
+	return s.ConvertAsOf(ctx, amount, to, time.Time{})
+}
+
+// ConvertAsOf expresses amount in the currency to with the rates in effect
+// at the given time; the zero time means now
+func (s *currencyService) ConvertAsOf(ctx context.Context, amount money.Money, to string, at time.Time) (money.Money, error) {
+	converter, err := s.converterAt(ctx, at)
+	if err != nil {
+		return money.Money{}, err
+	}
+	converted, err := converter.Convert(amount, to)
+	if err != nil {
+		return money.Money{}, currencyError(err)
+	}
+	return converted, nil
+}
+
+// Currency looks up a currency by ISO 4217 code
+func (s *currencyService) Currency(ctx context.Context, code string) (money.Currency, error) {
+	converter, err := s.converter(ctx)
+	if err != nil {
+		return money.Currency{}, err
+	}
+	currency, err := converter.Currency(code)
+	if err != nil {
+		return money.Currency{}, currencyError(err)
+	}
+	return currency, nil
+}
+
+// ConvertAmount converts a decimal amount as typed by a client, with the
+// rates in effect at the given time; the zero time means now
+func (s *currencyService) ConvertAmount(ctx context.Context, from, to, amount string, at time.Time) (*dto.CurrencyConversionResponse, error) {
+	converter, err := s.converterAt(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+
+	fromCurrency, err := converter.Currency(from)
+	if err != nil {
+		return nil, currencyError(err)
+	}
+	source, err := money.Parse(amount, fromCurrency)
+	if err != nil {
+		return nil, currencyError(err)
+	}
+	converted, err := converter.Convert(source, to)
+	if err != nil {
+		return nil, currencyError(err)
+	}
+	rate, err := converter.Rate(from, to)
+	if err != nil {
+		return nil, currencyError(err)
+	}
+
+	response := &dto.CurrencyConversionResponse{
+		From: toMoneyResponse(source),
+		To:   toMoneyResponse(converted),
+		Rate: decimalString(rate, 8),
+	}
+	if !at.IsZero() {
+		response.At = at.UTC().Format(time.RFC3339)
+	}
+	return response, nil
+}
+
+// converterAt returns a converter over the rates in effect at the given
+// time, or the current one for the zero time
+func (s *currencyService) converterAt(ctx context.Context, at time.Time) (*money.Converter, error) {
+	if at.IsZero() {
+		return s.converter(ctx)
+	}
+
+	countries, err := s.countryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to load currencies", err)
+	}
+	history, err := s.rateRepo.AsOf(ctx, at)
+	if err != nil {
+		return nil, apperror.Internal("failed to load exchange rate history", err)
+	}
+
+	known := currencies(countries)
+	var rates []money.Rate
+	for _, entry := range history {
+		currency, ok := known[entry.CurrencyCode]
+		if !ok {
+			// A currency no country uses any more still converts, without a symbol
+			currency = money.Currency{Code: entry.CurrencyCode, Decimals: 2}
+		}
+		rate, err := money.ParseRate(currency, entry.Rate)
+		if err != nil {
+			slog.WarnContext(ctx, "skipping exchange rate", "currency", entry.CurrencyCode, "effective_at", entry.EffectiveAt, "error", err)
+			continue
+		}
+		rates = append(rates, rate)
+	}
+	return money.NewConverter(rates, s.settings.Rounding), nil
+}
+
+// converter returns a converter over the current rates, reading them from
+// the country table when the cached copy has expired
+func (s *currencyService) converter(ctx context.Context) (*money.Converter, error) {
+	if converter, ok := s.converters.get(""); ok {
+		return converter, nil
+	}
+
+	countries, err := s.countryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, apperror.Internal("failed to load currency rates", err)
+	}
+
+	known := currencies(countries)
+	var rates []money.Rate
+	for _, country := range countries {
+		currency, ok := known[normalizeCurrencyCode(deref(country.CurrencyCode))]
+		if !ok || country.CurrencyRate == nil {
+			continue
+		}
+		rate, err := money.NewRate(currency, *country.CurrencyRate)
+		if err != nil {
+			// One bad row should not take every other currency down with it
+			slog.WarnContext(ctx, "skipping currency rate", "country", deref(country.Shortname), "error", err)
+			continue
+		}
+		rates = append(rates, rate)
+	}
+
+	converter := money.NewConverter(rates, s.settings.Rounding)
+	if s.settings.RateCacheTTL > 0 {
+		s.converters.set("", converter, s.settings.RateCacheTTL)
+	}
+	return converter, nil
+}
+
+// currencies collects the currencies countries use, keyed by code. When
+// countries share a currency, the first country's symbol wins.
+func currencies(countries []domain.Country) map[string]money.Currency {
+	known := make(map[string]money.Currency, len(countries))
+	for _, country := range countries {
+		code := normalizeCurrencyCode(deref(country.CurrencyCode))
+		if _, ok := known[code]; code == "" || ok {
+			continue
+		}
+		currency := money.Currency{
+			Code:     code,
+			Symbol:   deref(country.CurrencySymbol),
+			Decimals: 2,
+		}
+		if isSet(country.NoDecimalCurrency) {
+			currency.Decimals = 0
+		}
+		known[code] = currency
+	}
+	return known
+}
+
+// normalizeCurrencyCode stores and looks up currency codes in upper case
+func normalizeCurrencyCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// decimalString formats r with at most places decimals and no trailing zeros
+func decimalString(r *big.Rat, places int) string {
+	s := r.FloatString(places)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// currencyError maps money errors to client errors
+func currencyError(err error) error {
+	switch {
+	case errors.Is(err, money.ErrUnknownCurrency):
+		return ErrUnknownCurrency
+	case errors.Is(err, money.ErrTooManyDecimals):
+		return apperror.Validation(map[string]string{"amount": "has more decimal places than the currency allows"})
+	case errors.Is(err, money.ErrInvalidAmount):
+		return apperror.Validation(map[string]string{"amount": "must be a decimal number, e.g. 12.50"})
+	case errors.Is(err, money.ErrOutOfRange):
+		return ErrAmountOutOfRange
+	default:
+		return apperror.Internal("failed to convert currency", err)
+	}
+}
+
+// toMoneyResponse maps an amount to its API representation
+func toMoneyResponse(m money.Money) dto.MoneyResponse {
+	return dto.MoneyResponse{
+		Currency:   m.Currency().Code,
+		Amount:     m.String(),
+		MinorUnits: m.Minor(),
+		Formatted:  m.Format(),
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import "go-booking-system/internal/apperror"
+
+// Errors returned to API clients. Handlers pass them to the error middleware,
+// which maps the kind to a status code; match them with errors.Is.
+// Unexpected failures are returned as apperror.Internal wrapping the cause.
+var (
+	// Accounts and profiles
+	ErrUserNotFound         = apperror.NotFound("user_not_found", "user not found")
+	ErrEmailTaken           = apperror.Conflict("email_taken", "email already registered")
+	ErrEmptyName            = apperror.Invalid("empty_name", "name cannot be empty")
+	ErrUnknownCountry       = apperror.Invalid("unknown_country", "unknown country")
+	ErrCountryNotFound      = apperror.NotFound("country_not_found", "country not found")
+	ErrCountryExists        = apperror.Conflict("country_exists", "country already exists")
+	ErrInvalidPassword      = apperror.Forbidden("invalid_current_password", "invalid current password")
+	ErrSameEmail            = apperror.Invalid("same_email", "new email is the same as the current email")
+	ErrAccountDeleted       = apperror.Forbidden("account_deleted", "account has been deleted")
+	ErrEmailAlreadyVerified = apperror.Conflict("email_already_verified", "email already verified")
+	ErrEmailNotVerified     = apperror.Conflict("email_not_verified", "email registered but not verified")
+
+	// Sign-in and tokens
+	ErrInvalidCredentials  = apperror.Unauthorized("invalid_credentials", "invalid credentials")
+	ErrInvalidToken        = apperror.Unauthorized("invalid_token", "invalid or expired token")
+	ErrTokenRevoked        = apperror.Unauthorized("token_revoked", "token has been revoked")
+	ErrInvalidRefreshToken = apperror.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	ErrRefreshTokenReused  = apperror.Unauthorized("refresh_token_reused", "refresh token reuse detected")
+	ErrInvalidSignInLink   = apperror.Unauthorized("invalid_sign_in_link", "invalid or expired sign-in link")
+	ErrInvalidSignInCode   = apperror.Unauthorized("invalid_sign_in_code", "invalid or expired code")
+
+	// Emailed one-time tokens
+	ErrInvalidVerificationToken      = apperror.Invalid("invalid_verification_token", "invalid or expired verification token")
+	ErrInvalidResetToken             = apperror.Invalid("invalid_reset_token", "invalid or expired reset token")
+	ErrInvalidConfirmationToken      = apperror.Invalid("invalid_confirmation_token", "invalid or expired confirmation token")
+	ErrVerificationEmailRecentlySent = apperror.TooManyRequests("verification_email_recently_sent", "verification email recently sent")
+
+	// Phone numbers
+	ErrInvalidPhone                 = apperror.Invalid("invalid_phone", "invalid phone number")
+	ErrPhoneCountryRequired         = apperror.Invalid("phone_country_required", "country is required for national phone numbers")
+	ErrNoPhone                      = apperror.Invalid("no_phone", "no phone number on account")
+	ErrPhoneAlreadyVerified         = apperror.Conflict("phone_already_verified", "phone number already verified")
+	ErrPhoneTaken                   = apperror.Conflict("phone_taken", "phone number already verified by another account")
+	ErrInvalidVerificationCode      = apperror.Invalid("invalid_verification_code", "invalid or expired code")
+	ErrVerificationCodeRecentlySent = apperror.TooManyRequests("verification_code_recently_sent", "verification code recently sent")
+
+	// Two-factor authentication
+	ErrTwoFactorEnabled         = apperror.Conflict("two_factor_enabled", "two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled      = apperror.Invalid("two_factor_not_enabled", "two-factor authentication not enabled")
+	ErrTwoFactorSetupNotStarted = apperror.Invalid("two_factor_setup_not_started", "two-factor setup not started")
+	ErrInvalidSetupCode         = apperror.Invalid("invalid_setup_code", "invalid two-factor code")
+	ErrInvalidTwoFactorCode     = apperror.Unauthorized("invalid_two_factor_code", "invalid two-factor code")
+	ErrInvalidChallengeToken    = apperror.Unauthorized("invalid_challenge_token", "invalid or expired challenge token")
+
+	// Social sign-in
+	ErrUnknownProvider          = apperror.NotFound("unknown_provider", "unknown identity provider")
+	ErrInvalidSignInState       = apperror.Invalid("invalid_sign_in_state", "invalid or expired sign-in state")
+	ErrProviderSignInFailed     = apperror.Unauthorized("provider_sign_in_failed", "identity provider sign-in failed")
+	ErrProviderEmailNotVerified = apperror.Forbidden("provider_email_not_verified", "identity provider email not verified")
+	ErrProviderAlreadyLinked    = apperror.Conflict("provider_already_linked", "account already linked to another identity of this provider")
+
+	// Roles
+	ErrRoleNotGrantable = apperror.Invalid("role_not_grantable", "role cannot be granted")
+	ErrRoleNotRevocable = apperror.Invalid("role_not_revocable", "role cannot be revoked")
+	ErrLastAdmin        = apperror.Conflict("last_admin", "cannot revoke the last admin")
+	ErrLastAdminAccount = apperror.Conflict("last_admin_account", "cannot delete the last admin account")
+
+	// Currency conversion
+	ErrUnknownCurrency  = apperror.Invalid("unknown_currency", "unknown currency or no exchange rate")
+	ErrAmountOutOfRange = apperror.Invalid("amount_out_of_range", "amount is too large")
+	ErrNoRateSource     = apperror.Conflict("no_rate_source", "no exchange rate source configured")
+	ErrBaseCurrencyRate = apperror.Invalid("base_currency_rate", "the base currency's rate is always 1")
+)
@@ -0,0 +1,985 @@
+package service
+
+import (
+	"context"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/keyring"
+	"go-booking-system/internal/mailer"
+	"go-booking-system/internal/repository"
+	"go-booking-system/internal/sms"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// The fakes below keep rows in memory and mirror the conditional updates of
+// the Postgres repositories, so service logic can be tested without a
+// database. Rows are copied in and out, as they would be through GORM.
+
+// fakeUserRepo implements repository.UserRepository
+type fakeUserRepo struct {
+	mu     sync.Mutex
+	nextID uint
+	users  map[uint]*domain.User
+}
+
+func newFakeUserRepo() *fakeUserRepo {
+	return &fakeUserRepo{users: map[uint]*domain.User{}}
+}
+
+func (r *fakeUserRepo) Create(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	user.ID = r.nextID
+	if user.UUID == "" {
+		user.UUID = uuid.New().String()
+	}
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *fakeUserRepo) find(match func(*domain.User) bool) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id := uint(1); id <= r.nextID; id++ {
+		if user, ok := r.users[id]; ok && match(user) {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return !u.DeletedAt.Valid && u.Email == email })
+}
+
+func (r *fakeUserRepo) FindByEmailIncludingDeleted(ctx context.Context, email string) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return u.Email == email })
+}
+
+func (r *fakeUserRepo) FindByID(ctx context.Context, id uint) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return !u.DeletedAt.Valid && u.ID == id })
+}
+
+func (r *fakeUserRepo) FindByUUID(ctx context.Context, uuid string) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return !u.DeletedAt.Valid && u.UUID == uuid })
+}
+
+func (r *fakeUserRepo) FindByVerifiedPhone(ctx context.Context, phone string) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool {
+		return !u.DeletedAt.Valid && u.Phone == phone && u.PhoneVerifiedAt != nil
+	})
+}
+
+func (r *fakeUserRepo) Update(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	updated := *user
+	updated.UpdatedAt = time.Now()
+	r.users[user.ID] = &updated
+	return nil
+}
+
+func (r *fakeUserRepo) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[id]; ok {
+		user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) DeleteUnlessLastRoleHolder(ctx context.Context, id uint, role domain.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	holders := 0
+	for _, user := range r.users {
+		if !user.DeletedAt.Valid && user.HasRole(role) {
+			holders++
+		}
+	}
+	user, ok := r.users[id]
+	if !ok {
+		return nil
+	}
+	if holders <= 1 && user.HasRole(role) {
+		return repository.ErrLastRoleHolder
+	}
+	user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (r *fakeUserRepo) FindDeletedForAnonymization(ctx context.Context, deletedBefore time.Time, limit int) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var users []domain.User
+	for id := uint(1); id <= r.nextID && len(users) < limit; id++ {
+		user, ok := r.users[id]
+		if ok && user.DeletedAt.Valid && user.DeletedAt.Time.Before(deletedBefore) && user.AnonymizedAt == nil {
+			found := *user
+			found.Roles = nil // not preloaded
+			users = append(users, found)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepo) SaveDeleted(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *fakeUserRepo) AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.TOTPLastStep >= step {
+		return false, nil
+	}
+	user.TOTPLastStep = step
+	return true, nil
+}
+
+// fakeSessionRepo implements repository.SessionRepository
+type fakeSessionRepo struct {
+	mu       sync.Mutex
+	sessions []domain.Session
+}
+
+func (r *fakeSessionRepo) Create(ctx context.Context, session *domain.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session.ID = uint(len(r.sessions) + 1)
+	session.CreatedAt = time.Now()
+	r.sessions = append(r.sessions, *session)
+	return nil
+}
+
+func (r *fakeSessionRepo) FindByTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if session.TokenHash == tokenHash {
+			return &session, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeSessionRepo) MarkRotated(ctx context.Context, id uint, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session := &r.sessions[id-1]
+	if session.RotatedAt != nil || session.RevokedAt != nil {
+		return false, nil
+	}
+	session.RotatedAt = &at
+	return true, nil
+}
+
+func (r *fakeSessionRepo) revokeWhere(match func(*domain.Session) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i := range r.sessions {
+		if match(&r.sessions[i]) && r.sessions[i].RevokedAt == nil {
+			r.sessions[i].RevokedAt = &now
+		}
+	}
+}
+
+func (r *fakeSessionRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	r.revokeWhere(func(s *domain.Session) bool { return s.FamilyID == familyID })
+	return nil
+}
+
+func (r *fakeSessionRepo) RevokeAllForUser(ctx context.Context, userUUID string) error {
+	r.revokeWhere(func(s *domain.Session) bool { return s.UserUUID == userUUID })
+	return nil
+}
+
+func (r *fakeSessionRepo) FindAllForUser(ctx context.Context, userUUID string) ([]domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sessions []domain.Session
+	for i := len(r.sessions) - 1; i >= 0; i-- {
+		if r.sessions[i].UserUUID == userUUID {
+			sessions = append(sessions, r.sessions[i])
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeSessionRepo) DeleteAllForUser(ctx context.Context, userUUID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.sessions {
+		if r.sessions[i].UserUUID == userUUID {
+			// Keep IDs aligned with slice positions
+			r.sessions[i] = domain.Session{ID: r.sessions[i].ID}
+		}
+	}
+	return nil
+}
+
+// fakeRevocationRepo implements repository.RevocationRepository
+type fakeRevocationRepo struct {
+	mu      sync.Mutex
+	tokens  map[string]domain.RevokedToken
+	users   map[string]domain.UserRevocation
+	lookups int // database reads, to tell cache hits from misses
+}
+
+func newFakeRevocationRepo() *fakeRevocationRepo {
+	return &fakeRevocationRepo{tokens: map[string]domain.RevokedToken{}, users: map[string]domain.UserRevocation{}}
+}
+
+func (r *fakeRevocationRepo) RevokeToken(ctx context.Context, token *domain.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tokens[token.JTI]; !ok {
+		r.tokens[token.JTI] = *token
+	}
+	return nil
+}
+
+func (r *fakeRevocationRepo) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lookups++
+	_, ok := r.tokens[jti]
+	return ok, nil
+}
+
+func (r *fakeRevocationRepo) RevokeUserTokens(ctx context.Context, userUUID string, before, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users[userUUID] = domain.UserRevocation{UserUUID: userUUID, RevokedBefore: before, ExpiresAt: expiresAt, UpdatedAt: time.Now()}
+	return nil
+}
+
+func (r *fakeRevocationRepo) FindUserRevocation(ctx context.Context, userUUID string) (*domain.UserRevocation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lookups++
+	revocation, ok := r.users[userUUID]
+	if !ok {
+		return nil, nil
+	}
+	return &revocation, nil
+}
+
+func (r *fakeRevocationRepo) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for jti, token := range r.tokens {
+		if token.ExpiresAt.Before(now) {
+			delete(r.tokens, jti)
+			deleted++
+		}
+	}
+	for userUUID, revocation := range r.users {
+		if revocation.ExpiresAt.Before(now) {
+			delete(r.users, userUUID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// fakeOneTimeTokenRepo implements repository.OneTimeTokenRepository
+type fakeOneTimeTokenRepo struct {
+	mu     sync.Mutex
+	tokens []domain.OneTimeToken
+}
+
+func (r *fakeOneTimeTokenRepo) Create(ctx context.Context, token *domain.OneTimeToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = uint(len(r.tokens) + 1)
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, *token)
+	return nil
+}
+
+func (r *fakeOneTimeTokenRepo) FindByHash(ctx context.Context, purpose, tokenHash string) (*domain.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.Purpose == purpose && token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeOneTimeTokenRepo) FindLatest(ctx context.Context, userID uint, purpose string) (*domain.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := len(r.tokens) - 1; i >= 0; i-- {
+		if token := r.tokens[i]; token.UserID == userID && token.Purpose == purpose {
+			return &token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeOneTimeTokenRepo) Consume(ctx context.Context, id uint, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token := &r.tokens[id-1]
+	if token.ConsumedAt != nil {
+		return false, nil
+	}
+	token.ConsumedAt = &at
+	return true, nil
+}
+
+func (r *fakeOneTimeTokenRepo) RecordFailedAttempt(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[id-1].FailedAttempts++
+	return nil
+}
+
+func (r *fakeOneTimeTokenRepo) ConsumeAllForUser(ctx context.Context, userID uint, purpose string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i := range r.tokens {
+		if token := &r.tokens[i]; token.UserID == userID && token.Purpose == purpose && token.ConsumedAt == nil {
+			token.ConsumedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeOneTimeTokenRepo) FindAllForUser(ctx context.Context, userID uint) ([]domain.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tokens []domain.OneTimeToken
+	for i := len(r.tokens) - 1; i >= 0; i-- {
+		if r.tokens[i].UserID == userID {
+			tokens = append(tokens, r.tokens[i])
+		}
+	}
+	return tokens, nil
+}
+
+func (r *fakeOneTimeTokenRepo) DeleteAllForUser(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tokens {
+		if r.tokens[i].UserID == userID {
+			r.tokens[i] = domain.OneTimeToken{ID: r.tokens[i].ID}
+		}
+	}
+	return nil
+}
+
+// expire moves the expiry of every token of a purpose to the given time
+func (r *fakeOneTimeTokenRepo) expire(purpose string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tokens {
+		if r.tokens[i].Purpose == purpose {
+			r.tokens[i].ExpiresAt = at
+		}
+	}
+}
+
+// fakeRecoveryCodeRepo implements repository.RecoveryCodeRepository
+type fakeRecoveryCodeRepo struct {
+	mu    sync.Mutex
+	codes map[uint][]domain.RecoveryCode
+}
+
+func newFakeRecoveryCodeRepo() *fakeRecoveryCodeRepo {
+	return &fakeRecoveryCodeRepo{codes: map[uint][]domain.RecoveryCode{}}
+}
+
+func (r *fakeRecoveryCodeRepo) ReplaceForUser(ctx context.Context, userID uint, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := make([]domain.RecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = domain.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	r.codes[userID] = codes
+	return nil
+}
+
+func (r *fakeRecoveryCodeRepo) Consume(ctx context.Context, userID uint, codeHash string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.codes[userID] {
+		if code := &r.codes[userID][i]; code.CodeHash == codeHash && code.UsedAt == nil {
+			code.UsedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRecoveryCodeRepo) CountUnused(ctx context.Context, userID uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, code := range r.codes[userID] {
+		if code.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRecoveryCodeRepo) DeleteAllForUser(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.codes, userID)
+	return nil
+}
+
+// fakeIdentityRepo implements repository.IdentityRepository
+type fakeIdentityRepo struct {
+	mu         sync.Mutex
+	identities []domain.LinkedIdentity
+	states     []domain.OAuthState
+}
+
+func (r *fakeIdentityRepo) Create(ctx context.Context, identity *domain.LinkedIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.identities {
+		if existing.Provider != identity.Provider {
+			continue
+		}
+		if existing.Subject == identity.Subject {
+			return repository.ErrSubjectLinked
+		}
+		if existing.UserID == identity.UserID {
+			return repository.ErrProviderLinked
+		}
+	}
+	identity.ID = uint(len(r.identities) + 1)
+	identity.CreatedAt = time.Now()
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *fakeIdentityRepo) FindByProviderSubject(ctx context.Context, provider, subject string) (*domain.LinkedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.UserID != 0 && identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeIdentityRepo) FindAllForUser(ctx context.Context, userID uint) ([]domain.LinkedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var identities []domain.LinkedIdentity
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (r *fakeIdentityRepo) MarkUsed(ctx context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.identities[id-1].LastUsedAt = &at
+	return nil
+}
+
+func (r *fakeIdentityRepo) DeleteAllForUser(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.identities {
+		if r.identities[i].UserID == userID {
+			r.identities[i] = domain.LinkedIdentity{ID: r.identities[i].ID}
+		}
+	}
+	return nil
+}
+
+func (r *fakeIdentityRepo) CreateState(ctx context.Context, state *domain.OAuthState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state.ID = uint(len(r.states) + 1)
+	state.CreatedAt = time.Now()
+	r.states = append(r.states, *state)
+	return nil
+}
+
+func (r *fakeIdentityRepo) ConsumeState(ctx context.Context, provider, stateHash string, at time.Time) (*domain.OAuthState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.states {
+		state := &r.states[i]
+		if state.Provider == provider && state.StateHash == stateHash && state.ConsumedAt == nil && state.ExpiresAt.After(at) {
+			state.ConsumedAt = &at
+			consumed := *state
+			return &consumed, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeIdentityRepo) DeleteExpiredStates(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var kept []domain.OAuthState
+	for _, state := range r.states {
+		if !state.ExpiresAt.Before(before) {
+			kept = append(kept, state)
+		}
+	}
+	deleted := int64(len(r.states) - len(kept))
+	r.states = kept
+	return deleted, nil
+}
+
+// fakeRoleRepo implements repository.RoleRepository on the users of a fakeUserRepo
+type fakeRoleRepo struct {
+	users *fakeUserRepo
+	audit *fakeAuditRepo
+}
+
+func (r *fakeRoleRepo) Grant(ctx context.Context, userID uint, role domain.Role, event *domain.AuditEvent) (bool, error) {
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+
+	user := r.users.users[userID]
+	for _, granted := range user.Roles {
+		if granted.Role == role {
+			return false, nil
+		}
+	}
+	user.Roles = append(user.Roles, domain.UserRole{UserID: userID, Role: role, CreatedAt: time.Now()})
+	return true, r.audit.Create(ctx, event)
+}
+
+func (r *fakeRoleRepo) Revoke(ctx context.Context, userID uint, role domain.Role, event *domain.AuditEvent) (bool, error) {
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+
+	return r.revokeLocked(ctx, userID, role, event)
+}
+
+func (r *fakeRoleRepo) RevokeUnlessLast(ctx context.Context, userID uint, role domain.Role, event *domain.AuditEvent) (bool, error) {
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+
+	holders := 0
+	for _, user := range r.users.users {
+		if !user.DeletedAt.Valid && user.HasRole(role) {
+			holders++
+		}
+	}
+	if holders <= 1 && r.users.users[userID].HasRole(role) {
+		return false, repository.ErrLastRoleHolder
+	}
+	return r.revokeLocked(ctx, userID, role, event)
+}
+
+func (r *fakeRoleRepo) revokeLocked(ctx context.Context, userID uint, role domain.Role, event *domain.AuditEvent) (bool, error) {
+	user := r.users.users[userID]
+	for i, granted := range user.Roles {
+		if granted.Role == role {
+			user.Roles = append(user.Roles[:i:i], user.Roles[i+1:]...)
+			return true, r.audit.Create(ctx, event)
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRoleRepo) DeleteAllForUser(ctx context.Context, userID uint) error {
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+
+	if user, ok := r.users.users[userID]; ok {
+		user.Roles = nil
+	}
+	return nil
+}
+
+// fakeAuditRepo implements repository.AuditRepository
+type fakeAuditRepo struct {
+	mu     sync.Mutex
+	events []domain.AuditEvent
+}
+
+func (r *fakeAuditRepo) Create(ctx context.Context, event *domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.ID = uint(len(r.events) + 1)
+	event.CreatedAt = time.Now()
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeAuditRepo) FindRecent(ctx context.Context, targetUUID string, limit int) ([]domain.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []domain.AuditEvent
+	for i := len(r.events) - 1; i >= 0 && len(events) < limit; i-- {
+		if targetUUID == "" || r.events[i].TargetUUID == targetUUID {
+			events = append(events, r.events[i])
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeAuditRepo) FindAllForUser(ctx context.Context, userUUID string) ([]domain.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []domain.AuditEvent
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if r.events[i].ActorUUID == userUUID || r.events[i].TargetUUID == userUUID {
+			events = append(events, r.events[i])
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeAuditRepo) ClearClientIPs(ctx context.Context, actorUUID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.events {
+		if r.events[i].ActorUUID == actorUUID {
+			r.events[i].ClientIP = ""
+		}
+	}
+	return nil
+}
+
+// accountFixture is an account service over fakes, with an outbox in place
+// of email delivery
+type accountFixture struct {
+	service     *accountService
+	users       *fakeUserRepo
+	sessions    *fakeSessionRepo
+	tokens      *fakeOneTimeTokenRepo
+	recovery    *fakeRecoveryCodeRepo
+	identities  *fakeIdentityRepo
+	roles       *fakeRoleRepo
+	audit       *fakeAuditRepo
+	revocations *fakeRevocationRepo
+	attempts    repository.LoginAttemptRepository
+	mail        *mailer.Outbox
+	texts       *sms.Outbox
+}
+
+func newAccountFixture(t *testing.T) *accountFixture {
+	t.Helper()
+
+	keys, err := keyring.NewHMAC("test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &accountFixture{
+		users:       newFakeUserRepo(),
+		sessions:    &fakeSessionRepo{},
+		tokens:      &fakeOneTimeTokenRepo{},
+		recovery:    newFakeRecoveryCodeRepo(),
+		identities:  &fakeIdentityRepo{},
+		audit:       &fakeAuditRepo{},
+		revocations: newFakeRevocationRepo(),
+		attempts:    repository.NewMemoryLoginAttemptRepository(),
+		mail:        mailer.NewOutbox(),
+		texts:       sms.NewOutbox(),
+	}
+	f.roles = &fakeRoleRepo{users: f.users, audit: f.audit}
+	f.service = NewAccountService(
+		f.users,
+		nil,
+		f.sessions,
+		f.tokens,
+		f.recovery,
+		f.identities,
+		f.roles,
+		f.audit,
+		nil,
+		NewTokenService(keys, f.revocations, 15*time.Minute),
+		NewLoginGuard(f.attempts),
+		f.mail,
+		f.texts,
+		AccountSettings{
+			AppBaseURL:      "https://app.example.com",
+			RefreshTokenTTL: 24 * time.Hour,
+			TOTPIssuer:      "Booking Test",
+		},
+	).(*accountService)
+	return f
+}
+
+// createUser stores a user with the given password, hashed at minimum cost
+// to keep tests fast
+func (f *accountFixture) createUser(t *testing.T, email, password string) *domain.User {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &domain.User{Email: email, Name: strings.Split(email, "@")[0], Password: string(hash)}
+	if err := f.users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// fakeCountryRepo implements repository.CountryRepository. Like the
+// Postgres repository, recorded exchange rates are copied to the countries
+// using their currency.
+type fakeCountryRepo struct {
+	mu        sync.Mutex
+	countries []domain.Country
+	rates     []domain.ExchangeRate
+	events    []domain.AuditEvent
+	// imports counts Import calls, each of which is one transaction
+	imports int
+}
+
+func newFakeCountryRepo() *fakeCountryRepo {
+	return &fakeCountryRepo{}
+}
+
+func (r *fakeCountryRepo) add(country domain.Country) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	country.ID = uint(len(r.countries) + 1)
+	r.countries = append(r.countries, country)
+}
+
+func (r *fakeCountryRepo) FindByShortname(ctx context.Context, shortname string) (*domain.Country, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, country := range r.countries {
+		if country.Shortname != nil && *country.Shortname == shortname {
+			return &country, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeCountryRepo) FindByID(ctx context.Context, id uint) (*domain.Country, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, country := range r.countries {
+		if country.ID == id {
+			return &country, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeCountryRepo) FindAll(ctx context.Context) ([]domain.Country, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]domain.Country(nil), r.countries...), nil
+}
+
+func (r *fakeCountryRepo) FindEnabled(ctx context.Context) ([]domain.Country, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var countries []domain.Country
+	for _, country := range r.countries {
+		if !country.IsDisabled() {
+			countries = append(countries, country)
+		}
+	}
+	return countries, nil
+}
+
+func (r *fakeCountryRepo) Create(ctx context.Context, country *domain.Country, rates []domain.ExchangeRate, event *domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	country.ID = uint(len(r.countries) + 1)
+	r.countries = append(r.countries, *country)
+	r.recordLocked(rates)
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeCountryRepo) Update(ctx context.Context, country *domain.Country, rates []domain.ExchangeRate, event *domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.save(*country)
+	r.recordLocked(rates)
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeCountryRepo) Import(ctx context.Context, countries []domain.Country, rates []domain.ExchangeRate, events []domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.imports++
+	for _, country := range countries {
+		if country.ID == 0 {
+			country.ID = uint(len(r.countries) + 1)
+			r.countries = append(r.countries, country)
+			continue
+		}
+		r.save(country)
+	}
+	r.recordLocked(rates)
+	r.events = append(r.events, events...)
+	return nil
+}
+
+// save replaces the stored country with the same ID; callers hold r.mu
+func (r *fakeCountryRepo) save(country domain.Country) {
+	for i := range r.countries {
+		if r.countries[i].ID == country.ID {
+			r.countries[i] = country
+		}
+	}
+}
+
+// recordLocked appends rates not yet recorded for their currency and time
+// and copies them to the countries using the currency; callers hold r.mu
+func (r *fakeCountryRepo) recordLocked(rates []domain.ExchangeRate) {
+	for _, rate := range rates {
+		if slices.ContainsFunc(r.rates, func(recorded domain.ExchangeRate) bool {
+			return recorded.CurrencyCode == rate.CurrencyCode && recorded.EffectiveAt.Equal(rate.EffectiveAt)
+		}) {
+			continue
+		}
+		rate.ID = uint(len(r.rates) + 1)
+		r.rates = append(r.rates, rate)
+
+		value, _ := strconv.ParseFloat(rate.Rate, 64)
+		for i := range r.countries {
+			if strings.ToUpper(deref(r.countries[i].CurrencyCode)) == rate.CurrencyCode {
+				r.countries[i].CurrencyRate = &value
+			}
+		}
+	}
+}
+
+// fakeExchangeRateRepo implements repository.ExchangeRateRepository on the
+// history of a fakeCountryRepo
+type fakeExchangeRateRepo struct {
+	countries *fakeCountryRepo
+}
+
+func (r *fakeExchangeRateRepo) Latest(ctx context.Context) ([]domain.ExchangeRate, error) {
+	return r.AsOf(ctx, time.Now().Add(time.Hour))
+}
+
+func (r *fakeExchangeRateRepo) AsOf(ctx context.Context, at time.Time) ([]domain.ExchangeRate, error) {
+	r.countries.mu.Lock()
+	defer r.countries.mu.Unlock()
+
+	latest := make(map[string]domain.ExchangeRate)
+	for _, rate := range r.countries.rates {
+		if rate.EffectiveAt.After(at) {
+			continue
+		}
+		if current, ok := latest[rate.CurrencyCode]; !ok || rate.EffectiveAt.After(current.EffectiveAt) {
+			latest[rate.CurrencyCode] = rate
+		}
+	}
+	rates := make([]domain.ExchangeRate, 0, len(latest))
+	for _, rate := range latest {
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func (r *fakeExchangeRateRepo) Record(ctx context.Context, rates []domain.ExchangeRate) error {
+	r.countries.mu.Lock()
+	defer r.countries.mu.Unlock()
+
+	r.countries.recordLocked(rates)
+	return nil
+}
+
+func (r *fakeExchangeRateRepo) Set(ctx context.Context, rate *domain.ExchangeRate, event *domain.AuditEvent) error {
+	r.countries.mu.Lock()
+	defer r.countries.mu.Unlock()
+
+	r.countries.recordLocked([]domain.ExchangeRate{*rate})
+	r.countries.events = append(r.countries.events, *event)
+	return nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/repository"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Lockout policy for failed sign-ins. Once a key reaches its free failures,
+// every further failure locks it out for base * 2^(extra failures), capped.
+const (
+	loginAttemptWindow   = time.Minute * 15
+	accountFreeFailures  = 5
+	clientFreeFailures   = 20
+	loginLockoutBase     = time.Minute
+	loginLockoutMaximum  = time.Hour
+	lockoutScopeAccount  = "account"
+	lockoutScopeClientIP = "ip"
+	lockoutRecordTimeout = time.Second * 5
+)
+
+// LockoutError is returned while an account or client IP is locked out
+type LockoutError struct {
+	Scope      string // "account" or "ip"
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	if e.Scope == lockoutScopeAccount {
+		return "account temporarily locked"
+	}
+	return "too many failed sign-in attempts"
+}
+
+// Unwrap exposes the lockout as an apperror so the error middleware can map
+// it, including the Retry-After hint
+func (e *LockoutError) Unwrap() error {
+	if e.IsAccountLock() {
+		return &apperror.Error{Kind: apperror.KindLocked, Code: "account_locked", Message: e.Error(), RetryAfter: e.RetryAfter}
+	}
+	return &apperror.Error{Kind: apperror.KindTooManyRequests, Code: "too_many_attempts", Message: e.Error(), RetryAfter: e.RetryAfter}
+}
+
+// IsAccountLock reports whether the lockout applies to the account rather than the client
+func (e *LockoutError) IsAccountLock() bool {
+	return e.Scope == lockoutScopeAccount
+}
+
+// LoginGuard throttles password guessing per account and per client IP
+type LoginGuard interface {
+	Check(ctx context.Context, email, clientIP string) error
+	RecordFailure(ctx context.Context, email, clientIP string) error
+	RecordSuccess(ctx context.Context, email string) error
+	AccountAttempts(ctx context.Context, email string) (*domain.LoginAttempt, error)
+	ForgetAccount(ctx context.Context, email string) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+// loginGuard implements LoginGuard
+type loginGuard struct {
+	attemptRepo repository.LoginAttemptRepository
+}
+
+// NewLoginGuard creates a new login guard instance
+func NewLoginGuard(attemptRepo repository.LoginAttemptRepository) LoginGuard {
+	return &loginGuard{attemptRepo: attemptRepo}
+}
+
+// Check returns a *LockoutError if either the client or the account is locked out
+func (g *loginGuard) Check(ctx context.Context, email, clientIP string) error {
+	now := time.Now()
+
+	for _, key := range []struct{ key, scope string }{
+		{clientKey(clientIP), lockoutScopeClientIP},
+		{accountKey(email), lockoutScopeAccount},
+	} {
+		attempt, err := g.attemptRepo.Find(ctx, key.key)
+		if err != nil {
+			return err
+		}
+		if attempt != nil && attempt.IsLocked(now) {
+			return &LockoutError{Scope: key.scope, RetryAfter: attempt.LockedUntil.Sub(now)}
+		}
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed sign-in against both the account and the client
+func (g *loginGuard) RecordFailure(ctx context.Context, email, clientIP string) error {
+	// Hanging up right after a wrong guess must not skip the count
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockoutRecordTimeout)
+	defer cancel()
+
+	if err := g.recordFailure(ctx, accountKey(email), accountFreeFailures); err != nil {
+		return err
+	}
+	return g.recordFailure(ctx, clientKey(clientIP), clientFreeFailures)
+}
+
+// RecordSuccess clears the account counter. The client counter is left alone
+// so signing into one's own account cannot reset a guessing IP.
+func (g *loginGuard) RecordSuccess(ctx context.Context, email string) error {
+	return g.attemptRepo.Reset(ctx, accountKey(email))
+}
+
+// AccountAttempts returns the failure counter of the account, or nil if
+// there is none
+func (g *loginGuard) AccountAttempts(ctx context.Context, email string) (*domain.LoginAttempt, error) {
+	return g.attemptRepo.Find(ctx, accountKey(email))
+}
+
+// ForgetAccount drops the failure counter of an account whose data is erased
+func (g *loginGuard) ForgetAccount(ctx context.Context, email string) error {
+	return g.attemptRepo.Reset(ctx, accountKey(email))
+}
+
+// PurgeExpired deletes counters that no longer lock anything out or count
+// towards a lockout
+func (g *loginGuard) PurgeExpired(ctx context.Context) (int64, error) {
+	now := time.Now()
+	return g.attemptRepo.PurgeExpired(ctx, now, now.Add(-loginAttemptWindow))
+}
+
+// recordFailure increments a counter and locks the key once it runs out of free failures
+func (g *loginGuard) recordFailure(ctx context.Context, key string, freeFailures int) error {
+	now := time.Now()
+	attempt, err := g.attemptRepo.RecordFailure(ctx, key, now, now.Add(-loginAttemptWindow))
+	if err != nil {
+		return err
+	}
+
+	if attempt.Failures < freeFailures {
+		return nil
+	}
+	return g.attemptRepo.Lock(ctx, key, now.Add(lockoutDuration(attempt.Failures-freeFailures)))
+}
+
+// lockoutDuration doubles the base lockout for every failure past the free ones
+func lockoutDuration(extraFailures int) time.Duration {
+	duration := loginLockoutBase
+	for i := 0; i < extraFailures && duration < loginLockoutMaximum; i++ {
+		duration *= 2
+	}
+	if duration > loginLockoutMaximum {
+		duration = loginLockoutMaximum
+	}
+	return duration
+}
+
+// accountKey is the counter key for an email address
+func accountKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// clientKey is the counter key for a client IP
+func clientKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// checkDummyPassword runs a bcrypt comparison against a throwaway hash with the
+// same cost as real passwords, for sign-ins to emails that have no account
+func checkDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
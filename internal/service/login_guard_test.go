@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-booking-system/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestLoginGuardLockout(t *testing.T) {
+	type failure struct {
+		email, ip string
+		times     int
+	}
+
+	tests := []struct {
+		name     string
+		failures []failure
+		// success signs in as guest@example.com after the failures
+		success   bool
+		wantScope string // "" for no lockout
+		wantRetry time.Duration
+	}{
+		{
+			name:     "below account limit",
+			failures: []failure{{"guest@example.com", "192.0.2.1", accountFreeFailures - 1}},
+		},
+		{
+			name:      "account limit reached",
+			failures:  []failure{{"guest@example.com", "192.0.2.1", accountFreeFailures}},
+			wantScope: lockoutScopeAccount,
+			wantRetry: loginLockoutBase,
+		},
+		{
+			name:      "lockout doubles per further failure",
+			failures:  []failure{{"guest@example.com", "192.0.2.1", accountFreeFailures + 2}},
+			wantScope: lockoutScopeAccount,
+			wantRetry: 4 * loginLockoutBase,
+		},
+		{
+			name:      "account key ignores case and spaces",
+			failures:  []failure{{" Guest@Example.com", "192.0.2.1", accountFreeFailures}},
+			wantScope: lockoutScopeAccount,
+			wantRetry: loginLockoutBase,
+		},
+		{
+			name: "failures spread over clients",
+			failures: []failure{
+				{"guest@example.com", "192.0.2.1", 2},
+				{"guest@example.com", "192.0.2.2", 2},
+				{"guest@example.com", "192.0.2.3", 1},
+			},
+			wantScope: lockoutScopeAccount,
+			wantRetry: loginLockoutBase,
+		},
+		{
+			name: "client guessing many accounts",
+			failures: func() []failure {
+				var failures []failure
+				for i := 0; i < clientFreeFailures; i++ {
+					failures = append(failures, failure{fmt.Sprintf("user%d@example.com", i), "192.0.2.1", 1})
+				}
+				return failures
+			}(),
+			wantScope: lockoutScopeClientIP,
+			wantRetry: loginLockoutBase,
+		},
+		{
+			name:     "success clears the account",
+			failures: []failure{{"guest@example.com", "192.0.2.1", accountFreeFailures - 1}},
+			success:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			guard := NewLoginGuard(repository.NewMemoryLoginAttemptRepository())
+
+			for _, f := range tt.failures {
+				for i := 0; i < f.times; i++ {
+					if err := guard.RecordFailure(ctx, f.email, f.ip); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if tt.success {
+				if err := guard.RecordSuccess(ctx, "guest@example.com"); err != nil {
+					t.Fatal(err)
+				}
+				// One more failure must not lock the account now
+				if err := guard.RecordFailure(ctx, "guest@example.com", "192.0.2.1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := guard.Check(ctx, "guest@example.com", "192.0.2.1")
+			if tt.wantScope == "" {
+				if err != nil {
+					t.Fatalf("Check = %v, want no lockout", err)
+				}
+				return
+			}
+
+			var lockout *LockoutError
+			if !errors.As(err, &lockout) {
+				t.Fatalf("Check = %v, want a lockout", err)
+			}
+			if lockout.Scope != tt.wantScope {
+				t.Errorf("scope = %q, want %q", lockout.Scope, tt.wantScope)
+			}
+			if lockout.RetryAfter > tt.wantRetry || lockout.RetryAfter < tt.wantRetry-time.Second {
+				t.Errorf("retry after = %v, want %v", lockout.RetryAfter, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestLoginGuardClientLockoutSparesOtherClients(t *testing.T) {
+	ctx := context.Background()
+	guard := NewLoginGuard(repository.NewMemoryLoginAttemptRepository())
+
+	for i := 0; i < clientFreeFailures; i++ {
+		if err := guard.RecordFailure(ctx, fmt.Sprintf("user%d@example.com", i), "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The guessing client is out; the accounts it tried are not
+	if err := guard.Check(ctx, "user0@example.com", "192.0.2.1"); err == nil {
+		t.Error("guessing client was not locked out")
+	}
+	if err := guard.Check(ctx, "user0@example.com", "192.0.2.2"); err != nil {
+		t.Errorf("other client: %v", err)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		extraFailures int
+		want          time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{5, 32 * time.Minute},
+		{6, time.Hour},
+		{1000, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.extraFailures); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", tt.extraFailures, got, tt.want)
+		}
+	}
+}
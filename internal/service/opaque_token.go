@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"go-booking-system/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// generateOpaqueToken returns a random URL-safe token with 256 bits of entropy
+func generateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 digest stored in place of an opaque token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// errInvalidOneTimeToken is returned for unknown, expired or already used tokens
+var errInvalidOneTimeToken = errors.New("invalid one-time token")
+
+// issueOneTimeToken stores the hash of a new single-use token and returns the raw token
+func (s *accountService) issueOneTimeToken(ctx context.Context, userID uint, purpose, target string, ttl time.Duration) (string, error) {
+	rawToken, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	token := &domain.OneTimeToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(rawToken),
+		Target:    target,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
+
+// consumeOneTimeToken redeems a raw token exactly once
+func (s *accountService) consumeOneTimeToken(ctx context.Context, purpose, rawToken string) (*domain.OneTimeToken, error) {
+	token, err := s.tokenRepo.FindByHash(ctx, purpose, hashToken(rawToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errInvalidOneTimeToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !token.IsUsable(now) {
+		return nil, errInvalidOneTimeToken
+	}
+
+	consumed, err := s.tokenRepo.Consume(ctx, token.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, errInvalidOneTimeToken
+	}
+
+	return token, nil
+}
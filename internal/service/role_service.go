@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/dto"
+	"go-booking-system/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxAuditEvents caps how many audit events one request can return
+const maxAuditEvents = 200
+
+// RoleService defines business logic for granting and revoking roles
+type RoleService interface {
+	GetUserRoles(ctx context.Context, targetUUID string) (*dto.UserRoles_Response, error)
+	GrantRole(ctx context.Context, actorUUID, targetUUID string, role domain.Role, clientIP string) (*dto.UserRoles_Response, error)
+	RevokeRole(ctx context.Context, actorUUID, targetUUID string, role domain.Role, clientIP string) (*dto.UserRoles_Response, error)
+	ListAuditEvents(ctx context.Context, targetUUID string, limit int) ([]dto.AuditEventResponse, error)
+}
+
+// roleService implements RoleService
+type roleService struct {
+	userRepo     repository.UserRepository
+	roleRepo     repository.RoleRepository
+	auditRepo    repository.AuditRepository
+	tokenService TokenService
+}
+
+// NewRoleService creates a new role service instance
+func NewRoleService(
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	auditRepo repository.AuditRepository,
+	tokenService TokenService,
+) RoleService {
+	return &roleService{
+		userRepo:     userRepo,
+		roleRepo:     roleRepo,
+		auditRepo:    auditRepo,
+		tokenService: tokenService,
+	}
+}
+
+// GetUserRoles returns the roles and permissions of a user
+func (s *roleService) GetUserRoles(ctx context.Context, targetUUID string) (*dto.UserRoles_Response, error) {
+	user, err := s.findUser(ctx, targetUUID)
+	if err != nil {
+		return nil, err
+	}
+	return toUserRolesResponse(user), nil
+}
+
+// GrantRole gives a user a role. Granting a role the user already has is a
+// no-op and is not audited. actorUUID is empty when run from the command line.
+func (s *roleService) GrantRole(ctx context.Context, actorUUID, targetUUID string, role domain.Role, clientIP string) (*dto.UserRoles_Response, error) {
+	if !role.IsGrantable() {
+		return nil, ErrRoleNotGrantable
+	}
+
+	user, err := s.findUser(ctx, targetUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &domain.AuditEvent{
+		ActorUUID:  actorUUID,
+		Action:     domain.AuditActionRoleGranted,
+		TargetUUID: user.UUID,
+		Detail:     string(role),
+		ClientIP:   clientIP,
+	}
+	if _, err := s.roleRepo.Grant(ctx, user.ID, role, event); err != nil {
+		return nil, apperror.Internal("failed to grant role", err)
+	}
+
+	// New permissions show up in the next access token the user mints
+	return s.GetUserRoles(ctx, user.UUID)
+}
+
+// RevokeRole takes a role away from a user. The user's access tokens are
+// revoked so the lost permissions stop working before the tokens expire.
+func (s *roleService) RevokeRole(ctx context.Context, actorUUID, targetUUID string, role domain.Role, clientIP string) (*dto.UserRoles_Response, error) {
+	if !role.IsGrantable() {
+		return nil, ErrRoleNotRevocable
+	}
+
+	user, err := s.findUser(ctx, targetUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &domain.AuditEvent{
+		ActorUUID:  actorUUID,
+		Action:     domain.AuditActionRoleRevoked,
+		TargetUUID: user.UUID,
+		Detail:     string(role),
+		ClientIP:   clientIP,
+	}
+
+	// Never leave the system without anyone able to manage roles
+	revoke := s.roleRepo.Revoke
+	if role == domain.RoleAdmin {
+		revoke = s.roleRepo.RevokeUnlessLast
+	}
+	revoked, err := revoke(ctx, user.ID, role, event)
+	if err != nil {
+		if errors.Is(err, repository.ErrLastRoleHolder) {
+			return nil, ErrLastAdmin
+		}
+		return nil, apperror.Internal("failed to revoke role", err)
+	}
+
+	if revoked {
+		// Refresh tokens stay valid, so the user simply gets new claims on refresh
+		if err := s.tokenService.RevokeAllAccessTokens(ctx, user.UUID); err != nil {
+			return nil, apperror.Internal("failed to revoke access tokens", err)
+		}
+	}
+
+	return s.GetUserRoles(ctx, user.UUID)
+}
+
+// ListAuditEvents returns the newest audit events, optionally about one user
+func (s *roleService) ListAuditEvents(ctx context.Context, targetUUID string, limit int) ([]dto.AuditEventResponse, error) {
+	if limit <= 0 || limit > maxAuditEvents {
+		limit = maxAuditEvents
+	}
+
+	events, err := s.auditRepo.FindRecent(ctx, targetUUID, limit)
+	if err != nil {
+		return nil, apperror.Internal("failed to load audit events", err)
+	}
+
+	response := make([]dto.AuditEventResponse, len(events))
+	for i, event := range events {
+		response[i] = dto.AuditEventResponse{
+			ActorUUID:  event.ActorUUID,
+			Action:     event.Action,
+			TargetUUID: event.TargetUUID,
+			Detail:     event.Detail,
+			ClientIP:   event.ClientIP,
+			CreatedAt:  event.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return response, nil
+}
+
+// findUser loads a user with their roles
+func (s *roleService) findUser(ctx context.Context, userUUID string) (*domain.User, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, apperror.Internal("failed to find user", err)
+	}
+	return user, nil
+}
+
+// toUserRolesResponse maps a user's roles to their API representation
+func toUserRolesResponse(user *domain.User) *dto.UserRoles_Response {
+	roles := user.RoleNames()
+	return &dto.UserRoles_Response{
+		UUID:        user.UUID,
+		Roles:       roleStrings(roles),
+		Permissions: permissionStrings(domain.PermissionsFor(roles)),
+	}
+}
+
+// roleStrings converts roles for use in JSON payloads
+func roleStrings(roles []domain.Role) []string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return names
+}
+
+// permissionStrings converts permissions for use in JSON payloads
+func permissionStrings(permissions []domain.Permission) []string {
+	names := make([]string, len(permissions))
+	for i, permission := range permissions {
+		names[i] = string(permission)
+	}
+	return names
+}
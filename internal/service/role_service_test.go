@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"slices"
+	"testing"
+)
+
+func TestRevokeRole(t *testing.T) {
+	tests := []struct {
+		name string
+		// others are granted roles besides the target; a name starting with
+		// "deleted" is deleted afterwards
+		others      map[string][]domain.Role
+		targetRoles []domain.Role
+		revoke      domain.Role
+		wantErr     error
+		wantRevoked bool
+	}{
+		{
+			name:        "one of two admins",
+			others:      map[string][]domain.Role{"other": {domain.RoleAdmin}},
+			targetRoles: []domain.Role{domain.RoleAdmin},
+			revoke:      domain.RoleAdmin,
+			wantRevoked: true,
+		},
+		{
+			name:        "last admin",
+			others:      map[string][]domain.Role{"other": {domain.RoleHost}},
+			targetRoles: []domain.Role{domain.RoleAdmin},
+			revoke:      domain.RoleAdmin,
+			wantErr:     ErrLastAdmin,
+		},
+		{
+			name:        "only other admin is deleted",
+			others:      map[string][]domain.Role{"deleted": {domain.RoleAdmin}},
+			targetRoles: []domain.Role{domain.RoleAdmin},
+			revoke:      domain.RoleAdmin,
+			wantErr:     ErrLastAdmin,
+		},
+		{
+			name:        "admin from a user without it",
+			others:      map[string][]domain.Role{"other": {domain.RoleAdmin}},
+			targetRoles: []domain.Role{domain.RoleHost},
+			revoke:      domain.RoleAdmin,
+		},
+		{
+			name:        "host from the last admin",
+			targetRoles: []domain.Role{domain.RoleAdmin, domain.RoleHost},
+			revoke:      domain.RoleHost,
+			wantRevoked: true,
+		},
+		{
+			name:        "guest",
+			targetRoles: []domain.Role{domain.RoleAdmin},
+			revoke:      domain.RoleGuest,
+			wantErr:     ErrRoleNotRevocable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			ctx := context.Background()
+			service := NewRoleService(f.users, f.roles, f.audit, f.service.tokenService)
+
+			grant := func(user *domain.User, roles []domain.Role) {
+				for _, role := range roles {
+					if _, err := f.roles.Grant(ctx, user.ID, role, &domain.AuditEvent{Action: domain.AuditActionRoleGranted}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			for name, roles := range tt.others {
+				other := f.createUser(t, name+"@example.com", "correct horse")
+				grant(other, roles)
+				if name == "deleted" {
+					if err := f.users.Delete(ctx, other.ID); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			target := f.createUser(t, "target@example.com", "correct horse")
+			grant(target, tt.targetRoles)
+			eventsBefore := len(f.audit.events)
+
+			response, err := service.RevokeRole(ctx, "admin-uuid", target.UUID, tt.revoke, "192.0.2.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RevokeRole error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, _ := f.users.FindByID(ctx, target.ID)
+			hasRole := stored.HasRole(tt.revoke)
+			if hasRole && tt.wantErr == nil {
+				t.Errorf("user still has %s", tt.revoke)
+			}
+			if wantKept := slices.Contains(tt.targetRoles, tt.revoke) && tt.wantErr != nil; wantKept && !hasRole {
+				t.Errorf("user lost %s", tt.revoke)
+			}
+			if response != nil && slices.Contains(response.Roles, string(tt.revoke)) {
+				t.Errorf("response roles = %v, still listing %s", response.Roles, tt.revoke)
+			}
+
+			audited := len(f.audit.events) - eventsBefore
+			if tt.wantRevoked && (audited != 1 || f.audit.events[eventsBefore].Action != domain.AuditActionRoleRevoked) {
+				t.Errorf("audit events = %+v, want one revocation", f.audit.events[eventsBefore:])
+			}
+			if !tt.wantRevoked && audited != 0 {
+				t.Errorf("audited %d events for no change", audited)
+			}
+			if _, revoked := f.revocations.users[target.UUID]; revoked != tt.wantRevoked {
+				t.Errorf("access tokens revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+		})
+	}
+}
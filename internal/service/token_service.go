@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"go-booking-system/internal/apperror"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/keyring"
+	"go-booking-system/internal/repository"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// revocationCacheTTL is how long a "not revoked" answer is trusted before the
+// database is asked again. Revocations made on another replica take at most
+// this long to be honoured here.
+const revocationCacheTTL = 30 * time.Second
+
+func init() {
+	// Issue iat/exp with millisecond precision so a token minted right after
+	// "log out everywhere" is distinguishable from the ones it revoked
+	jwt.TimePrecision = time.Millisecond
+}
+
+// AccessClaims is the payload of a JWT access token
+type AccessClaims struct {
+	UUID          string   `json:"uuid"`
+	SessionID     string   `json:"sid,omitempty"` // refresh token family the token was minted for
+	EmailVerified bool     `json:"email_verified"`
+	Roles         []string `json:"roles,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// HasRole reports whether the token was minted for a user holding the role
+func (c *AccessClaims) HasRole(role domain.Role) bool {
+	for _, held := range c.Roles {
+		if held == string(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the token grants the permission
+func (c *AccessClaims) HasPermission(permission domain.Permission) bool {
+	for _, held := range c.Permissions {
+		if held == string(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenService mints, verifies and revokes JWT access tokens
+type TokenService interface {
+	GenerateAccessToken(user *domain.User, sessionID string) (string, error)
+	ParseAccessToken(ctx context.Context, tokenString string) (*AccessClaims, error)
+	RevokeAccessToken(ctx context.Context, claims *AccessClaims) error
+	RevokeAllAccessTokens(ctx context.Context, userUUID string) error
+	PurgeExpiredRevocations(ctx context.Context) (int64, error)
+	PublicKeys() keyring.JWKS
+}
+
+// tokenService implements TokenService
+type tokenService struct {
+	keys            *keyring.Keyring
+	revocationRepo  repository.RevocationRepository
+	accessTokenTTL  time.Duration
+	revokedTokens   *ttlCache[bool]
+	userRevocations *ttlCache[time.Time]
+}
+
+// NewTokenService creates a new token service instance issuing access tokens
+// that expire after accessTokenTTL
+func NewTokenService(keys *keyring.Keyring, revocationRepo repository.RevocationRepository, accessTokenTTL time.Duration) TokenService {
+	return &tokenService{
+		keys:            keys,
+		revocationRepo:  revocationRepo,
+		accessTokenTTL:  accessTokenTTL,
+		revokedTokens:   newTTLCache[bool](),
+		userRevocations: newTTLCache[time.Time](),
+	}
+}
+
+// GenerateAccessToken creates a JWT with a unique jti, signed with the active key
+func (s *tokenService) GenerateAccessToken(user *domain.User, sessionID string) (string, error) {
+	now := time.Now()
+	roles := user.RoleNames()
+	claims := AccessClaims{
+		UUID:          user.UUID,
+		SessionID:     sessionID,
+		EmailVerified: user.IsEmailVerified(),
+		Roles:         roleStrings(roles),
+		Permissions:   permissionStrings(domain.PermissionsFor(roles)),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTokenTTL)),
+		},
+	}
+
+	return s.keys.Sign(claims)
+}
+
+// ParseAccessToken verifies signature, expiry and revocation status of a token
+func (s *tokenService) ParseAccessToken(ctx context.Context, tokenString string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keys.Keyfunc,
+		jwt.WithValidMethods(s.keys.ValidMethods()), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	// Tokens without a jti or iat cannot be revoked, so they are not accepted
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := s.isRevoked(ctx, claims)
+	if err != nil {
+		return nil, apperror.Internal("failed to check token revocation", err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// RevokeAccessToken adds a single token to the revocation list
+func (s *tokenService) RevokeAccessToken(ctx context.Context, claims *AccessClaims) error {
+	expiresAt := time.Now().Add(s.accessTokenTTL)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	err := s.revocationRepo.RevokeToken(ctx, &domain.RevokedToken{
+		JTI:       claims.ID,
+		UserUUID:  claims.UUID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	// A revoked token stays revoked, so cache it until it would expire anyway
+	s.revokedTokens.set(claims.ID, true, time.Until(expiresAt))
+	return nil
+}
+
+// RevokeAllAccessTokens revokes every token issued to the user so far
+func (s *tokenService) RevokeAllAccessTokens(ctx context.Context, userUUID string) error {
+	// Tokens carry millisecond timestamps; anything issued before this
+	// millisecond is revoked, anything minted from now on is not
+	before := time.Now().Truncate(time.Millisecond)
+	if err := s.revocationRepo.RevokeUserTokens(ctx, userUUID, before, before.Add(s.accessTokenTTL)); err != nil {
+		return err
+	}
+
+	s.userRevocations.set(userUUID, before, revocationCacheTTL)
+	return nil
+}
+
+// PurgeExpiredRevocations deletes revocations of tokens that have expired;
+// an expired token is rejected without them
+func (s *tokenService) PurgeExpiredRevocations(ctx context.Context) (int64, error) {
+	return s.revocationRepo.PurgeExpired(ctx, time.Now())
+}
+
+// PublicKeys returns the JWKS other services use to verify our tokens
+func (s *tokenService) PublicKeys() keyring.JWKS {
+	return s.keys.PublicJWKS()
+}
+
+// isRevoked consults the cache first and falls back to the database
+func (s *tokenService) isRevoked(ctx context.Context, claims *AccessClaims) (bool, error) {
+	// Check user-wide revocation ("log out everywhere")
+	before, ok := s.userRevocations.get(claims.UUID)
+	if !ok {
+		revocation, err := s.revocationRepo.FindUserRevocation(ctx, claims.UUID)
+		if err != nil {
+			return false, err
+		}
+		if revocation != nil {
+			before = revocation.RevokedBefore
+		}
+		s.userRevocations.set(claims.UUID, before, revocationCacheTTL)
+	}
+	// iat travels as float seconds and can parse a millisecond early, which
+	// would revoke a token minted in the revoking millisecond itself
+	if !before.IsZero() && claims.IssuedAt.Add(time.Millisecond).Before(before) {
+		return true, nil
+	}
+
+	// Check the individual token
+	revoked, ok := s.revokedTokens.get(claims.ID)
+	if ok {
+		return revoked, nil
+	}
+
+	revoked, err := s.revocationRepo.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return false, err
+	}
+
+	ttl := revocationCacheTTL
+	if revoked && claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+	}
+	s.revokedTokens.set(claims.ID, revoked, ttl)
+	return revoked, nil
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/keyring"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestTokenService(t *testing.T, repo *fakeRevocationRepo) *tokenService {
+	t.Helper()
+
+	keys, err := keyring.NewHMAC("test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTokenService(keys, repo, 15*time.Minute).(*tokenService)
+}
+
+// expireCache ages every entry of a cache past its expiry
+func expireCache[V any](c *ttlCache[V]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		entry.expiresAt = time.Now().Add(-time.Second)
+		c.entries[key] = entry
+	}
+}
+
+func TestParseAccessTokenRevocation(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{UUID: "7d1f4c2e-0000-4000-8000-000000000001"}
+
+	tests := []struct {
+		name string
+		// revoke runs against the issuing replica after the token is minted
+		revoke func(s *tokenService, claims *AccessClaims) error
+		// mintAfter mints the presented token only after revoke has run
+		mintAfter bool
+		wantErr   error
+	}{
+		{
+			name:   "not revoked",
+			revoke: func(s *tokenService, claims *AccessClaims) error { return nil },
+		},
+		{
+			name: "jti revoked",
+			revoke: func(s *tokenService, claims *AccessClaims) error {
+				return s.RevokeAccessToken(ctx, claims)
+			},
+			wantErr: ErrTokenRevoked,
+		},
+		{
+			name: "other jti revoked",
+			revoke: func(s *tokenService, claims *AccessClaims) error {
+				other := *claims
+				other.ID = "another-jti"
+				return s.RevokeAccessToken(ctx, &other)
+			},
+		},
+		{
+			name: "user revoked",
+			revoke: func(s *tokenService, claims *AccessClaims) error {
+				// Revocation has millisecond resolution, like iat
+				time.Sleep(2 * time.Millisecond)
+				return s.RevokeAllAccessTokens(ctx, claims.UUID)
+			},
+			wantErr: ErrTokenRevoked,
+		},
+		{
+			name: "minted after user revocation",
+			revoke: func(s *tokenService, claims *AccessClaims) error {
+				return s.RevokeAllAccessTokens(ctx, claims.UUID)
+			},
+			mintAfter: true,
+		},
+		{
+			name: "other user revoked",
+			revoke: func(s *tokenService, claims *AccessClaims) error {
+				return s.RevokeAllAccessTokens(ctx, "someone-else")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRevocationRepo()
+			issuer := newTestTokenService(t, repo)
+
+			token, err := issuer.GenerateAccessToken(user, "family")
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := issuer.ParseAccessToken(ctx, token)
+			if err != nil {
+				t.Fatalf("fresh token: %v", err)
+			}
+			if err := tt.revoke(issuer, claims); err != nil {
+				t.Fatal(err)
+			}
+			if tt.mintAfter {
+				time.Sleep(2 * time.Millisecond)
+				if token, err = issuer.GenerateAccessToken(user, "family"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The replica that revoked knows at once; another one asks the database
+			for name, s := range map[string]*tokenService{"issuer": issuer, "replica": newTestTokenService(t, repo)} {
+				if _, err := s.ParseAccessToken(ctx, token); !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s: ParseAccessToken error = %v, want %v", name, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAccessTokenRejectsInvalid(t *testing.T) {
+	s := newTestTokenService(t, newFakeRevocationRepo())
+	now := time.Now()
+
+	sign := func(claims AccessClaims) string {
+		token, err := s.keys.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := jwt.RegisteredClaims{
+		ID:        "jti",
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"garbage", "not.a.jwt"},
+		{"expired", sign(AccessClaims{UUID: "u", RegisteredClaims: jwt.RegisteredClaims{
+			ID: "jti", IssuedAt: jwt.NewNumericDate(now.Add(-time.Hour)), ExpiresAt: jwt.NewNumericDate(now.Add(-time.Minute)),
+		}})},
+		{"no expiry", sign(AccessClaims{UUID: "u", RegisteredClaims: jwt.RegisteredClaims{ID: "jti", IssuedAt: jwt.NewNumericDate(now)}})},
+		{"no jti", sign(AccessClaims{UUID: "u", RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt: valid.IssuedAt, ExpiresAt: valid.ExpiresAt,
+		}})},
+		{"no iat", sign(AccessClaims{UUID: "u", RegisteredClaims: jwt.RegisteredClaims{ID: "jti", ExpiresAt: valid.ExpiresAt}})},
+		{"wrong secret", func() string {
+			other, err := keyring.NewHMAC("another-secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := other.Sign(AccessClaims{UUID: "u", RegisteredClaims: valid})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}()},
+		{"unsigned", func() string {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodNone, AccessClaims{UUID: "u", RegisteredClaims: valid}).
+				SignedString(jwt.UnsafeAllowNoneSignatureType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.ParseAccessToken(context.Background(), tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("ParseAccessToken error = %v, want invalid token", err)
+			}
+		})
+	}
+}
+
+func TestParseAccessTokenCachesRevocationChecks(t *testing.T) {
+	repo := newFakeRevocationRepo()
+	s := newTestTokenService(t, repo)
+
+	token, err := s.GenerateAccessToken(&domain.User{UUID: "user"}, "family")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := s.ParseAccessToken(context.Background(), token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// One read for the user-wide revocation and one for the jti
+	if repo.lookups != 2 {
+		t.Errorf("database lookups = %d, want 2", repo.lookups)
+	}
+
+	// Entries expire, after which the database is asked again
+	expireCache(s.userRevocations)
+	expireCache(s.revokedTokens)
+	if _, err := s.ParseAccessToken(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+	if repo.lookups != 4 {
+		t.Errorf("database lookups after expiry = %d, want 4", repo.lookups)
+	}
+}
+
+func TestPurgeExpiredRevocations(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRevocationRepo()
+	s := newTestTokenService(t, repo)
+
+	for _, userUUID := range []string{"expired", "live"} {
+		token, err := s.GenerateAccessToken(&domain.User{UUID: userUUID}, "family")
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := s.ParseAccessToken(ctx, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RevokeAccessToken(ctx, claims); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RevokeAllAccessTokens(ctx, userUUID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A user revocation lasts as long as the tokens it covers
+	if got, want := repo.users["live"].ExpiresAt, repo.users["live"].RevokedBefore.Add(s.accessTokenTTL); !got.Equal(want) {
+		t.Errorf("user revocation expires at %v, want %v", got, want)
+	}
+
+	// Age one user's rows past the lifetime of their tokens
+	for jti, token := range repo.tokens {
+		if token.UserUUID == "expired" {
+			token.ExpiresAt = time.Now().Add(-time.Second)
+			repo.tokens[jti] = token
+		}
+	}
+	revocation := repo.users["expired"]
+	revocation.ExpiresAt = time.Now().Add(-time.Second)
+	repo.users["expired"] = revocation
+
+	deleted, err := s.PurgeExpiredRevocations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted = %d, want 2", deleted)
+	}
+	if _, ok := repo.users["live"]; !ok || len(repo.tokens) != 1 {
+		t.Errorf("live revocations were purged: users %v, tokens %v", repo.users, repo.tokens)
+	}
+}
+
+func TestParseAccessTokenRevocationBoundary(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRevocationRepo()
+	s := newTestTokenService(t, repo)
+	start := time.Now().Truncate(time.Second)
+
+	sign := func(issuedAt time.Time) string {
+		token, err := s.keys.Sign(AccessClaims{UUID: "user", RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti",
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(time.Hour)),
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	// Every millisecond of a second, as some do not survive the float round trip
+	for ms := 0; ms < 1000; ms++ {
+		before := start.Add(time.Duration(ms) * time.Millisecond)
+		if err := repo.RevokeUserTokens(ctx, "user", before, before.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		expireCache(s.userRevocations)
+
+		if _, err := s.ParseAccessToken(ctx, sign(before)); err != nil {
+			t.Fatalf("token minted in the revoking millisecond %v: %v", before, err)
+		}
+		if _, err := s.ParseAccessToken(ctx, sign(before.Add(-2*time.Millisecond))); !errors.Is(err, ErrTokenRevoked) {
+			t.Fatalf("token minted two milliseconds before %v: %v, want token revoked", before, err)
+		}
+	}
+}
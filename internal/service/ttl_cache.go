@@ -0,0 +1,64 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// ttlCacheMaxEntries bounds a cache before expired entries are swept
+const ttlCacheMaxEntries = 10000
+
+// cacheEntry is a cached value with its expiry
+type cacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// ttlCache is a small in-process cache for values that may be a little stale,
+// such as revocations checked on every request and currency converters.
+type ttlCache[V any] struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry[V]
+}
+
+// newTTLCache creates an empty cache
+func newTTLCache[V any]() *ttlCache[V] {
+	return &ttlCache[V]{entries: make(map[string]cacheEntry[V])}
+}
+
+// get returns a cached value that has not yet expired
+func (c *ttlCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// set stores a value until ttl elapses
+func (c *ttlCache[V]) set(key string, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= ttlCacheMaxEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = cacheEntry[V]{value: value, expiresAt: now.Add(ttl)}
+}
+
+// delete drops a cached value so the next get misses
+func (c *ttlCache[V]) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
@@ -0,0 +1,47 @@
+package sms
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogSender writes messages to a file, or to the standard logger when no path
+// is given, instead of sending them. Use it for local development.
+type LogSender struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewLogSender creates a sender that appends messages to path
+func NewLogSender(path string) *LogSender {
+	return &LogSender{path: path}
+}
+
+// Send records the message
+func (s *LogSender) Send(msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	entry := fmt.Sprintf("--- %s\nTo: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), msg.To, msg.Body)
+
+	if s.path == "" {
+		log.Print("SMS not sent (log sender):\n" + entry)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(entry)
+	return err
+}
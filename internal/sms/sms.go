@@ -0,0 +1,28 @@
+package sms
+
+import (
+	"errors"
+	"strings"
+)
+
+// Message is a text message to an E.164 phone number
+type Message struct {
+	To   string
+	Body string
+}
+
+// Sender delivers text messages
+type Sender interface {
+	Send(msg Message) error
+}
+
+// validate rejects messages that are not addressed to an E.164 number
+func (m Message) validate() error {
+	if !strings.HasPrefix(m.To, "+") || len(m.To) < 2 {
+		return errors.New("sms: recipient is not an E.164 number")
+	}
+	if m.Body == "" {
+		return errors.New("sms: body is empty")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package sms
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TwilioSender sends messages through Twilio's Messages API
+type TwilioSender struct {
+	accountSID string
+	authToken  string
+	from       string
+	client     *http.Client
+}
+
+// NewTwilioSender creates a sender for a Twilio account. from is the sending
+// number or messaging service SID.
+func NewTwilioSender(accountSID, authToken, from string) *TwilioSender {
+	return &TwilioSender{
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send delivers a message via Twilio
+func (s *TwilioSender) Send(msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	form.Set("To", msg.To)
+	form.Set("Body", msg.Body)
+	if strings.HasPrefix(s.from, "MG") {
+		form.Set("MessagingServiceSid", s.from)
+	} else {
+		form.Set("From", s.from)
+	}
+
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", url.PathEscape(s.accountSID))
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("sms: twilio returned %s: %s", resp.Status, body)
+	}
+	return nil
+}
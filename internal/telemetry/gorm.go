@@ -0,0 +1,91 @@
+package telemetry
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gormSpanKey is where the open span is kept between the before and after callbacks
+const gormSpanKey = "telemetry:span"
+
+// GormTracing is a GORM plugin that records a client span for every query.
+// Spans are children of the span in the statement context, so repositories
+// must use WithContext. The SQL keeps its placeholders; bound values, which
+// may hold personal data, are never recorded.
+type GormTracing struct {
+	tracer trace.Tracer
+}
+
+// NewGormTracing creates the plugin using the global tracer provider
+func NewGormTracing() *GormTracing {
+	return &GormTracing{tracer: otel.Tracer("go-booking-system/gorm")}
+}
+
+// Name implements gorm.Plugin
+func (p *GormTracing) Name() string {
+	return "telemetry:tracing"
+}
+
+// Initialize implements gorm.Plugin by wrapping each callback chain
+func (p *GormTracing) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("telemetry:before_create", p.start("create")),
+		cb.Create().After("gorm:create").Register("telemetry:after_create", p.end),
+		cb.Query().Before("gorm:query").Register("telemetry:before_query", p.start("query")),
+		cb.Query().After("gorm:query").Register("telemetry:after_query", p.end),
+		cb.Update().Before("gorm:update").Register("telemetry:before_update", p.start("update")),
+		cb.Update().After("gorm:update").Register("telemetry:after_update", p.end),
+		cb.Delete().Before("gorm:delete").Register("telemetry:before_delete", p.start("delete")),
+		cb.Delete().After("gorm:delete").Register("telemetry:after_delete", p.end),
+		cb.Row().Before("gorm:row").Register("telemetry:before_row", p.start("row")),
+		cb.Row().After("gorm:row").Register("telemetry:after_row", p.end),
+		cb.Raw().Before("gorm:raw").Register("telemetry:before_raw", p.start("raw")),
+		cb.Raw().After("gorm:raw").Register("telemetry:after_raw", p.end),
+	)
+}
+
+// start opens a span and puts it in the statement context
+func (p *GormTracing) start(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := p.tracer.Start(db.Statement.Context, "db."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(operation)),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+// end records the query and its outcome on the span and closes it
+func (p *GormTracing) end(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+
+	// Not finding a row is an expected outcome, not a failed query
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
@@ -0,0 +1,169 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTracedDB returns a dry-run database, which builds SQL without a server,
+// with the tracing plugin recording into spans
+func newTracedDB(t *testing.T, spans *tracetest.SpanRecorder) (*gorm.DB, trace.Tracer) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test")
+	if err := db.Use(&GormTracing{tracer: tracer}); err != nil {
+		t.Fatal(err)
+	}
+	return db, tracer
+}
+
+func TestGormTracing(t *testing.T) {
+	tests := []struct {
+		name      string
+		run       func(db *gorm.DB) error
+		failWith  error // injected into the query callbacks
+		wantName  string
+		wantTable string
+		wantSQL   string
+		wantError bool
+	}{
+		{
+			name: "query",
+			run: func(db *gorm.DB) error {
+				return db.Where("email = ?", "guest@example.com").Find(&[]domain.User{}).Error
+			},
+			wantName:  "db.query",
+			wantTable: "users",
+			wantSQL:   `SELECT * FROM "users" WHERE email = $1`,
+		},
+		{
+			name:      "create",
+			run:       func(db *gorm.DB) error { return db.Create(&domain.AuditEvent{Action: "test", Detail: "secret"}).Error },
+			wantName:  "db.create",
+			wantTable: "audit_events",
+			wantSQL:   `INSERT INTO "audit_events"`,
+		},
+		{
+			name: "update",
+			run: func(db *gorm.DB) error {
+				return db.Model(&domain.User{}).Where("id = ?", 1).Update("name", "Secret Name").Error
+			},
+			wantName:  "db.update",
+			wantTable: "users",
+			wantSQL:   `UPDATE "users" SET "name"=$1`,
+		},
+		{
+			name: "delete",
+			run: func(db *gorm.DB) error {
+				return db.Where("key = ?", "email:guest@example.com").Delete(&domain.LoginAttempt{}).Error
+			},
+			wantName:  "db.delete",
+			wantTable: "login_attempts",
+			wantSQL:   `DELETE FROM "login_attempts" WHERE key = $1`,
+		},
+		{
+			name:     "raw",
+			run:      func(db *gorm.DB) error { return db.Exec("SELECT pg_sleep(?)", 1).Error },
+			wantName: "db.raw",
+			wantSQL:  "SELECT pg_sleep($1)",
+		},
+		{
+			name:      "failed query",
+			run:       func(db *gorm.DB) error { return db.Find(&[]domain.User{}).Error },
+			failWith:  errors.New("connection reset"),
+			wantName:  "db.query",
+			wantTable: "users",
+			wantSQL:   `SELECT * FROM "users"`,
+			wantError: true,
+		},
+		{
+			name:      "row not found",
+			run:       func(db *gorm.DB) error { return db.Find(&[]domain.User{}).Error },
+			failWith:  gorm.ErrRecordNotFound,
+			wantName:  "db.query",
+			wantTable: "users",
+			wantSQL:   `SELECT * FROM "users"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := tracetest.NewSpanRecorder()
+			db, tracer := newTracedDB(t, spans)
+			if tt.failWith != nil {
+				err := db.Callback().Query().After("gorm:query").Before("telemetry:after_query").
+					Register("test:fail", func(db *gorm.DB) { _ = db.AddError(tt.failWith) })
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctx, parent := tracer.Start(context.Background(), "request")
+			err := tt.run(db.WithContext(ctx))
+			parent.End()
+			if !errors.Is(err, tt.failWith) {
+				t.Fatalf("query error = %v, want %v", err, tt.failWith)
+			}
+
+			ended := spans.Ended()
+			if len(ended) != 2 {
+				t.Fatalf("spans = %d, want the query and its parent", len(ended))
+			}
+			span := ended[0]
+			if span.Name() != tt.wantName || span.SpanKind() != trace.SpanKindClient {
+				t.Errorf("span = %q (%v), want client span %q", span.Name(), span.SpanKind(), tt.wantName)
+			}
+			if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+				t.Error("query span is not a child of the request span")
+			}
+
+			attrs := map[attribute.Key]attribute.Value{}
+			for _, attr := range span.Attributes() {
+				attrs[attr.Key] = attr.Value
+			}
+			if got := attrs[semconv.DBSystemNameKey].AsString(); got != "postgresql" {
+				t.Errorf("db.system.name = %q, want postgresql", got)
+			}
+			if got := attrs[semconv.DBCollectionNameKey].AsString(); got != tt.wantTable {
+				t.Errorf("db.collection.name = %q, want %q", got, tt.wantTable)
+			}
+			query := attrs[semconv.DBQueryTextKey].AsString()
+			if !strings.HasPrefix(query, tt.wantSQL) {
+				t.Errorf("db.query.text = %q, want prefix %q", query, tt.wantSQL)
+			}
+			// Bound values stay out of the span
+			for _, value := range []string{"guest@example.com", "secret", "Secret Name"} {
+				if strings.Contains(query, value) {
+					t.Errorf("db.query.text = %q leaks %q", query, value)
+				}
+			}
+
+			if gotError := span.Status().Code == codes.Error; gotError != tt.wantError {
+				t.Errorf("span status = %v, want error = %v", span.Status(), tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"go-booking-system/internal/domain"
+	"go-booking-system/internal/service"
+)
+
+// loginGuard counts sign-in outcomes as they pass through a service.LoginGuard.
+// Every completed sign-in reports its success to the guard, but only password,
+// SMS code and two-factor attempts are checked and report failures; magic links
+// and social sign-ins have no guessable secret, so their failures go uncounted.
+type loginGuard struct {
+	next    service.LoginGuard
+	metrics *Metrics
+}
+
+// InstrumentLoginGuard wraps guard so sign-ins are counted in metrics
+func InstrumentLoginGuard(guard service.LoginGuard, metrics *Metrics) service.LoginGuard {
+	return &loginGuard{next: guard, metrics: metrics}
+}
+
+// Check counts a refused attempt when the account or client is locked out
+func (g *loginGuard) Check(ctx context.Context, email, clientIP string) error {
+	err := g.next.Check(ctx, email, clientIP)
+	var lockout *service.LockoutError
+	if errors.As(err, &lockout) {
+		g.metrics.RecordSignIn(SignInLocked)
+	}
+	return err
+}
+
+// RecordFailure counts a failed attempt
+func (g *loginGuard) RecordFailure(ctx context.Context, email, clientIP string) error {
+	g.metrics.RecordSignIn(SignInFailure)
+	return g.next.RecordFailure(ctx, email, clientIP)
+}
+
+// RecordSuccess counts a completed sign-in
+func (g *loginGuard) RecordSuccess(ctx context.Context, email string) error {
+	g.metrics.RecordSignIn(SignInSuccess)
+	return g.next.RecordSuccess(ctx, email)
+}
+
+// AccountAttempts passes through uncounted
+func (g *loginGuard) AccountAttempts(ctx context.Context, email string) (*domain.LoginAttempt, error) {
+	return g.next.AccountAttempts(ctx, email)
+}
+
+// ForgetAccount passes through uncounted
+func (g *loginGuard) ForgetAccount(ctx context.Context, email string) error {
+	return g.next.ForgetAccount(ctx, email)
+}
+
+// PurgeExpired passes through uncounted
+func (g *loginGuard) PurgeExpired(ctx context.Context) (int64, error) {
+	return g.next.PurgeExpired(ctx)
+}
@@ -0,0 +1,81 @@
+package telemetry
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace prefixes every metric this service exports
+const metricsNamespace = "booking"
+
+// Sign-in outcomes counted by the signins_total metric
+const (
+	SignInSuccess = "success"
+	SignInFailure = "failure"
+	SignInLocked  = "locked"
+)
+
+// Metrics holds the Prometheus collectors of the service in its own registry
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestDuration *prometheus.HistogramVec
+	signIns         *prometheus.CounterVec
+}
+
+// NewMetrics creates the collectors along with the Go runtime and process ones
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		signIns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "signins_total",
+			Help:      "Sign-in attempts by outcome: success, failure or locked.",
+		}, []string{"outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.signIns,
+	)
+	return m
+}
+
+// RegisterDB exports the connection pool statistics of db
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveRequest records one served request. route is the route template,
+// e.g. /api/admin/users/:uuid/roles, so IDs do not blow up the label set.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// RecordSignIn counts one sign-in outcome
+func (m *Metrics) RecordSignIn(outcome string) {
+	m.signIns.WithLabelValues(outcome).Inc()
+}
+
+// Gatherer returns the registry behind Handler
+func (m *Metrics) Gatherer() prometheus.Gatherer {
+	return m.registry
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
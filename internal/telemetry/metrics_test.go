@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+	"go-booking-system/internal/repository"
+	"go-booking-system/internal/service"
+	"strings"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRegisterDB(t *testing.T) {
+	// Opening does not connect; the pool stats are read from the handle
+	db, err := sql.Open("pgx", "host=localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	db.SetMaxOpenConns(7)
+
+	metrics := NewMetrics()
+	if err := metrics.RegisterDB(db, "postgres"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP go_sql_max_open_connections Maximum number of open connections to the database.
+# TYPE go_sql_max_open_connections gauge
+go_sql_max_open_connections{db_name="postgres"} 7
+# HELP go_sql_open_connections The number of established connections both in use and idle.
+# TYPE go_sql_open_connections gauge
+go_sql_open_connections{db_name="postgres"} 0
+`
+	if err := testutil.GatherAndCompare(metrics.registry, strings.NewReader(expected),
+		"go_sql_max_open_connections", "go_sql_open_connections"); err != nil {
+		t.Error(err)
+	}
+
+	// A second pool under the same name would be ambiguous
+	if err := metrics.RegisterDB(db, "postgres"); err == nil {
+		t.Error("registered the same database twice")
+	}
+}
+
+func TestInstrumentLoginGuard(t *testing.T) {
+	ctx := context.Background()
+	metrics := NewMetrics()
+	guard := InstrumentLoginGuard(service.NewLoginGuard(repository.NewMemoryLoginAttemptRepository()), metrics)
+
+	// Five wrong passwords lock the account, then a correct one is refused
+	for i := 0; i < 5; i++ {
+		if err := guard.Check(ctx, "guest@example.com", "192.0.2.1"); err != nil {
+			t.Fatalf("check %d: %v", i+1, err)
+		}
+		if err := guard.RecordFailure(ctx, "guest@example.com", "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := guard.Check(ctx, "guest@example.com", "192.0.2.1"); err == nil {
+		t.Fatal("account was not locked")
+	}
+
+	// Another account signs in from elsewhere
+	if err := guard.Check(ctx, "host@example.com", "198.51.100.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.RecordSuccess(ctx, "host@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reads and housekeeping are not sign-ins
+	if _, err := guard.AccountAttempts(ctx, "guest@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guard.PurgeExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for outcome, want := range map[string]float64{SignInSuccess: 1, SignInFailure: 5, SignInLocked: 1} {
+		if got := testutil.ToFloat64(metrics.signIns.WithLabelValues(outcome)); got != want {
+			t.Errorf("signins_total{outcome=%q} = %v, want %v", outcome, got, want)
+		}
+	}
+	if series := testutil.CollectAndCount(metrics.signIns); series != 3 {
+		t.Errorf("signins_total series = %d, want 3", series)
+	}
+}
@@ -0,0 +1,59 @@
+// Package telemetry wires up OpenTelemetry tracing and Prometheus metrics
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// TracingConfig selects where spans go
+type TracingConfig struct {
+	ServiceName string
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://localhost:4318.
+	// Tracing is disabled when it is empty.
+	Endpoint string
+	// SampleRatio is the fraction of new traces to record, from 0 to 1.
+	// Traces started upstream follow the caller's sampling decision.
+	SampleRatio float64
+}
+
+// SetupTracing installs the global tracer provider and W3C propagators. The
+// returned function flushes buffered spans and must be called on shutdown.
+func SetupTracing(ctx context.Context, cfg TracingConfig) (func(context.Context) error, error) {
+	// Propagate incoming trace context even when this service does not export
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS o_auth_states;
+DROP TABLE IF EXISTS linked_identities;
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS one_time_tokens;
+DROP TABLE IF EXISTS user_revocations;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS country;
+DROP TABLE IF EXISTS users;
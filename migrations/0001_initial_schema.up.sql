@@ -0,0 +1,178 @@
+-- Baseline: the schema GORM AutoMigrate created before versioned migrations.
+-- IF NOT EXISTS lets databases created that way adopt migrations unchanged.
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    email text NOT NULL,
+    password text NOT NULL,
+    name text NOT NULL,
+    mobile_country_id bigint DEFAULT NULL,
+    phone text,
+    phone_verified_at timestamptz,
+    email_verified_at timestamptz,
+    totp_secret text,
+    totp_enabled_at timestamptz,
+    totp_last_step bigint DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    anonymized_at timestamptz,
+    uuid text NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+-- Only one live account may have a given verified phone number
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_verified_phone ON users (phone)
+    WHERE phone_verified_at IS NOT NULL AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS country (
+    id bigserial PRIMARY KEY,
+    name varchar(255),
+    shortname varchar(255),
+    country_code bigint,
+    currency_name varchar(255),
+    currency_code varchar(255),
+    currency_symbol varchar(255),
+    currency_rate decimal,
+    latitude decimal,
+    longitude decimal,
+    timezone_name varchar(255),
+    name_variant varchar(255),
+    created_time timestamptz,
+    last_modified varchar(255),
+    paypal_transaction_surcharge decimal,
+    paypal_payout_fee decimal,
+    name_zh_cn varchar(255),
+    name_zh_tw varchar(255),
+    referral_reward bigint,
+    search_radius bigint,
+    gmt varchar(255),
+    name_cs_cz varchar(255),
+    name_th varchar(255),
+    name_ja_jp varchar(255),
+    name_ko_kr varchar(255),
+    no_decimal_currency bigint,
+    has_vat_gst bigint,
+    deposit_only bigint,
+    points_multiplier bigint,
+    allow_share bigint,
+    referral_host_reward bigint,
+    host_expected_earnings bigint,
+    platform_fee_percent bigint,
+    hits bigint
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id bigserial PRIMARY KEY,
+    user_uuid text NOT NULL,
+    family_id text NOT NULL,
+    token_hash text NOT NULL,
+    device_label text,
+    expires_at timestamptz NOT NULL,
+    rotated_at timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_uuid ON sessions (user_uuid);
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions (family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_token_hash ON sessions (token_hash);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    id bigserial PRIMARY KEY,
+    jti text NOT NULL,
+    user_uuid text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_revoked_tokens_jti ON revoked_tokens (jti);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_uuid ON revoked_tokens (user_uuid);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS user_revocations (
+    user_uuid text PRIMARY KEY,
+    revoked_before timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_user_revocations_expires_at ON user_revocations (expires_at);
+
+CREATE TABLE IF NOT EXISTS one_time_tokens (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    purpose text NOT NULL,
+    token_hash text NOT NULL,
+    target text,
+    expires_at timestamptz NOT NULL,
+    consumed_at timestamptz,
+    created_at timestamptz,
+    failed_attempts bigint NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_one_time_tokens_user_id ON one_time_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_one_time_tokens_purpose ON one_time_tokens (purpose);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_one_time_tokens_token_hash ON one_time_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key text PRIMARY KEY,
+    failures bigint NOT NULL DEFAULT 0,
+    last_failed_at timestamptz NOT NULL,
+    locked_until timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    code_hash text NOT NULL,
+    used_at timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    role varchar(32) NOT NULL,
+    created_at timestamptz,
+    CONSTRAINT fk_users_roles FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_roles_user_role ON user_roles (user_id, role);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id bigserial PRIMARY KEY,
+    actor_uuid text,
+    action varchar(64) NOT NULL,
+    target_uuid text,
+    detail text,
+    client_ip text,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_uuid ON audit_events (actor_uuid);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events (action);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target_uuid ON audit_events (target_uuid);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
+
+CREATE TABLE IF NOT EXISTS linked_identities (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    provider varchar(32) NOT NULL,
+    subject text NOT NULL,
+    email text,
+    created_at timestamptz,
+    last_used_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_linked_identities_user_id ON linked_identities (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_linked_identities_user_provider ON linked_identities (user_id, provider);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_linked_identities_provider_subject ON linked_identities (provider, subject);
+
+CREATE TABLE IF NOT EXISTS o_auth_states (
+    id bigserial PRIMARY KEY,
+    state_hash text NOT NULL,
+    provider varchar(32) NOT NULL,
+    nonce text NOT NULL,
+    code_verifier text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    consumed_at timestamptz,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_o_auth_states_state_hash ON o_auth_states (state_hash);
+CREATE INDEX IF NOT EXISTS idx_o_auth_states_expires_at ON o_auth_states (expires_at);
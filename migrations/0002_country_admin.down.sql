@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_country_shortname;
+
+ALTER TABLE country DROP COLUMN IF EXISTS disabled_at;
+
+ALTER TABLE country ALTER COLUMN last_modified TYPE varchar(255)
+    USING to_char(last_modified AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"');
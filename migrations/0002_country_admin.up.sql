@@ -0,0 +1,16 @@
+-- Countries become editable through the admin API
+
+-- last_modified was free-form text; keep the values that look like timestamps
+ALTER TABLE country ALTER COLUMN last_modified TYPE timestamptz
+    USING CASE WHEN last_modified ~ '^\d{4}-\d{2}-\d{2}' THEN last_modified::timestamptz END;
+
+-- Disabled countries stay referenced by users but are no longer offered
+ALTER TABLE country ADD COLUMN IF NOT EXISTS disabled_at timestamptz;
+
+-- Shortnames are the key admins and imports address countries by
+UPDATE country SET shortname = upper(trim(shortname)) WHERE shortname <> upper(trim(shortname));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_country_shortname ON country (shortname);
+
+-- Rows loaded with explicit ids can leave the sequence behind, which would
+-- make the first country created through the API collide
+SELECT setval(pg_get_serial_sequence('country', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM country;
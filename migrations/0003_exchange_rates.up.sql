@@ -0,0 +1,20 @@
+-- History of exchange rates, so past conversions can be reproduced
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id bigserial PRIMARY KEY,
+    currency_code varchar(3) NOT NULL,
+    rate numeric NOT NULL CHECK (rate > 0),
+    source varchar(64) NOT NULL,
+    effective_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+-- Serves "latest rate at or before T" per currency
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_rates_currency_effective ON exchange_rates (currency_code, effective_at);
+
+-- Start the history with the rates the country table holds today
+INSERT INTO exchange_rates (currency_code, rate, source, effective_at, created_at)
+SELECT DISTINCT ON (upper(currency_code))
+    upper(currency_code), currency_rate, 'country', COALESCE(last_modified, created_time, now()), now()
+FROM country
+WHERE currency_code IS NOT NULL AND currency_rate > 0
+ORDER BY upper(currency_code), shortname
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,13 @@
+// Package migrations embeds the versioned SQL migrations of the schema.
+//
+// Each version is a pair of files, NNNN_name.up.sql and NNNN_name.down.sql,
+// applied in version order by internal/migrate. Create new pairs with
+// go run ./cmd/migrate create NAME; never edit a file once it has shipped.
+package migrations
+
+import "embed"
+
+// FS holds every migration file
+//
+//go:embed *.sql
+var FS embed.FS